@@ -0,0 +1,121 @@
+// Package bucket provides generators and presets of histogram buckets. The
+// returned buckets can be given to the HistogramConfig of any kind of
+// publisher. Buckets are given in the unit of the histogram, which is second
+// for the latency presets.
+package bucket
+
+import (
+	"math"
+)
+
+// Exponential returns count buckets, where the lowest bucket has an upper bound
+// of start and every following bucket's upper bound is factor times the
+// previous one. Start must be greater than 0 and factor must be greater than 1.
+func Exponential(start float64, factor float64, count int) ([]float64, error) {
+	if count < 1 {
+		return nil, maskAnyf(invalidBucketsError, "count must be greater than 0")
+	}
+	if !isFinite(start) || start <= 0 {
+		return nil, maskAnyf(invalidBucketsError, "start must be greater than 0")
+	}
+	if !isFinite(factor) || factor <= 1 {
+		return nil, maskAnyf(invalidBucketsError, "factor must be greater than 1")
+	}
+
+	buckets := make([]float64, count)
+	for i := range buckets {
+		buckets[i] = start
+		start *= factor
+	}
+
+	err := Validate(buckets)
+	if err != nil {
+		return nil, maskAny(err)
+	}
+
+	return buckets, nil
+}
+
+// ExponentialRange returns count buckets, where the lowest bucket has an upper
+// bound of min and the highest bucket has an upper bound of max. The upper
+// bounds in between grow by a constant factor. Min must be greater than 0, max
+// must be greater than min and count must be at least 2.
+func ExponentialRange(min float64, max float64, count int) ([]float64, error) {
+	if count < 2 {
+		return nil, maskAnyf(invalidBucketsError, "count must be at least 2")
+	}
+	if !isFinite(min) || min <= 0 {
+		return nil, maskAnyf(invalidBucketsError, "min must be greater than 0")
+	}
+	if !isFinite(max) || max <= min {
+		return nil, maskAnyf(invalidBucketsError, "max must be greater than min")
+	}
+
+	factor := math.Pow(max/min, 1/float64(count-1))
+
+	buckets := make([]float64, count)
+	for i := range buckets {
+		buckets[i] = min * math.Pow(factor, float64(i))
+	}
+	// The highest upper bound is set explicitly, so that it is not affected by
+	// rounding errors.
+	buckets[count-1] = max
+
+	err := Validate(buckets)
+	if err != nil {
+		return nil, maskAny(err)
+	}
+
+	return buckets, nil
+}
+
+// Linear returns count buckets, where the lowest bucket has an upper bound of
+// start and every following bucket's upper bound is width greater than the
+// previous one. Width must be greater than 0.
+func Linear(start float64, width float64, count int) ([]float64, error) {
+	if count < 1 {
+		return nil, maskAnyf(invalidBucketsError, "count must be greater than 0")
+	}
+	if !isFinite(start) {
+		return nil, maskAnyf(invalidBucketsError, "start must be finite")
+	}
+	if !isFinite(width) || width <= 0 {
+		return nil, maskAnyf(invalidBucketsError, "width must be greater than 0")
+	}
+
+	buckets := make([]float64, count)
+	for i := range buckets {
+		buckets[i] = start + float64(i)*width
+	}
+
+	err := Validate(buckets)
+	if err != nil {
+		return nil, maskAny(err)
+	}
+
+	return buckets, nil
+}
+
+// Validate checks whether the given buckets are usable by a histogram. There
+// must be at least one bucket and all upper bounds must be finite and strictly
+// increasing. Otherwise an error asserted by IsInvalidBuckets is returned.
+func Validate(buckets []float64) error {
+	if len(buckets) == 0 {
+		return maskAnyf(invalidBucketsError, "buckets must not be empty")
+	}
+
+	for i, b := range buckets {
+		if !isFinite(b) {
+			return maskAnyf(invalidBucketsError, "bucket %d must be finite, got %v", i, b)
+		}
+		if i > 0 && b <= buckets[i-1] {
+			return maskAnyf(invalidBucketsError, "bucket %d must be greater than bucket %d, got %v after %v", i, i-1, b, buckets[i-1])
+		}
+	}
+
+	return nil
+}
+
+func isFinite(f float64) bool {
+	return !math.IsNaN(f) && !math.IsInf(f, 0)
+}
@@ -0,0 +1,172 @@
+package bucket
+
+import (
+	"math"
+	"testing"
+)
+
+// equal checks whether the given buckets are equal, allowing for rounding
+// errors of the generators.
+func equal(a, b []float64) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if math.Abs(a[i]-b[i]) > 1e-9*math.Max(1, math.Abs(b[i])) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func TestExponential(t *testing.T) {
+	testCases := []struct {
+		Start  float64
+		Factor float64
+		Count  int
+		Want   []float64
+		Valid  bool
+	}{
+		{Start: 1, Factor: 2, Count: 4, Want: []float64{1, 2, 4, 8}, Valid: true},
+		{Start: 0.005, Factor: 10, Count: 3, Want: []float64{0.005, 0.05, 0.5}, Valid: true},
+		{Start: 1, Factor: 2, Count: 1, Want: []float64{1}, Valid: true},
+		{Start: 1, Factor: 2, Count: 0, Valid: false},
+		{Start: 0, Factor: 2, Count: 3, Valid: false},
+		{Start: -1, Factor: 2, Count: 3, Valid: false},
+		{Start: 1, Factor: 1, Count: 3, Valid: false},
+		{Start: 1, Factor: math.Inf(1), Count: 3, Valid: false},
+		{Start: math.NaN(), Factor: 2, Count: 3, Valid: false},
+		// The upper bounds overflow to +Inf.
+		{Start: 1e300, Factor: 1e10, Count: 3, Valid: false},
+	}
+
+	for i, tc := range testCases {
+		buckets, err := Exponential(tc.Start, tc.Factor, tc.Count)
+		if !tc.Valid {
+			if !IsInvalidBuckets(err) {
+				t.Fatalf("test case %d: expected invalid buckets error, got %v", i, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("test case %d: expected no error, got %v", i, err)
+		}
+		if !equal(buckets, tc.Want) {
+			t.Fatalf("test case %d: expected %v, got %v", i, tc.Want, buckets)
+		}
+	}
+}
+
+func TestExponentialRange(t *testing.T) {
+	testCases := []struct {
+		Min   float64
+		Max   float64
+		Count int
+		Want  []float64
+		Valid bool
+	}{
+		{Min: 1, Max: 8, Count: 4, Want: []float64{1, 2, 4, 8}, Valid: true},
+		{Min: 0.001, Max: 10, Count: 5, Want: []float64{0.001, 0.01, 0.1, 1, 10}, Valid: true},
+		{Min: 1, Max: 2, Count: 2, Want: []float64{1, 2}, Valid: true},
+		{Min: 1, Max: 8, Count: 1, Valid: false},
+		{Min: 0, Max: 8, Count: 4, Valid: false},
+		{Min: 8, Max: 8, Count: 4, Valid: false},
+		{Min: 8, Max: 1, Count: 4, Valid: false},
+		{Min: 1, Max: math.Inf(1), Count: 4, Valid: false},
+	}
+
+	for i, tc := range testCases {
+		buckets, err := ExponentialRange(tc.Min, tc.Max, tc.Count)
+		if !tc.Valid {
+			if !IsInvalidBuckets(err) {
+				t.Fatalf("test case %d: expected invalid buckets error, got %v", i, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("test case %d: expected no error, got %v", i, err)
+		}
+		if !equal(buckets, tc.Want) {
+			t.Fatalf("test case %d: expected %v, got %v", i, tc.Want, buckets)
+		}
+		// The bounds are exact, even though the factor is not.
+		if buckets[0] != tc.Min || buckets[len(buckets)-1] != tc.Max {
+			t.Fatalf("test case %d: expected bounds %v and %v, got %v", i, tc.Min, tc.Max, buckets)
+		}
+	}
+}
+
+func TestLinear(t *testing.T) {
+	testCases := []struct {
+		Start float64
+		Width float64
+		Count int
+		Want  []float64
+		Valid bool
+	}{
+		{Start: 0, Width: 10, Count: 3, Want: []float64{0, 10, 20}, Valid: true},
+		{Start: -1, Width: 0.5, Count: 4, Want: []float64{-1, -0.5, 0, 0.5}, Valid: true},
+		{Start: 0.1, Width: 0.1, Count: 3, Want: []float64{0.1, 0.2, 0.3}, Valid: true},
+		{Start: 0, Width: 10, Count: 0, Valid: false},
+		{Start: 0, Width: 0, Count: 3, Valid: false},
+		{Start: 0, Width: -1, Count: 3, Valid: false},
+		{Start: math.Inf(-1), Width: 1, Count: 3, Valid: false},
+		{Start: 0, Width: math.NaN(), Count: 3, Valid: false},
+	}
+
+	for i, tc := range testCases {
+		buckets, err := Linear(tc.Start, tc.Width, tc.Count)
+		if !tc.Valid {
+			if !IsInvalidBuckets(err) {
+				t.Fatalf("test case %d: expected invalid buckets error, got %v", i, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("test case %d: expected no error, got %v", i, err)
+		}
+		if !equal(buckets, tc.Want) {
+			t.Fatalf("test case %d: expected %v, got %v", i, tc.Want, buckets)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	testCases := []struct {
+		Buckets []float64
+		Valid   bool
+	}{
+		{Buckets: []float64{1}, Valid: true},
+		{Buckets: []float64{-1, 0, 0.5, 1}, Valid: true},
+		{Buckets: nil, Valid: false},
+		{Buckets: []float64{}, Valid: false},
+		{Buckets: []float64{1, 1}, Valid: false},
+		{Buckets: []float64{2, 1}, Valid: false},
+		{Buckets: []float64{1, math.NaN()}, Valid: false},
+		{Buckets: []float64{1, math.Inf(1)}, Valid: false},
+	}
+
+	for i, tc := range testCases {
+		err := Validate(tc.Buckets)
+		if tc.Valid && err != nil {
+			t.Fatalf("test case %d: expected no error, got %v", i, err)
+		}
+		if !tc.Valid && !IsInvalidBuckets(err) {
+			t.Fatalf("test case %d: expected invalid buckets error, got %v", i, err)
+		}
+	}
+}
+
+func TestPresets(t *testing.T) {
+	for name, buckets := range map[string][]float64{
+		"DBLatency":   DBLatency(),
+		"HTTPLatency": HTTPLatency(),
+		"PayloadSize": PayloadSize(),
+	} {
+		err := Validate(buckets)
+		if err != nil {
+			t.Fatalf("expected preset %s to be valid, got %v", name, err)
+		}
+	}
+}
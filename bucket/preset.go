@@ -0,0 +1,19 @@
+package bucket
+
+// DBLatency returns buckets in seconds suited for the latency of database
+// queries, ranging from 100 microseconds to 5 seconds.
+func DBLatency() []float64 {
+	return []float64{.0001, .00025, .0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5}
+}
+
+// HTTPLatency returns buckets in seconds suited for the latency of HTTP
+// requests, ranging from 5 milliseconds to 10 seconds.
+func HTTPLatency() []float64 {
+	return []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+}
+
+// PayloadSize returns buckets in bytes suited for the size of request and
+// response payloads, ranging from 64 bytes to 16 megabytes.
+func PayloadSize() []float64 {
+	return []float64{64, 256, 1024, 4096, 16384, 65536, 262144, 1048576, 4194304, 16777216}
+}
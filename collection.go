@@ -3,91 +3,308 @@ package instrumentor
 import (
 	"net/http"
 	"sync"
+	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
 
+	graphitepublisher "github.com/the-anna-project/instrumentor/graphite/publisher"
+	influxdbpublisher "github.com/the-anna-project/instrumentor/influxdb/publisher"
 	memoryconsumer "github.com/the-anna-project/instrumentor/memory/consumer"
 	memorypublisher "github.com/the-anna-project/instrumentor/memory/publisher"
+	memorystorage "github.com/the-anna-project/instrumentor/memory/storage"
+	multipublisher "github.com/the-anna-project/instrumentor/multi/publisher"
+	otlppublisher "github.com/the-anna-project/instrumentor/otlp/publisher"
 	prometheusconsumer "github.com/the-anna-project/instrumentor/prometheus/consumer"
 	prometheuspublisher "github.com/the-anna-project/instrumentor/prometheus/publisher"
 	"github.com/the-anna-project/instrumentor/spec"
+	statsdconsumer "github.com/the-anna-project/instrumentor/statsd/consumer"
+	statsdpublisher "github.com/the-anna-project/instrumentor/statsd/publisher"
 )
 
 const (
+	// KindGraphite is the kind to be used to create a collection of Graphite
+	// instrumentor services. Metrics are aggregated in memory and can be read
+	// back using the collection's consumer.
+	KindGraphite = "graphite"
+	// KindInfluxDB is the kind to be used to create a collection of InfluxDB
+	// instrumentor services. Metrics are aggregated in memory and can be read
+	// back using the collection's consumer.
+	KindInfluxDB = "influxdb"
 	// KindMemory is the kind to be used to create a memory instrumentor services.
 	KindMemory = "memory"
+	// KindOTLP is the kind to be used to create a collection of OpenTelemetry
+	// instrumentor services exporting metrics via OTLP/HTTP. Metrics are
+	// aggregated in memory and can be read back using the collection's consumer.
+	KindOTLP = "otlp"
 	// KindPrometheus is the kind to be used to create a collection of prometheus
 	// instrumentor services.
 	KindPrometheus = "prometheus"
+	// KindStatsD is the kind to be used to create a collection of StatsD
+	// instrumentor services. Metrics sent to StatsD cannot be read back using
+	// the collection's consumer.
+	KindStatsD = "statsd"
 )
 
 // CollectionConfig represents the configuration used to create a new
 // collection.
 type CollectionConfig struct {
+	// Dependencies.
+
+	// Gatherer is used by the prometheus kind to gather metrics. It must be the
+	// same registry as Registerer.
+	Gatherer prometheus.Gatherer
+	// HTTPHandler is used by the prometheus kind to serve metrics at
+	// HTTPEndpoint. In case it is nil, which is the default, a handler serving
+	// the metrics gathered using Gatherer is used.
+	HTTPHandler http.Handler
+	// Registerer is used by the prometheus kind to register metrics. Collections
+	// sharing a registry share metric names, so each collection should be given
+	// its own registry in case more than one is used within the same process.
+	Registerer prometheus.Registerer
+
 	// Settings.
-	HTTPEndpoint string
-	HTTPHandler  http.Handler
-	Kind         string
-	Prefixes     []string
+
+	// ConstLabels represents labels having the same value for all metrics
+	// created by the collection's publisher, e.g. the name of the service or its
+	// version.
+	ConstLabels map[string]string
+	// GraphiteAddress represents the TCP address of the Graphite plaintext
+	// receiver used by the Graphite kind.
+	GraphiteAddress string
+	// GraphiteFlushInterval represents the interval in which the Graphite kind
+	// writes aggregated metrics.
+	GraphiteFlushInterval time.Duration
+	HTTPEndpoint          string
+	// InfluxDBAddress represents the URL of the InfluxDB endpoint used by the
+	// InfluxDB kind. See influxdbpublisher.ClientConfig.Address for the
+	// supported schemes.
+	InfluxDBAddress string
+	// InfluxDBFlushInterval represents the interval in which the InfluxDB kind
+	// writes aggregated metrics.
+	InfluxDBFlushInterval time.Duration
+	// InfluxDBToken represents the API token used by the InfluxDB kind in order
+	// to authenticate against the InfluxDB 2 write API.
+	InfluxDBToken string
+	// Kind represents the kind of the collection's services. It is only used
+	// in case Kinds is empty.
+	Kind string
+	// Kinds represents the kinds of publishers every metric is emitted to at
+	// the same time, e.g. during a migration from one backend to another. The
+	// collection's consumer reads metrics back from the first kind. In case
+	// Kinds is empty, Kind is used.
+	Kinds []string
+	// OTLPEndpoint represents the URL of the OTLP/HTTP metrics endpoint used by
+	// the OTLP kind.
+	OTLPEndpoint string
+	// OTLPExportInterval represents the interval in which the OTLP kind exports
+	// aggregated metrics.
+	OTLPExportInterval time.Duration
+	// OTLPHeaders represents additional HTTP headers the OTLP kind sends with
+	// every export request.
+	OTLPHeaders map[string]string
+	// OTLPResourceAttributes represents the attributes of the resource the OTLP
+	// kind exports all metrics with, e.g. service.name.
+	OTLPResourceAttributes map[string]string
+	// PanicMode describes how panics of actions wrapped by the publisher are
+	// handled. It is one of spec.PanicModeNone, spec.PanicModeRecover or
+	// spec.PanicModeRepanic.
+	PanicMode string
+	Prefixes  []string
+	// PrometheusCardinalityMode describes how the prometheus kind handles label
+	// combinations exceeding its cardinality limits. It is one of
+	// prometheuspublisher.CardinalityModeDrop or
+	// prometheuspublisher.CardinalityModeOverflow.
+	PrometheusCardinalityMode string
+	// PrometheusMaxSeries represents the maximum number of series of all
+	// labelled metrics of the prometheus kind. There is no limit in case it is
+	// 0. The other kinds do not limit the number of series.
+	PrometheusMaxSeries int
+	// PrometheusMaxSeriesPerMetric represents the maximum number of series of a
+	// single labelled metric of the prometheus kind. There is no limit in case
+	// it is 0.
+	PrometheusMaxSeriesPerMetric int
+	// PrometheusSeriesTTL represents the default TTL of the metrics of the
+	// prometheus kind. A series of a labelled metric not touched within the TTL
+	// is deleted. Series never expire in case it is 0. The other kinds do not
+	// expire series.
+	PrometheusSeriesTTL time.Duration
+	// PushgatewayGrouping represents the grouping labels the prometheus kind
+	// pushes metrics with, next to PushgatewayJob.
+	PushgatewayGrouping map[string]string
+	// PushgatewayInterval represents the interval in which the prometheus kind
+	// pushes metrics.
+	PushgatewayInterval time.Duration
+	// PushgatewayJob represents the job name the prometheus kind pushes metrics
+	// with.
+	PushgatewayJob string
+	// PushgatewayMethod represents the HTTP method the prometheus kind pushes
+	// metrics with. It is one of prometheuspublisher.PushMethodPost or
+	// prometheuspublisher.PushMethodPut.
+	PushgatewayMethod string
+	// PushgatewayTimeout represents the maximum duration of a single push of the
+	// prometheus kind to the Pushgateway.
+	PushgatewayTimeout time.Duration
+	// PushgatewayURL represents the URL of the Pushgateway the prometheus kind
+	// pushes metrics to. Pushing is disabled in case it is empty.
+	PushgatewayURL string
+	// SanitizeKeys causes NewKey of the collection's publisher to replace all
+	// characters not being allowed in metric names by underscores.
+	SanitizeKeys bool
+	// StatsDAddress represents the UDP address of the StatsD agent used by the
+	// StatsD kind.
+	StatsDAddress string
+	// StatsDFlushInterval represents the interval in which the StatsD kind sends
+	// buffered metrics.
+	StatsDFlushInterval time.Duration
+	// StatsDMaxPacketSize represents the maximum number of bytes the StatsD kind
+	// sends within a single UDP packet.
+	StatsDMaxPacketSize int
 }
 
 // DefaultCollectionConfig provides a default configuration to create a new
 // collection by best effort.
 func DefaultCollectionConfig() CollectionConfig {
+	graphiteConfig := graphitepublisher.DefaultServiceConfig()
+	influxdbConfig := influxdbpublisher.DefaultServiceConfig()
+	otlpConfig := otlppublisher.DefaultServiceConfig()
+	prometheusConfig := prometheuspublisher.DefaultServiceConfig()
+	statsdConfig := statsdpublisher.DefaultServiceConfig()
+
 	return CollectionConfig{
+		// Dependencies.
+		Gatherer:    prometheus.DefaultGatherer,
+		HTTPHandler: prometheusConfig.HTTPHandler,
+		Registerer:  prometheus.DefaultRegisterer,
+
 		// Settings.
-		HTTPEndpoint: "/metrics",
-		HTTPHandler:  prometheus.Handler(),
-		Kind:         KindMemory,
-		Prefixes:     []string{},
+		ConstLabels:                  map[string]string{},
+		GraphiteAddress:              graphiteConfig.Address,
+		GraphiteFlushInterval:        graphiteConfig.FlushInterval,
+		HTTPEndpoint:                 prometheusConfig.HTTPEndpoint,
+		InfluxDBAddress:              influxdbConfig.Address,
+		InfluxDBFlushInterval:        influxdbConfig.FlushInterval,
+		InfluxDBToken:                influxdbConfig.Token,
+		Kind:                         KindMemory,
+		Kinds:                        nil,
+		OTLPEndpoint:                 otlpConfig.Endpoint,
+		OTLPExportInterval:           otlpConfig.ExportInterval,
+		OTLPHeaders:                  otlpConfig.Headers,
+		OTLPResourceAttributes:       otlpConfig.ResourceAttributes,
+		PanicMode:                    spec.PanicModeNone,
+		Prefixes:                     []string{},
+		PrometheusCardinalityMode:    prometheusConfig.CardinalityMode,
+		PrometheusMaxSeries:          prometheusConfig.MaxSeries,
+		PrometheusMaxSeriesPerMetric: prometheusConfig.MaxSeriesPerMetric,
+		PrometheusSeriesTTL:          prometheusConfig.SeriesTTL,
+		PushgatewayGrouping:          prometheusConfig.PushGrouping,
+		PushgatewayInterval:          prometheusConfig.PushInterval,
+		PushgatewayJob:               prometheusConfig.PushJob,
+		PushgatewayMethod:            prometheusConfig.PushMethod,
+		PushgatewayTimeout:           prometheusConfig.PushTimeout,
+		PushgatewayURL:               prometheusConfig.PushURL,
+		SanitizeKeys:                 false,
+		StatsDAddress:                statsdConfig.Address,
+		StatsDFlushInterval:          statsdConfig.FlushInterval,
+		StatsDMaxPacketSize:          statsdConfig.MaxPacketSize,
 	}
 }
 
 // NewCollection creates a new configured storage Collection.
 func NewCollection(config CollectionConfig) (*Collection, error) {
 	// Settings.
-	if config.Kind == "" {
-		return nil, maskAnyf(invalidConfigError, "kind must not be empty")
+	kinds := config.Kinds
+	if len(kinds) == 0 {
+		kinds = []string{config.Kind}
 	}
-	if config.Kind != KindMemory && config.Kind != KindPrometheus {
-		return nil, maskAnyf(invalidConfigError, "kind must be one of: %s, %s", KindMemory, KindPrometheus)
+	seen := map[string]bool{}
+	for _, k := range kinds {
+		if k == "" {
+			return nil, maskAnyf(invalidConfigError, "kind must not be empty")
+		}
+		if k != KindGraphite && k != KindInfluxDB && k != KindMemory && k != KindOTLP && k != KindPrometheus && k != KindStatsD {
+			return nil, maskAnyf(invalidConfigError, "kind must be one of: %s, %s, %s, %s, %s, %s", KindGraphite, KindInfluxDB, KindMemory, KindOTLP, KindPrometheus, KindStatsD)
+		}
+		if seen[k] {
+			return nil, maskAnyf(invalidConfigError, "kinds must not contain %s more than once", k)
+		}
+		seen[k] = true
 	}
 
 	var err error
 
-	var consumerService spec.Consumer
-	{
-		switch config.Kind {
-		case KindMemory:
-			consumerConfig := memoryconsumer.DefaultServiceConfig()
-			consumerService, err = memoryconsumer.NewService(consumerConfig)
+	// The memory consumer reads back what the memory, Graphite, InfluxDB and
+	// OTLP publishers record, so the publisher of the first kind and the
+	// consumer share the same storage. Every other kind aggregating metrics in
+	// memory gets a storage of its own, since metric names are unique within a
+	// storage.
+	//
+	// The prometheus consumer uses the prometheus publisher to look up the label
+	// names metrics have been configured with.
+	var memoryStorage *memorystorage.Storage
+	var prometheusPublisher *prometheuspublisher.Service
+	var publisherServices []spec.Publisher
+	for i, k := range kinds {
+		var s *memorystorage.Storage
+		if k == KindGraphite || k == KindInfluxDB || k == KindMemory || k == KindOTLP {
+			s, err = memorystorage.NewStorage(memorystorage.DefaultStorageConfig())
 			if err != nil {
 				return nil, maskAny(err)
 			}
-		case KindPrometheus:
-			consumerConfig := prometheusconsumer.DefaultServiceConfig()
-			consumerService, err = prometheusconsumer.NewService(consumerConfig)
+		}
+
+		var p spec.Publisher
+		p, err = newPublisher(config, k, s)
+		if err != nil {
+			return nil, maskAny(err)
+		}
+
+		if i == 0 {
+			memoryStorage = s
+			prometheusPublisher, _ = p.(*prometheuspublisher.Service)
+		}
+		publisherServices = append(publisherServices, p)
+	}
+
+	// Multiple kinds are combined using the multi publisher, which emits every
+	// metric to the publishers of all kinds.
+	var publisherService spec.Publisher
+	{
+		if len(publisherServices) == 1 {
+			publisherService = publisherServices[0]
+		} else {
+			publisherConfig := multipublisher.DefaultServiceConfig()
+			publisherConfig.Publishers = publisherServices
+			publisherConfig.PanicMode = config.PanicMode
+			publisherConfig.Prefixes = config.Prefixes
+			publisherConfig.SanitizeKeys = config.SanitizeKeys
+			publisherService, err = multipublisher.NewService(publisherConfig)
 			if err != nil {
 				return nil, maskAny(err)
 			}
 		}
 	}
 
-	var publisherService spec.Publisher
+	var consumerService spec.Consumer
 	{
-		switch config.Kind {
-		case KindMemory:
-			publisherConfig := memorypublisher.DefaultServiceConfig()
-			publisherService, err = memorypublisher.NewService(publisherConfig)
+		switch kinds[0] {
+		case KindGraphite, KindInfluxDB, KindMemory, KindOTLP:
+			consumerConfig := memoryconsumer.DefaultServiceConfig()
+			consumerConfig.Storage = memoryStorage
+			consumerService, err = memoryconsumer.NewService(consumerConfig)
 			if err != nil {
 				return nil, maskAny(err)
 			}
 		case KindPrometheus:
-			publisherConfig := prometheuspublisher.DefaultServiceConfig()
-			publisherConfig.HTTPEndpoint = config.HTTPEndpoint
-			publisherConfig.HTTPHandler = config.HTTPHandler
-			publisherConfig.Prefixes = config.Prefixes
-			publisherService, err = prometheuspublisher.NewService(publisherConfig)
+			consumerConfig := prometheusconsumer.DefaultServiceConfig()
+			consumerConfig.Gatherer = config.Gatherer
+			consumerConfig.Labeler = prometheusPublisher
+			consumerService, err = prometheusconsumer.NewService(consumerConfig)
+			if err != nil {
+				return nil, maskAny(err)
+			}
+		case KindStatsD:
+			consumerConfig := statsdconsumer.DefaultServiceConfig()
+			consumerService, err = statsdconsumer.NewService(consumerConfig)
 			if err != nil {
 				return nil, maskAny(err)
 			}
@@ -157,3 +374,105 @@ func (c *Collection) Shutdown() {
 		wg.Wait()
 	})
 }
+
+// newPublisher creates the publisher service of the given kind. The given
+// storage is used by kinds aggregating metrics in memory.
+func newPublisher(config CollectionConfig, kind string, memoryStorage *memorystorage.Storage) (spec.Publisher, error) {
+	var err error
+
+	var publisherService spec.Publisher
+	switch kind {
+	case KindGraphite:
+		publisherConfig := graphitepublisher.DefaultServiceConfig()
+		publisherConfig.Storage = memoryStorage
+		publisherConfig.Address = config.GraphiteAddress
+		publisherConfig.FlushInterval = config.GraphiteFlushInterval
+		publisherConfig.ConstLabels = config.ConstLabels
+		publisherConfig.PanicMode = config.PanicMode
+		publisherConfig.Prefixes = config.Prefixes
+		publisherConfig.SanitizeKeys = config.SanitizeKeys
+		publisherService, err = graphitepublisher.NewService(publisherConfig)
+		if err != nil {
+			return nil, maskAny(err)
+		}
+	case KindInfluxDB:
+		publisherConfig := influxdbpublisher.DefaultServiceConfig()
+		publisherConfig.Storage = memoryStorage
+		publisherConfig.Address = config.InfluxDBAddress
+		publisherConfig.FlushInterval = config.InfluxDBFlushInterval
+		publisherConfig.ConstLabels = config.ConstLabels
+		publisherConfig.PanicMode = config.PanicMode
+		publisherConfig.Prefixes = config.Prefixes
+		publisherConfig.SanitizeKeys = config.SanitizeKeys
+		publisherConfig.Token = config.InfluxDBToken
+		publisherService, err = influxdbpublisher.NewService(publisherConfig)
+		if err != nil {
+			return nil, maskAny(err)
+		}
+	case KindMemory:
+		publisherConfig := memorypublisher.DefaultServiceConfig()
+		publisherConfig.Storage = memoryStorage
+		publisherConfig.ConstLabels = config.ConstLabels
+		publisherConfig.PanicMode = config.PanicMode
+		publisherConfig.Prefixes = config.Prefixes
+		publisherConfig.SanitizeKeys = config.SanitizeKeys
+		publisherService, err = memorypublisher.NewService(publisherConfig)
+		if err != nil {
+			return nil, maskAny(err)
+		}
+	case KindOTLP:
+		publisherConfig := otlppublisher.DefaultServiceConfig()
+		publisherConfig.Storage = memoryStorage
+		publisherConfig.Endpoint = config.OTLPEndpoint
+		publisherConfig.ExportInterval = config.OTLPExportInterval
+		publisherConfig.Headers = config.OTLPHeaders
+		publisherConfig.ConstLabels = config.ConstLabels
+		publisherConfig.PanicMode = config.PanicMode
+		publisherConfig.Prefixes = config.Prefixes
+		publisherConfig.ResourceAttributes = config.OTLPResourceAttributes
+		publisherConfig.SanitizeKeys = config.SanitizeKeys
+		publisherService, err = otlppublisher.NewService(publisherConfig)
+		if err != nil {
+			return nil, maskAny(err)
+		}
+	case KindPrometheus:
+		publisherConfig := prometheuspublisher.DefaultServiceConfig()
+		publisherConfig.Gatherer = config.Gatherer
+		publisherConfig.HTTPHandler = config.HTTPHandler
+		publisherConfig.Registerer = config.Registerer
+		publisherConfig.HTTPEndpoint = config.HTTPEndpoint
+		publisherConfig.CardinalityMode = config.PrometheusCardinalityMode
+		publisherConfig.ConstLabels = config.ConstLabels
+		publisherConfig.MaxSeries = config.PrometheusMaxSeries
+		publisherConfig.MaxSeriesPerMetric = config.PrometheusMaxSeriesPerMetric
+		publisherConfig.PanicMode = config.PanicMode
+		publisherConfig.Prefixes = config.Prefixes
+		publisherConfig.PushGrouping = config.PushgatewayGrouping
+		publisherConfig.PushInterval = config.PushgatewayInterval
+		publisherConfig.PushJob = config.PushgatewayJob
+		publisherConfig.PushMethod = config.PushgatewayMethod
+		publisherConfig.PushTimeout = config.PushgatewayTimeout
+		publisherConfig.PushURL = config.PushgatewayURL
+		publisherConfig.SanitizeKeys = config.SanitizeKeys
+		publisherConfig.SeriesTTL = config.PrometheusSeriesTTL
+		publisherService, err = prometheuspublisher.NewService(publisherConfig)
+		if err != nil {
+			return nil, maskAny(err)
+		}
+	case KindStatsD:
+		publisherConfig := statsdpublisher.DefaultServiceConfig()
+		publisherConfig.Address = config.StatsDAddress
+		publisherConfig.FlushInterval = config.StatsDFlushInterval
+		publisherConfig.MaxPacketSize = config.StatsDMaxPacketSize
+		publisherConfig.ConstLabels = config.ConstLabels
+		publisherConfig.PanicMode = config.PanicMode
+		publisherConfig.Prefixes = config.Prefixes
+		publisherConfig.SanitizeKeys = config.SanitizeKeys
+		publisherService, err = statsdpublisher.NewService(publisherConfig)
+		if err != nil {
+			return nil, maskAny(err)
+		}
+	}
+
+	return publisherService, nil
+}
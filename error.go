@@ -4,6 +4,8 @@ import (
 	"fmt"
 
 	"github.com/juju/errgo"
+
+	"github.com/the-anna-project/instrumentor/internal/metric"
 )
 
 var (
@@ -22,9 +24,48 @@ func maskAnyf(err error, f string, v ...interface{}) error {
 	return newErr
 }
 
-var invalidConfigError = errgo.New("invalid config")
+var invalidConfigError = metric.InvalidConfigError
 
-// IsInvalidConfig asserts invalidConfigError.
+// IsInvalidConfig asserts the errors returned in case a collection or any kind
+// of publisher is not configured properly.
 func IsInvalidConfig(err error) bool {
-	return errgo.Cause(err) == invalidConfigError
+	return metric.IsInvalidConfig(err)
+}
+
+// IsAlreadyRegistered asserts the errors returned by any kind of publisher in
+// case a metric name is already registered as another kind of metric.
+func IsAlreadyRegistered(err error) bool {
+	return metric.IsAlreadyRegistered(err)
+}
+
+// IsConflictingDefinition asserts the errors returned by any kind of publisher
+// in case a metric name is already registered with e.g. other labels or
+// buckets.
+func IsConflictingDefinition(err error) bool {
+	return metric.IsConflictingDefinition(err)
+}
+
+// IsPanic asserts the panic errors returned by actions wrapped by any kind of
+// publisher in case the configured panic mode is spec.PanicModeRecover.
+func IsPanic(err error) bool {
+	return metric.IsPanic(err)
+}
+
+// IsMissingLabel asserts the errors returned by any kind of publisher in case a
+// label map lacks one of the labels a metric has been configured with.
+func IsMissingLabel(err error) bool {
+	return metric.IsMissingLabel(err)
+}
+
+// IsUnknownLabel asserts the errors returned by any kind of publisher in case a
+// label map holds a label a metric has not been configured with.
+func IsUnknownLabel(err error) bool {
+	return metric.IsUnknownLabel(err)
+}
+
+// IsInvalidName asserts the errors returned by any kind of publisher in case a
+// metric name or label name is not valid according to the Prometheus and
+// OpenMetrics data model.
+func IsInvalidName(err error) bool {
+	return metric.IsInvalidName(err)
 }
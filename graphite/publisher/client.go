@@ -0,0 +1,182 @@
+package publisher
+
+import (
+	"net"
+	"sync"
+	"time"
+)
+
+// ClientConfig represents the configuration used to create a new Graphite
+// client.
+type ClientConfig struct {
+	// Settings.
+
+	// Address represents the TCP address of the Graphite plaintext receiver
+	// metrics are written to.
+	Address string
+	// MaxBackoff represents the maximum duration the client waits before trying
+	// to reconnect after a failure.
+	MaxBackoff time.Duration
+	// MinBackoff represents the duration the client waits before trying to
+	// reconnect after the first failure. The duration is doubled with every
+	// consecutive failure until MaxBackoff is reached.
+	MinBackoff time.Duration
+	// Timeout represents the maximum duration of connecting to the Graphite
+	// receiver and of writing to it.
+	Timeout time.Duration
+}
+
+// DefaultClientConfig provides a default configuration to create a new Graphite
+// client by best effort.
+func DefaultClientConfig() ClientConfig {
+	return ClientConfig{
+		// Settings.
+		Address:    "127.0.0.1:2003",
+		MaxBackoff: time.Minute,
+		MinBackoff: time.Second,
+		Timeout:    5 * time.Second,
+	}
+}
+
+// NewClient creates a new configured Graphite client. The client connects
+// lazily, so NewClient does not fail in case the Graphite receiver is not yet
+// reachable.
+func NewClient(config ClientConfig) (*Client, error) {
+	// Settings.
+	if config.Address == "" {
+		return nil, maskAnyf(invalidConfigError, "address must not be empty")
+	}
+	if config.MinBackoff <= 0 {
+		return nil, maskAnyf(invalidConfigError, "min backoff must be greater than 0")
+	}
+	if config.MaxBackoff < config.MinBackoff {
+		return nil, maskAnyf(invalidConfigError, "max backoff must not be less than min backoff")
+	}
+	if config.Timeout <= 0 {
+		return nil, maskAnyf(invalidConfigError, "timeout must be greater than 0")
+	}
+
+	newClient := &Client{
+		// Internals.
+		backoff:  0,
+		conn:     nil,
+		mutex:    sync.Mutex{},
+		nextDial: time.Time{},
+
+		// Settings.
+		address:    config.Address,
+		maxBackoff: config.MaxBackoff,
+		minBackoff: config.MinBackoff,
+		timeout:    config.Timeout,
+	}
+
+	return newClient, nil
+}
+
+// Client writes lines to the configured Graphite receiver over a single TCP
+// connection. Once connecting or writing fails, the connection is dropped and
+// the client backs off exponentially before it connects again. All methods are
+// safe for concurrent use.
+type Client struct {
+	// Internals.
+	backoff  time.Duration
+	conn     net.Conn
+	mutex    sync.Mutex
+	nextDial time.Time
+
+	// Settings.
+	address    string
+	maxBackoff time.Duration
+	minBackoff time.Duration
+	timeout    time.Duration
+}
+
+// Close closes the client's connection, if any.
+func (c *Client) Close() error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if c.conn == nil {
+		return nil
+	}
+
+	err := c.conn.Close()
+	c.conn = nil
+	if err != nil {
+		return maskAny(err)
+	}
+
+	return nil
+}
+
+// ForceWrite is like Write, but connects to the Graphite receiver even in case
+// the client is backing off. It is used for writes that cannot be retried,
+// e.g. the final flush on shutdown.
+func (c *Client) ForceWrite(lines []byte) error {
+	err := c.write(lines, true)
+	if err != nil {
+		return maskAny(err)
+	}
+
+	return nil
+}
+
+// Write writes the given lines to the Graphite receiver. In case the client is
+// backing off, nothing is written and an error asserted by IsBackoff is
+// returned.
+func (c *Client) Write(lines []byte) error {
+	err := c.write(lines, false)
+	if err != nil {
+		return maskAny(err)
+	}
+
+	return nil
+}
+
+// fail increases the backoff duration and schedules the next connection
+// attempt. It must only be called while holding the mutex.
+func (c *Client) fail() {
+	if c.backoff == 0 {
+		c.backoff = c.minBackoff
+	} else {
+		c.backoff *= 2
+	}
+	if c.backoff > c.maxBackoff {
+		c.backoff = c.maxBackoff
+	}
+	c.nextDial = time.Now().Add(c.backoff)
+}
+
+// write writes the given lines to the Graphite receiver, connecting to it if
+// necessary. The backoff is only respected in case force is false.
+func (c *Client) write(lines []byte, force bool) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if c.conn == nil {
+		if !force && time.Now().Before(c.nextDial) {
+			return maskAnyf(backoffError, "reconnecting to %s not before %s", c.address, c.nextDial)
+		}
+
+		conn, err := net.DialTimeout("tcp", c.address, c.timeout)
+		if err != nil {
+			c.fail()
+			return maskAny(err)
+		}
+		c.conn = conn
+	}
+
+	err := c.conn.SetWriteDeadline(time.Now().Add(c.timeout))
+	if err == nil {
+		_, err = c.conn.Write(lines)
+	}
+	if err != nil {
+		c.conn.Close()
+		c.conn = nil
+		c.fail()
+		return maskAny(err)
+	}
+	c.backoff = 0
+
+	return nil
+}
@@ -0,0 +1,81 @@
+package publisher
+
+import (
+	"fmt"
+
+	"github.com/juju/errgo"
+
+	"github.com/the-anna-project/instrumentor/internal/metric"
+)
+
+var (
+	maskAny = errgo.MaskFunc(errgo.Any)
+)
+
+func maskAnyf(err error, f string, v ...interface{}) error {
+	if err == nil {
+		return nil
+	}
+
+	f = fmt.Sprintf("%s: %s", err.Error(), f)
+	newErr := errgo.WithCausef(nil, errgo.Cause(err), f, v...)
+	newErr.(*errgo.Err).SetLocation(1)
+
+	return newErr
+}
+
+var invalidConfigError = metric.InvalidConfigError
+
+// IsInvalidConfig asserts invalidConfigError.
+func IsInvalidConfig(err error) bool {
+	return metric.IsInvalidConfig(err)
+}
+
+var alreadyRegisteredError = metric.AlreadyRegisteredError
+
+// IsAlreadyRegistered asserts alreadyRegisteredError.
+func IsAlreadyRegistered(err error) bool {
+	return metric.IsAlreadyRegistered(err)
+}
+
+var conflictingDefinitionError = metric.ConflictingDefinitionError
+
+// IsConflictingDefinition asserts conflictingDefinitionError.
+func IsConflictingDefinition(err error) bool {
+	return metric.IsConflictingDefinition(err)
+}
+
+var panicError = metric.PanicError
+
+// IsPanic asserts panicError.
+func IsPanic(err error) bool {
+	return metric.IsPanic(err)
+}
+
+var backoffError = errgo.New("backoff")
+
+// IsBackoff asserts backoffError.
+func IsBackoff(err error) bool {
+	return errgo.Cause(err) == backoffError
+}
+
+var missingLabelError = metric.MissingLabelError
+
+// IsMissingLabel asserts missingLabelError.
+func IsMissingLabel(err error) bool {
+	return metric.IsMissingLabel(err)
+}
+
+var unknownLabelError = metric.UnknownLabelError
+
+// IsUnknownLabel asserts unknownLabelError.
+func IsUnknownLabel(err error) bool {
+	return metric.IsUnknownLabel(err)
+}
+
+var invalidNameError = metric.InvalidNameError
+
+// IsInvalidName asserts invalidNameError.
+func IsInvalidName(err error) bool {
+	return metric.IsInvalidName(err)
+}
@@ -0,0 +1,126 @@
+package publisher
+
+import (
+	"bytes"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/the-anna-project/instrumentor/memory/storage"
+)
+
+// writeLines writes the plaintext lines of all series of the given metric to
+// the given buffer. The path of a series is the metric's name followed by its
+// label values as dotted segments. In case the metric's name starts with the
+// given name prefix, the prefix is written as the given prefix segments, e.g.
+// for a counter created using NewKey("requests", "total") of a service
+// configured with the prefix "prefix", labelled with method and status code.
+//
+//	prefix.requests_total.GET.200 42 1500000000
+//
+// The values of constant labels, ordered by the label names, are the leading
+// segments of the path, e.g. for a constant region label.
+//
+//	eu-west.prefix.requests_total.GET.200 42 1500000000
+//
+// Histograms are written as the count, the sum and the cumulative count of
+// every bucket. Summaries are written as the count, the sum and the estimated
+// value of every objective.
+//
+//	prefix.duration_milliseconds.count 3 1500000000
+//	prefix.duration_milliseconds.sum 12.5 1500000000
+//	prefix.duration_milliseconds.le_0_5 1 1500000000
+//	prefix.summary.p99 4.2 1500000000
+func writeLines(b *bytes.Buffer, m *storage.Metric, namePrefix string, prefixSegments []string, timestamp int64) {
+	for _, s := range m.SeriesList() {
+		path := seriesPath(m.ConstLabels(), namePrefix, prefixSegments, m.Name(), s.LabelValues)
+
+		switch m.Kind() {
+		case storage.KindCounter, storage.KindGauge:
+			writeLine(b, path, s.Value, timestamp)
+		case storage.KindHistogram:
+			writeLine(b, path+".count", float64(s.Count), timestamp)
+			writeLine(b, path+".sum", s.Sum, timestamp)
+			for i, u := range m.Buckets() {
+				writeLine(b, path+".le_"+formatSegment(u), float64(s.BucketCounts[i]), timestamp)
+			}
+		case storage.KindSummary:
+			writeLine(b, path+".count", float64(s.Count), timestamp)
+			writeLine(b, path+".sum", s.Sum, timestamp)
+			var quantiles []float64
+			for q := range s.Quantiles {
+				quantiles = append(quantiles, q)
+			}
+			sort.Float64s(quantiles)
+			for _, q := range quantiles {
+				writeLine(b, path+".p"+formatSegment(q*100), s.Quantiles[q], timestamp)
+			}
+		}
+	}
+}
+
+// writeLine writes a single plaintext line. NaN values, e.g. quantiles of
+// empty summaries, are not accepted by Graphite and therefore skipped.
+func writeLine(b *bytes.Buffer, path string, value float64, timestamp int64) {
+	if math.IsNaN(value) {
+		return
+	}
+
+	b.WriteString(path)
+	b.WriteByte(' ')
+	b.WriteString(strconv.FormatFloat(value, 'f', -1, 64))
+	b.WriteByte(' ')
+	b.WriteString(strconv.FormatInt(timestamp, 10))
+	b.WriteByte('\n')
+}
+
+// formatSegment formats the given value so it can be used as a single path
+// segment.
+func formatSegment(value float64) string {
+	return strings.Replace(strconv.FormatFloat(value, 'f', -1, 64), ".", "_", -1)
+}
+
+// seriesPath joins the given constant label values, metric name and label
+// values to a dotted path. In case the metric name starts with the given name
+// prefix, the prefix is replaced by the given prefix segments.
+func seriesPath(constLabels map[string]string, namePrefix string, prefixSegments []string, name string, values []string) string {
+	var names []string
+	for n := range constLabels {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+
+	var segments []string
+	for _, n := range names {
+		segments = append(segments, formatLabelValue(constLabels[n]))
+	}
+	if namePrefix != "" && strings.HasPrefix(name, namePrefix) {
+		segments = append(segments, prefixSegments...)
+		name = strings.TrimPrefix(name, namePrefix)
+	}
+	segments = append(segments, nameReplacer.Replace(name))
+	for _, v := range values {
+		segments = append(segments, formatLabelValue(v))
+	}
+
+	return strings.Join(segments, ".")
+}
+
+// formatLabelValue formats the given label value so it can be used as a single
+// path segment.
+func formatLabelValue(value string) string {
+	if value == "" {
+		return "_"
+	}
+
+	return segmentReplacer.Replace(value)
+}
+
+var (
+	// nameReplacer replaces whitespace separating the fields of a plaintext line.
+	nameReplacer = strings.NewReplacer(" ", "_", "\t", "_", "\n", "_", "\r", "_")
+	// segmentReplacer additionally replaces characters separating path segments,
+	// so every label value results in exactly one segment.
+	segmentReplacer = strings.NewReplacer(" ", "_", "\t", "_", "\n", "_", "\r", "_", ".", "_", "/", "_")
+)
@@ -0,0 +1,275 @@
+// Package publisher implements
+// github.com/the-anna-project/instrumentor.Publisher and provides
+// instrumentation primitives to emit application metrics.
+package publisher
+
+import (
+	"bytes"
+	"sync"
+	"time"
+
+	"github.com/the-anna-project/instrumentor/internal/metric"
+	memorypublisher "github.com/the-anna-project/instrumentor/memory/publisher"
+	"github.com/the-anna-project/instrumentor/memory/storage"
+	"github.com/the-anna-project/instrumentor/spec"
+)
+
+// ServiceConfig represents the configuration used to create a new Graphite
+// publisher service.
+type ServiceConfig struct {
+	// Dependencies.
+
+	// Storage represents the storage metric values are aggregated in between
+	// flushes. It can be shared with a memory consumer in order to read the
+	// aggregated values back. In case it is nil, which is the default, a new
+	// storage is created.
+	Storage *storage.Storage
+
+	// Settings.
+
+	// Address represents the TCP address of the Graphite plaintext receiver
+	// metrics are written to.
+	Address string
+	// ConstLabels represents labels having the same value for all metrics
+	// created by the service, e.g. the name of the service or its version.
+	ConstLabels map[string]string
+	// ErrorHandler is called with errors the service cannot return, which is
+	// the error of the final flush on Shutdown. The default logs them.
+	ErrorHandler func(err error)
+	// FlushInterval represents the interval in which aggregated metrics are
+	// written to the Graphite receiver.
+	FlushInterval time.Duration
+	// MaxBackoff represents the maximum duration to wait before reconnecting to
+	// the Graphite receiver after a failure.
+	MaxBackoff time.Duration
+	// MinBackoff represents the duration to wait before reconnecting to the
+	// Graphite receiver after the first failure.
+	MinBackoff time.Duration
+	// PanicMode describes how panics of wrapped actions are handled. It is one
+	// of spec.PanicModeNone, spec.PanicModeRecover or spec.PanicModeRepanic.
+	PanicMode string
+	// Prefixes represents the prefixes NewKey joins with the given key. They are
+	// written as the leading dotted segments of the paths of metrics created
+	// using NewKey.
+	Prefixes []string
+	// SanitizeKeys causes NewKey to replace all characters not being allowed in
+	// metric names by underscores, e.g. in case keys are built from dynamic
+	// input. Metric names and label names are validated either way. Invalid
+	// ones cause errors asserted by IsInvalidName.
+	SanitizeKeys bool
+	// Timeout represents the maximum duration of connecting to the Graphite
+	// receiver and of writing to it.
+	Timeout time.Duration
+}
+
+// DefaultServiceConfig provides a default configuration to create a new
+// Graphite publisher service by best effort.
+func DefaultServiceConfig() ServiceConfig {
+	clientConfig := DefaultClientConfig()
+
+	return ServiceConfig{
+		// Dependencies.
+		Storage: nil,
+
+		// Settings.
+		Address:       clientConfig.Address,
+		ConstLabels:   map[string]string{},
+		ErrorHandler:  metric.LogError("graphite"),
+		FlushInterval: 10 * time.Second,
+		MaxBackoff:    clientConfig.MaxBackoff,
+		MinBackoff:    clientConfig.MinBackoff,
+		PanicMode:     spec.PanicModeNone,
+		Prefixes:      []string{},
+		SanitizeKeys:  false,
+		Timeout:       clientConfig.Timeout,
+	}
+}
+
+// NewService creates a new Graphite publisher service. Metrics are created by
+// a memory publisher service aggregating their values in the configured
+// storage, which is periodically written to the Graphite receiver.
+func NewService(config ServiceConfig) (*Service, error) {
+	// Dependencies.
+	newStorage := config.Storage
+	if newStorage == nil {
+		var err error
+		newStorage, err = storage.NewStorage(storage.DefaultStorageConfig())
+		if err != nil {
+			return nil, maskAny(err)
+		}
+	}
+
+	// Settings.
+	if config.ErrorHandler == nil {
+		return nil, maskAnyf(invalidConfigError, "error handler must not be empty")
+	}
+	if config.FlushInterval <= 0 {
+		return nil, maskAnyf(invalidConfigError, "flush interval must be greater than 0")
+	}
+
+	var err error
+
+	var memoryService *memorypublisher.Service
+	{
+		memoryConfig := memorypublisher.DefaultServiceConfig()
+		memoryConfig.Storage = newStorage
+		memoryConfig.ConstLabels = config.ConstLabels
+		memoryConfig.PanicMode = config.PanicMode
+		memoryConfig.Prefixes = config.Prefixes
+		memoryConfig.SanitizeKeys = config.SanitizeKeys
+		memoryService, err = memorypublisher.NewService(memoryConfig)
+		if err != nil {
+			return nil, maskAny(err)
+		}
+	}
+
+	var newClient *Client
+	{
+		clientConfig := DefaultClientConfig()
+		clientConfig.Address = config.Address
+		clientConfig.MaxBackoff = config.MaxBackoff
+		clientConfig.MinBackoff = config.MinBackoff
+		clientConfig.Timeout = config.Timeout
+		newClient, err = NewClient(clientConfig)
+		if err != nil {
+			return nil, maskAny(err)
+		}
+	}
+
+	// Names created using NewKey start with the prefixes joined by underscores.
+	// They are written as dotted path segments instead.
+	var namePrefix string
+	var prefixSegments []string
+	if len(config.Prefixes) != 0 {
+		namePrefix = memoryService.NewKey() + "_"
+		for _, p := range config.Prefixes {
+			if config.SanitizeKeys {
+				p = metric.SanitizeName(p)
+			}
+			prefixSegments = append(prefixSegments, formatLabelValue(p))
+		}
+	}
+
+	newService := &Service{
+		// Dependencies.
+		Service: memoryService,
+		storage: newStorage,
+
+		// Internals.
+		client:       newClient,
+		closer:       make(chan struct{}, 1),
+		bootOnce:     sync.Once{},
+		done:         make(chan struct{}, 1),
+		shutdownOnce: sync.Once{},
+
+		// Settings.
+		errorHandler:   config.ErrorHandler,
+		flushInterval:  config.FlushInterval,
+		namePrefix:     namePrefix,
+		prefixSegments: prefixSegments,
+	}
+
+	return newService, nil
+}
+
+// Service creates metrics using the embedded memory publisher service and
+// writes their aggregated values to the Graphite receiver.
+type Service struct {
+	// Dependencies.
+
+	// Service represents the memory publisher service creating all metrics.
+	*memorypublisher.Service
+	// storage represents the storage metric values are aggregated in.
+	storage *storage.Storage
+
+	// Internals.
+	client       *Client
+	closer       chan struct{}
+	bootOnce     sync.Once
+	done         chan struct{}
+	shutdownOnce sync.Once
+
+	// Settings.
+
+	// errorHandler is called with the error of the final flush on Shutdown.
+	errorHandler func(err error)
+	// flushInterval represents the interval in which aggregated metrics are
+	// written to the Graphite receiver.
+	flushInterval time.Duration
+	// namePrefix represents the prefixes joined by NewKey, which are replaced
+	// by prefixSegments in the paths of metrics whose names start with them.
+	namePrefix     string
+	prefixSegments []string
+}
+
+func (s *Service) Boot() {
+	s.bootOnce.Do(func() {
+		s.Service.Boot()
+
+		go func() {
+			ticker := time.NewTicker(s.flushInterval)
+			defer ticker.Stop()
+			defer close(s.done)
+
+			for {
+				select {
+				case <-s.closer:
+					return
+				case <-ticker.C:
+					// There is no way to report errors of the background flush. Since
+					// all values are aggregated in the storage, the next successful
+					// flush writes the current state again.
+					s.flush(false)
+				}
+			}
+		}()
+	})
+}
+
+// flush writes the current values of all metrics aggregated in the storage
+// to the Graphite receiver. Counters are written as their cumulative values.
+// The client's backoff is bypassed in case force is true.
+func (s *Service) flush(force bool) error {
+	var b bytes.Buffer
+	timestamp := time.Now().Unix()
+	for _, m := range s.storage.Metrics() {
+		writeLines(&b, m, s.namePrefix, s.prefixSegments, timestamp)
+	}
+	if b.Len() == 0 {
+		return nil
+	}
+
+	var err error
+	if force {
+		err = s.client.ForceWrite(b.Bytes())
+	} else {
+		err = s.client.Write(b.Bytes())
+	}
+	if err != nil {
+		return maskAny(err)
+	}
+
+	return nil
+}
+
+func (s *Service) Shutdown() {
+	s.shutdownOnce.Do(func() {
+		close(s.closer)
+		// The flush loop is only running in case the service has been booted. The
+		// final flush must not race with a flush of the loop, so the loop is
+		// awaited first.
+		booted := true
+		s.bootOnce.Do(func() { booted = false })
+		if booted {
+			<-s.done
+		}
+		// The final flush is the last chance to write the current state, so it
+		// does not wait for the client to back off.
+		err := s.flush(true)
+		if err != nil {
+			s.errorHandler(err)
+		}
+		s.client.Close()
+		s.Service.Shutdown()
+	})
+}
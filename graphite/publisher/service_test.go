@@ -0,0 +1,199 @@
+package publisher
+
+import (
+	"io"
+	"net"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+// listen starts a TCP listener on a random local port. The returned channel
+// receives everything written to the listener once the writing connection is
+// closed.
+func listen(t *testing.T) (net.Listener, <-chan string) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	received := make(chan string, 1)
+	go func() {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		b, _ := io.ReadAll(conn)
+		received <- string(b)
+	}()
+
+	return l, received
+}
+
+// withoutTimestamps returns the given plaintext lines without their
+// timestamps, ordered alphabetically.
+func withoutTimestamps(s string) []string {
+	var lines []string
+	for _, l := range strings.Split(strings.TrimSpace(s), "\n") {
+		fields := strings.Fields(l)
+		if len(fields) != 3 {
+			lines = append(lines, l)
+			continue
+		}
+		lines = append(lines, fields[0]+" "+fields[1])
+	}
+	sort.Strings(lines)
+
+	return lines
+}
+
+func TestService_Shutdown(t *testing.T) {
+	l, received := listen(t)
+	defer l.Close()
+
+	config := DefaultServiceConfig()
+	config.Address = l.Addr().String()
+	config.ConstLabels = map[string]string{"region": "eu-west"}
+	config.FlushInterval = time.Hour
+	config.Prefixes = []string{"app", "api"}
+	s, err := NewService(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	counterConfig := s.CounterConfig()
+	counterConfig.SetHelp("Number of requests.")
+	counterConfig.SetLabels([]string{"method", "path"})
+	counterConfig.SetName(s.NewKey("requests", "total"))
+	c, err := s.Counter(counterConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = c.IncrementWithLabels(2, "GET", "/a.b")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	histogramConfig := s.HistogramConfig()
+	histogramConfig.SetBuckets([]float64{0.5, 1})
+	histogramConfig.SetHelp("Size of responses.")
+	histogramConfig.SetName("size")
+	h, err := s.Histogram(histogramConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = h.Observe(0.5)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The final flush on Shutdown writes the current state, even though the
+	// flush interval has not passed yet.
+	s.Shutdown()
+
+	var got []string
+	select {
+	case r := <-received:
+		got = withoutTimestamps(r)
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected lines to be written on shutdown")
+	}
+
+	// Names created using NewKey have their prefixes written as dotted path
+	// segments. Label values are written as single segments.
+	want := []string{
+		"eu-west.app.api.requests_total.GET._a_b 2",
+		"eu-west.size.count 1",
+		"eu-west.size.le_0_5 1",
+		"eu-west.size.le_1 1",
+		"eu-west.size.sum 0.5",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("expected lines %q, got %q", want, got)
+	}
+}
+
+func TestService_Shutdown_Backoff(t *testing.T) {
+	l, received := listen(t)
+	defer l.Close()
+
+	config := DefaultServiceConfig()
+	config.Address = l.Addr().String()
+	config.MaxBackoff = time.Hour
+	config.MinBackoff = time.Hour
+	s, err := NewService(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	gaugeConfig := s.GaugeConfig()
+	gaugeConfig.SetHelp("Number of workers.")
+	gaugeConfig.SetName("workers")
+	g, err := s.Gauge(gaugeConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = g.Set(3)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A previous failure causes the client to back off, so regular flushes do
+	// not write anything.
+	s.client.fail()
+	err = s.flush(false)
+	if !IsBackoff(err) {
+		t.Fatalf("expected backoff error, got %v", err)
+	}
+
+	// The final flush does not wait for the client to back off.
+	s.Shutdown()
+
+	select {
+	case r := <-received:
+		got := withoutTimestamps(r)
+		if len(got) != 1 || got[0] != "workers 3" {
+			t.Fatalf("expected line %q, got %q", "workers 3", got)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected lines to be written on shutdown")
+	}
+}
+
+func TestService_Shutdown_ErrorHandler(t *testing.T) {
+	// The listener is closed right away, so connecting to its address fails.
+	l, _ := listen(t)
+	l.Close()
+
+	var errs []error
+	config := DefaultServiceConfig()
+	config.Address = l.Addr().String()
+	config.ErrorHandler = func(err error) {
+		errs = append(errs, err)
+	}
+	s, err := NewService(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	gaugeConfig := s.GaugeConfig()
+	gaugeConfig.SetHelp("Number of workers.")
+	gaugeConfig.SetName("workers")
+	g, err := s.Gauge(gaugeConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = g.Set(3)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s.Shutdown()
+
+	if len(errs) != 1 {
+		t.Fatalf("expected the error of the final flush to be handled, got %v", errs)
+	}
+}
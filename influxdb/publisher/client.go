@@ -0,0 +1,220 @@
+package publisher
+
+import (
+	"bytes"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// ClientConfig represents the configuration used to create a new InfluxDB
+// client.
+type ClientConfig struct {
+	// Settings.
+
+	// Address represents the URL of the InfluxDB endpoint metrics are written
+	// to. Using the http or https scheme, lines are sent in the body of a POST
+	// request to the given URL, which should therefore point to the write API
+	// including its query parameters, e.g. one of the following.
+	//
+	//	http://127.0.0.1:8086/write?db=metrics
+	//	http://127.0.0.1:8086/api/v2/write?org=example&bucket=metrics
+	//
+	// Using the udp scheme, lines are sent in UDP packets to the given host and
+	// port, e.g. udp://127.0.0.1:8089.
+	Address string
+	// BatchSize represents the maximum number of lines sent within a single
+	// HTTP request. More lines are sent using several requests. It is only used
+	// with the http and https schemes.
+	BatchSize int
+	// MaxPacketSize represents the maximum number of bytes sent within a single
+	// UDP packet. It is only used with the udp scheme.
+	MaxPacketSize int
+	// Timeout represents the maximum duration of a single HTTP request. It is
+	// only used with the http and https schemes.
+	Timeout time.Duration
+	// Token represents the API token sent in the Authorization header of HTTP
+	// requests. It is required by the InfluxDB 2 write API. No header is sent
+	// in case the token is empty.
+	Token string
+}
+
+// DefaultClientConfig provides a default configuration to create a new
+// InfluxDB client by best effort.
+func DefaultClientConfig() ClientConfig {
+	return ClientConfig{
+		// Settings.
+		Address: "http://127.0.0.1:8086/write?db=metrics",
+		// InfluxDB recommends writing batches of 5000 lines.
+		BatchSize: 5000,
+		// 1432 bytes fit into a single ethernet frame together with the IP and UDP
+		// headers.
+		MaxPacketSize: 1432,
+		Timeout:       5 * time.Second,
+		Token:         "",
+	}
+}
+
+// NewClient creates a new configured InfluxDB client.
+func NewClient(config ClientConfig) (*Client, error) {
+	// Settings.
+	if config.Address == "" {
+		return nil, maskAnyf(invalidConfigError, "address must not be empty")
+	}
+	u, err := url.Parse(config.Address)
+	if err != nil {
+		return nil, maskAnyf(invalidConfigError, "address must be a valid URL: %s", err.Error())
+	}
+	if u.Scheme != "http" && u.Scheme != "https" && u.Scheme != "udp" {
+		return nil, maskAnyf(invalidConfigError, "address scheme must be one of: http, https, udp")
+	}
+	if u.Scheme == "udp" && config.MaxPacketSize < 1 {
+		return nil, maskAnyf(invalidConfigError, "max packet size must be greater than 0")
+	}
+	if u.Scheme != "udp" && config.BatchSize < 1 {
+		return nil, maskAnyf(invalidConfigError, "batch size must be greater than 0")
+	}
+	if u.Scheme != "udp" && config.Timeout <= 0 {
+		return nil, maskAnyf(invalidConfigError, "timeout must be greater than 0")
+	}
+
+	var conn net.Conn
+	var httpClient *http.Client
+	if u.Scheme == "udp" {
+		conn, err = net.Dial("udp", u.Host)
+		if err != nil {
+			return nil, maskAny(err)
+		}
+	} else {
+		httpClient = &http.Client{Timeout: config.Timeout}
+	}
+
+	newClient := &Client{
+		// Internals.
+		conn:       conn,
+		httpClient: httpClient,
+
+		// Settings.
+		address:       config.Address,
+		batchSize:     config.BatchSize,
+		maxPacketSize: config.MaxPacketSize,
+		token:         config.Token,
+	}
+
+	return newClient, nil
+}
+
+// Client writes batches of lines to the configured InfluxDB endpoint, either
+// over HTTP or over UDP. All methods are safe for concurrent use.
+type Client struct {
+	// Internals.
+	conn       net.Conn
+	httpClient *http.Client
+
+	// Settings.
+	address       string
+	batchSize     int
+	maxPacketSize int
+	token         string
+}
+
+// Close closes the client's UDP connection, if any.
+func (c *Client) Close() error {
+	if c.conn == nil {
+		return nil
+	}
+
+	err := c.conn.Close()
+	if err != nil {
+		return maskAny(err)
+	}
+
+	return nil
+}
+
+// Write writes the given lines to the InfluxDB endpoint. Over HTTP at most as
+// many lines as the batch size are sent within a single request. Over UDP as many lines as fit into the
+// maximum packet size are sent within a single packet. A single line exceeding
+// the packet size is sent on its own.
+func (c *Client) Write(lines []string) error {
+	if len(lines) == 0 {
+		return nil
+	}
+
+	if c.conn != nil {
+		err := c.writeUDP(lines)
+		if err != nil {
+			return maskAny(err)
+		}
+	} else {
+		// In case a batch fails, the remaining ones are not sent either. All
+		// lines are written again by the next flush anyway.
+		for len(lines) > 0 {
+			n := c.batchSize
+			if n > len(lines) {
+				n = len(lines)
+			}
+			err := c.writeHTTP(lines[:n])
+			if err != nil {
+				return maskAny(err)
+			}
+			lines = lines[n:]
+		}
+	}
+
+	return nil
+}
+
+func (c *Client) writeHTTP(lines []string) error {
+	var b bytes.Buffer
+	for _, l := range lines {
+		b.WriteString(l)
+		b.WriteByte('\n')
+	}
+
+	req, err := http.NewRequest("POST", c.address, &b)
+	if err != nil {
+		return maskAny(err)
+	}
+	req.Header.Set("Content-Type", "text/plain; charset=utf-8")
+	if c.token != "" {
+		req.Header.Set("Authorization", "Token "+c.token)
+	}
+
+	res, err := c.httpClient.Do(req)
+	if err != nil {
+		return maskAny(err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		body, _ := io.ReadAll(io.LimitReader(res.Body, 512))
+		return maskAnyf(writeFailedError, "%s responded with %s: %s", c.address, res.Status, bytes.TrimSpace(body))
+	}
+
+	return nil
+}
+
+func (c *Client) writeUDP(lines []string) error {
+	var b bytes.Buffer
+	for _, l := range lines {
+		if b.Len() > 0 && b.Len()+len(l)+1 > c.maxPacketSize {
+			_, err := c.conn.Write(b.Bytes())
+			if err != nil {
+				return maskAny(err)
+			}
+			b.Reset()
+		}
+		b.WriteString(l)
+		b.WriteByte('\n')
+	}
+
+	_, err := c.conn.Write(b.Bytes())
+	if err != nil {
+		return maskAny(err)
+	}
+
+	return nil
+}
@@ -0,0 +1,125 @@
+package publisher
+
+import (
+	"bytes"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/the-anna-project/instrumentor/memory/storage"
+)
+
+// appendLines appends the line protocol lines of all series of the given metric
+// to the given lines. The metric's name becomes the measurement and its labels
+// become tags, together with the metric's constant labels. Fields are named the
+// way Telegraf's Prometheus input names them, so dashboards can be shared
+// between both. Counters and gauges are written as a single field named after
+// their kind, counters being written as their cumulative values.
+//
+//	requests_total,method=GET counter=42 1500000000000000000
+//	goroutines gauge=7 1500000000000000000
+//
+// Histograms and summaries are written as their count and sum, together with
+// a field per bucket upper bound or per objective quantile respectively.
+//
+//	duration_seconds count=3,sum=1.25,0.5=2,1=3 1500000000000000000
+func appendLines(lines []string, m *storage.Metric, timestamp int64) []string {
+	for _, s := range m.SeriesList() {
+		var fields []string
+
+		switch m.Kind() {
+		case storage.KindCounter, storage.KindGauge:
+			fields = appendField(fields, m.Kind(), s.Value)
+		case storage.KindHistogram:
+			fields = appendField(fields, "count", float64(s.Count))
+			fields = appendField(fields, "sum", s.Sum)
+			for i, u := range m.Buckets() {
+				fields = appendField(fields, formatFloat(u), float64(s.BucketCounts[i]))
+			}
+		case storage.KindSummary:
+			fields = appendField(fields, "count", float64(s.Count))
+			fields = appendField(fields, "sum", s.Sum)
+			var quantiles []float64
+			for q := range s.Quantiles {
+				quantiles = append(quantiles, q)
+			}
+			sort.Float64s(quantiles)
+			for _, q := range quantiles {
+				fields = appendField(fields, formatFloat(q), s.Quantiles[q])
+			}
+		}
+
+		if len(fields) == 0 {
+			continue
+		}
+
+		var b bytes.Buffer
+		b.WriteString(measurementReplacer.Replace(m.Name()))
+		for _, t := range tags(m.ConstLabels(), m.Labels(), s.LabelValues) {
+			b.WriteByte(',')
+			b.WriteString(t)
+		}
+		b.WriteByte(' ')
+		b.WriteString(strings.Join(fields, ","))
+		b.WriteByte(' ')
+		b.WriteString(strconv.FormatInt(timestamp, 10))
+
+		lines = append(lines, b.String())
+	}
+
+	return lines
+}
+
+// appendField appends the given field to the given fields. NaN and infinite
+// values, e.g. quantiles of empty summaries, are not accepted by InfluxDB and
+// therefore skipped.
+func appendField(fields []string, key string, value float64) []string {
+	if math.IsNaN(value) || math.IsInf(value, 0) {
+		return fields
+	}
+
+	return append(fields, keyReplacer.Replace(key)+"="+formatFloat(value))
+}
+
+func formatFloat(value float64) string {
+	return strconv.FormatFloat(value, 'f', -1, 64)
+}
+
+// tags returns the escaped tags of the given constant labels, label names and
+// values, ordered by their keys as recommended by InfluxDB. Empty values are
+// not accepted by InfluxDB and therefore skipped.
+func tags(constLabels map[string]string, labels []string, values []string) []string {
+	var keys []string
+	tagValues := map[string]string{}
+	for n, v := range constLabels {
+		if v == "" {
+			continue
+		}
+		keys = append(keys, n)
+		tagValues[n] = v
+	}
+	for i, l := range labels {
+		if i >= len(values) || values[i] == "" {
+			continue
+		}
+		keys = append(keys, l)
+		tagValues[l] = values[i]
+	}
+	sort.Strings(keys)
+
+	var list []string
+	for _, k := range keys {
+		list = append(list, keyReplacer.Replace(k)+"="+keyReplacer.Replace(tagValues[k]))
+	}
+
+	return list
+}
+
+var (
+	// keyReplacer escapes tag keys, tag values and field keys. Newlines cannot be
+	// escaped, so they are replaced.
+	keyReplacer = strings.NewReplacer(",", `\,`, "=", `\=`, " ", `\ `, "\n", "_")
+	// measurementReplacer escapes measurements.
+	measurementReplacer = strings.NewReplacer(",", `\,`, " ", `\ `, "\n", "_")
+)
@@ -0,0 +1,243 @@
+// Package publisher implements
+// github.com/the-anna-project/instrumentor.Publisher and provides
+// instrumentation primitives to emit application metrics.
+package publisher
+
+import (
+	"sync"
+	"time"
+
+	"github.com/the-anna-project/instrumentor/internal/metric"
+	memorypublisher "github.com/the-anna-project/instrumentor/memory/publisher"
+	"github.com/the-anna-project/instrumentor/memory/storage"
+	"github.com/the-anna-project/instrumentor/spec"
+)
+
+// ServiceConfig represents the configuration used to create a new InfluxDB
+// publisher service.
+type ServiceConfig struct {
+	// Dependencies.
+
+	// Storage represents the storage metric values are aggregated in between
+	// flushes. It can be shared with a memory consumer in order to read the
+	// aggregated values back. In case it is nil, which is the default, a new
+	// storage is created.
+	Storage *storage.Storage
+
+	// Settings.
+
+	// Address represents the URL of the InfluxDB endpoint metrics are written
+	// to. See ClientConfig.Address for the supported schemes.
+	Address string
+	// BatchSize represents the maximum number of lines written to InfluxDB
+	// within a single HTTP request. See ClientConfig.BatchSize.
+	BatchSize int
+	// ConstLabels represents labels having the same value for all metrics
+	// created by the service, e.g. the name of the service or its version.
+	ConstLabels map[string]string
+	// ErrorHandler is called with errors the service cannot return, which is
+	// the error of the final flush on Shutdown. The default logs them.
+	ErrorHandler func(err error)
+	// FlushInterval represents the interval in which aggregated metrics are
+	// written to InfluxDB.
+	FlushInterval time.Duration
+	// MaxPacketSize represents the maximum number of bytes sent within a single
+	// UDP packet. It is only used with the udp scheme.
+	MaxPacketSize int
+	// PanicMode describes how panics of wrapped actions are handled. It is one
+	// of spec.PanicModeNone, spec.PanicModeRecover or spec.PanicModeRepanic.
+	PanicMode string
+	Prefixes  []string
+	// SanitizeKeys causes NewKey to replace all characters not being allowed in
+	// metric names by underscores, e.g. in case keys are built from dynamic
+	// input. Metric names and label names are validated either way. Invalid
+	// ones cause errors asserted by IsInvalidName.
+	SanitizeKeys bool
+	// Timeout represents the maximum duration of a single HTTP request.
+	Timeout time.Duration
+	// Token represents the API token required by the InfluxDB 2 write API.
+	Token string
+}
+
+// DefaultServiceConfig provides a default configuration to create a new
+// InfluxDB publisher service by best effort.
+func DefaultServiceConfig() ServiceConfig {
+	clientConfig := DefaultClientConfig()
+
+	return ServiceConfig{
+		// Dependencies.
+		Storage: nil,
+
+		// Settings.
+		Address:       clientConfig.Address,
+		BatchSize:     clientConfig.BatchSize,
+		ConstLabels:   map[string]string{},
+		ErrorHandler:  metric.LogError("influxdb"),
+		FlushInterval: 10 * time.Second,
+		MaxPacketSize: clientConfig.MaxPacketSize,
+		PanicMode:     spec.PanicModeNone,
+		Prefixes:      []string{},
+		SanitizeKeys:  false,
+		Timeout:       clientConfig.Timeout,
+		Token:         clientConfig.Token,
+	}
+}
+
+// NewService creates a new InfluxDB publisher service. Metrics are created by
+// a memory publisher service aggregating their values in the configured
+// storage, which is periodically written to InfluxDB.
+func NewService(config ServiceConfig) (*Service, error) {
+	// Dependencies.
+	newStorage := config.Storage
+	if newStorage == nil {
+		var err error
+		newStorage, err = storage.NewStorage(storage.DefaultStorageConfig())
+		if err != nil {
+			return nil, maskAny(err)
+		}
+	}
+
+	// Settings.
+	if config.ErrorHandler == nil {
+		return nil, maskAnyf(invalidConfigError, "error handler must not be empty")
+	}
+	if config.FlushInterval <= 0 {
+		return nil, maskAnyf(invalidConfigError, "flush interval must be greater than 0")
+	}
+
+	var err error
+
+	var memoryService *memorypublisher.Service
+	{
+		memoryConfig := memorypublisher.DefaultServiceConfig()
+		memoryConfig.Storage = newStorage
+		memoryConfig.ConstLabels = config.ConstLabels
+		memoryConfig.PanicMode = config.PanicMode
+		memoryConfig.Prefixes = config.Prefixes
+		memoryConfig.SanitizeKeys = config.SanitizeKeys
+		memoryService, err = memorypublisher.NewService(memoryConfig)
+		if err != nil {
+			return nil, maskAny(err)
+		}
+	}
+
+	var newClient *Client
+	{
+		clientConfig := DefaultClientConfig()
+		clientConfig.Address = config.Address
+		clientConfig.BatchSize = config.BatchSize
+		clientConfig.MaxPacketSize = config.MaxPacketSize
+		clientConfig.Timeout = config.Timeout
+		clientConfig.Token = config.Token
+		newClient, err = NewClient(clientConfig)
+		if err != nil {
+			return nil, maskAny(err)
+		}
+	}
+
+	newService := &Service{
+		// Dependencies.
+		Service: memoryService,
+		storage: newStorage,
+
+		// Internals.
+		client:       newClient,
+		closer:       make(chan struct{}, 1),
+		bootOnce:     sync.Once{},
+		done:         make(chan struct{}, 1),
+		shutdownOnce: sync.Once{},
+
+		// Settings.
+		errorHandler:  config.ErrorHandler,
+		flushInterval: config.FlushInterval,
+	}
+
+	return newService, nil
+}
+
+// Service creates metrics using the embedded memory publisher service and
+// writes their aggregated values to InfluxDB.
+type Service struct {
+	// Dependencies.
+
+	// Service represents the memory publisher service creating all metrics.
+	*memorypublisher.Service
+	// storage represents the storage metric values are aggregated in.
+	storage *storage.Storage
+
+	// Internals.
+	client       *Client
+	closer       chan struct{}
+	bootOnce     sync.Once
+	done         chan struct{}
+	shutdownOnce sync.Once
+
+	// Settings.
+
+	// errorHandler is called with the error of the final flush on Shutdown.
+	errorHandler func(err error)
+	// flushInterval represents the interval in which aggregated metrics are
+	// written to InfluxDB.
+	flushInterval time.Duration
+}
+
+func (s *Service) Boot() {
+	s.bootOnce.Do(func() {
+		s.Service.Boot()
+
+		go func() {
+			ticker := time.NewTicker(s.flushInterval)
+			defer ticker.Stop()
+			defer close(s.done)
+
+			for {
+				select {
+				case <-s.closer:
+					return
+				case <-ticker.C:
+					// There is no way to report errors of the background flush. Since
+					// all values are aggregated in the storage, the next successful
+					// flush writes the current state again.
+					s.flush()
+				}
+			}
+		}()
+	})
+}
+
+// flush writes the current values of all metrics aggregated in the storage
+// to InfluxDB. Counters are written as their cumulative values.
+func (s *Service) flush() error {
+	var lines []string
+	timestamp := time.Now().UnixNano()
+	for _, m := range s.storage.Metrics() {
+		lines = appendLines(lines, m, timestamp)
+	}
+
+	err := s.client.Write(lines)
+	if err != nil {
+		return maskAny(err)
+	}
+
+	return nil
+}
+
+func (s *Service) Shutdown() {
+	s.shutdownOnce.Do(func() {
+		close(s.closer)
+		// The flush loop is only running in case the service has been booted. The
+		// final flush must not race with a flush of the loop, so the loop is
+		// awaited first.
+		booted := true
+		s.bootOnce.Do(func() { booted = false })
+		if booted {
+			<-s.done
+		}
+		err := s.flush()
+		if err != nil {
+			s.errorHandler(err)
+		}
+		s.client.Close()
+		s.Service.Shutdown()
+	})
+}
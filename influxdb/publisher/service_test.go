@@ -0,0 +1,172 @@
+package publisher
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// testServer is an InfluxDB write API stub recording the lines of all write
+// requests.
+type testServer struct {
+	*httptest.Server
+
+	mutex    sync.Mutex
+	requests [][]string
+	tokens   []string
+}
+
+func newTestServer(status int) *testServer {
+	ts := &testServer{}
+	ts.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+
+		ts.mutex.Lock()
+		ts.requests = append(ts.requests, strings.Split(strings.TrimSpace(string(b)), "\n"))
+		ts.tokens = append(ts.tokens, r.Header.Get("Authorization"))
+		ts.mutex.Unlock()
+
+		w.WriteHeader(status)
+	}))
+
+	return ts
+}
+
+// lines returns the lines of all requests without their timestamps.
+func (ts *testServer) lines() []string {
+	ts.mutex.Lock()
+	defer ts.mutex.Unlock()
+
+	var lines []string
+	for _, r := range ts.requests {
+		for _, l := range r {
+			lines = append(lines, l[:strings.LastIndex(l, " ")])
+		}
+	}
+
+	return lines
+}
+
+func TestService_Shutdown(t *testing.T) {
+	ts := newTestServer(http.StatusNoContent)
+	defer ts.Close()
+
+	config := DefaultServiceConfig()
+	config.Address = ts.URL + "/api/v2/write?org=example&bucket=metrics"
+	config.ConstLabels = map[string]string{"region": "eu-west"}
+	config.FlushInterval = time.Hour
+	config.Token = "secret"
+	s, err := NewService(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	counterConfig := s.CounterConfig()
+	counterConfig.SetHelp("Number of requests.")
+	counterConfig.SetLabels([]string{"method"})
+	counterConfig.SetName("requests_total")
+	c, err := s.Counter(counterConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = c.IncrementWithLabels(2, "GET")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	histogramConfig := s.HistogramConfig()
+	histogramConfig.SetBuckets([]float64{0.5, 1})
+	histogramConfig.SetHelp("Size of responses.")
+	histogramConfig.SetName("size")
+	h, err := s.Histogram(histogramConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = h.Observe(0.5)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The final flush on Shutdown writes the current state, even though the
+	// flush interval has not passed yet.
+	s.Shutdown()
+
+	want := []string{
+		"requests_total,method=GET,region=eu-west counter=2",
+		"size,region=eu-west count=1,sum=0.5,0.5=1,1=1",
+	}
+	got := ts.lines()
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("expected lines %q, got %q", want, got)
+	}
+	if len(ts.tokens) != 1 || ts.tokens[0] != "Token secret" {
+		t.Fatalf("expected a single request authorized using the token, got %q", ts.tokens)
+	}
+}
+
+func TestService_Shutdown_ErrorHandler(t *testing.T) {
+	ts := newTestServer(http.StatusInternalServerError)
+	defer ts.Close()
+
+	var errs []error
+	config := DefaultServiceConfig()
+	config.Address = ts.URL + "/write?db=metrics"
+	config.ErrorHandler = func(err error) {
+		errs = append(errs, err)
+	}
+	s, err := NewService(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	gaugeConfig := s.GaugeConfig()
+	gaugeConfig.SetHelp("Number of workers.")
+	gaugeConfig.SetName("workers")
+	g, err := s.Gauge(gaugeConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = g.Set(3)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s.Shutdown()
+
+	if len(errs) != 1 {
+		t.Fatalf("expected the error of the final flush to be handled, got %v", errs)
+	}
+}
+
+func TestClient_Write_BatchSize(t *testing.T) {
+	ts := newTestServer(http.StatusNoContent)
+	defer ts.Close()
+
+	config := DefaultClientConfig()
+	config.Address = ts.URL + "/write?db=metrics"
+	config.BatchSize = 2
+	c, err := NewClient(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = c.Write([]string{"a gauge=1 1", "b gauge=2 1", "c gauge=3 1", "d gauge=4 1", "e gauge=5 1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var sizes []int
+	for _, r := range ts.requests {
+		sizes = append(sizes, len(r))
+	}
+	if len(sizes) != 3 || sizes[0] != 2 || sizes[1] != 2 || sizes[2] != 1 {
+		t.Fatalf("expected batches of 2, 2 and 1 lines, got %v", sizes)
+	}
+	if strings.Join(ts.lines(), ",") != "a gauge=1,b gauge=2,c gauge=3,d gauge=4,e gauge=5" {
+		t.Fatalf("expected all lines in order, got %q", ts.lines())
+	}
+}
@@ -0,0 +1,113 @@
+package metric
+
+import (
+	"time"
+)
+
+// The kinds of metrics a Definition describes.
+const (
+	KindCounter   = "counter"
+	KindGauge     = "gauge"
+	KindHistogram = "histogram"
+	KindSummary   = "summary"
+)
+
+// Definition describes a metric as it has been registered under its name. Each
+// name can only be registered with a single definition.
+type Definition struct {
+	AgeBuckets            uint32
+	Buckets               []float64
+	Help                  string
+	Kind                  string
+	Labels                []string
+	MaxAge                time.Duration
+	Name                  string
+	NativeBucketFactor    float64
+	NativeMaxBucketNumber uint32
+	NativeZeroThreshold   float64
+	Objectives            map[float64]float64
+	TTL                   time.Duration
+}
+
+// Check verifies that the given definition is valid and can be served by the
+// metric registered with the current definition. Help must not be empty,
+// otherwise InvalidConfigError is returned, so all publishers share the
+// requirements of the prometheus publisher. The zero Definition describes a
+// name not being registered yet, which serves any valid definition. Otherwise
+// the metric's kind must be the same, or AlreadyRegisteredError is returned.
+// Help, labels and buckets must be the same, as well as the settings of native
+// histograms, the objectives and the sliding time window of summaries and the
+// TTL of series. Otherwise ConflictingDefinitionError is returned.
+func (d Definition) Check(other Definition) error {
+	if other.Help == "" {
+		return maskAnyf(InvalidConfigError, "help of %s %s must not be empty", other.Kind, other.Name)
+	}
+	if d.Kind == "" {
+		return nil
+	}
+	if d.Kind != other.Kind {
+		return maskAnyf(AlreadyRegisteredError, "%s %s is already registered as %s", other.Kind, d.Name, d.Kind)
+	}
+	if d.Help != other.Help {
+		return maskAnyf(ConflictingDefinitionError, "%s %s is already registered with help %q", d.Kind, d.Name, d.Help)
+	}
+	if !equalStrings(d.Labels, other.Labels) {
+		return maskAnyf(ConflictingDefinitionError, "%s %s is already registered with labels %v", d.Kind, d.Name, d.Labels)
+	}
+	if !equalFloats(d.Buckets, other.Buckets) {
+		return maskAnyf(ConflictingDefinitionError, "%s %s is already registered with buckets %v", d.Kind, d.Name, d.Buckets)
+	}
+	if d.NativeBucketFactor != other.NativeBucketFactor || d.NativeMaxBucketNumber != other.NativeMaxBucketNumber || d.NativeZeroThreshold != other.NativeZeroThreshold {
+		return maskAnyf(ConflictingDefinitionError, "%s %s is already registered with native bucket factor %v, native max bucket number %d and native zero threshold %v", d.Kind, d.Name, d.NativeBucketFactor, d.NativeMaxBucketNumber, d.NativeZeroThreshold)
+	}
+	if !equalObjectives(d.Objectives, other.Objectives) {
+		return maskAnyf(ConflictingDefinitionError, "%s %s is already registered with objectives %v", d.Kind, d.Name, d.Objectives)
+	}
+	if d.MaxAge != other.MaxAge || d.AgeBuckets != other.AgeBuckets {
+		return maskAnyf(ConflictingDefinitionError, "%s %s is already registered with max age %s and %d age buckets", d.Kind, d.Name, d.MaxAge, d.AgeBuckets)
+	}
+	if d.TTL != other.TTL {
+		return maskAnyf(ConflictingDefinitionError, "%s %s is already registered with TTL %s", d.Kind, d.Name, d.TTL)
+	}
+
+	return nil
+}
+
+func equalFloats(a, b []float64) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+func equalObjectives(a, b map[float64]float64) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for q, e := range a {
+		if o, ok := b[q]; !ok || o != e {
+			return false
+		}
+	}
+
+	return true
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,115 @@
+package metric
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDefinition_Check(t *testing.T) {
+	registered := Definition{
+		Buckets: []float64{1, 2},
+		Help:    "Duration of requests.",
+		Kind:    KindHistogram,
+		Labels:  []string{"method"},
+		Name:    "duration",
+	}
+
+	testCases := []struct {
+		Name       string
+		Registered Definition
+		Other      func(d Definition) Definition
+		Check      func(err error) bool
+	}{
+		{
+			Name:       "same definition",
+			Registered: registered,
+			Other:      func(d Definition) Definition { return d },
+			Check:      func(err error) bool { return err == nil },
+		},
+		{
+			Name:       "not registered",
+			Registered: Definition{},
+			Other:      func(d Definition) Definition { return d },
+			Check:      func(err error) bool { return err == nil },
+		},
+		{
+			Name:       "empty help not registered",
+			Registered: Definition{},
+			Other:      func(d Definition) Definition { d.Help = ""; return d },
+			Check:      IsInvalidConfig,
+		},
+		{
+			Name:       "empty help",
+			Registered: registered,
+			Other:      func(d Definition) Definition { d.Help = ""; return d },
+			Check:      IsInvalidConfig,
+		},
+		{
+			Name:       "kind",
+			Registered: registered,
+			Other:      func(d Definition) Definition { d.Kind = KindSummary; return d },
+			Check:      IsAlreadyRegistered,
+		},
+		{
+			Name:       "help",
+			Registered: registered,
+			Other:      func(d Definition) Definition { d.Help = "Other help."; return d },
+			Check:      IsConflictingDefinition,
+		},
+		{
+			Name:       "labels",
+			Registered: registered,
+			Other:      func(d Definition) Definition { d.Labels = []string{"code"}; return d },
+			Check:      IsConflictingDefinition,
+		},
+		{
+			Name:       "label order",
+			Registered: Definition{Help: "Help.", Kind: KindCounter, Labels: []string{"a", "b"}, Name: "c"},
+			Other:      func(d Definition) Definition { d.Labels = []string{"b", "a"}; return d },
+			Check:      IsConflictingDefinition,
+		},
+		{
+			Name:       "buckets",
+			Registered: registered,
+			Other:      func(d Definition) Definition { d.Buckets = []float64{1, 3}; return d },
+			Check:      IsConflictingDefinition,
+		},
+		{
+			Name:       "native bucket factor",
+			Registered: registered,
+			Other:      func(d Definition) Definition { d.NativeBucketFactor = 1.1; return d },
+			Check:      IsConflictingDefinition,
+		},
+		{
+			Name:       "objectives",
+			Registered: Definition{Help: "Help.", Kind: KindSummary, Name: "s", Objectives: map[float64]float64{0.5: 0.05}},
+			Other:      func(d Definition) Definition { d.Objectives = map[float64]float64{0.5: 0.01}; return d },
+			Check:      IsConflictingDefinition,
+		},
+		{
+			Name:       "max age",
+			Registered: Definition{Help: "Help.", Kind: KindSummary, MaxAge: time.Minute, Name: "s"},
+			Other:      func(d Definition) Definition { d.MaxAge = time.Hour; return d },
+			Check:      IsConflictingDefinition,
+		},
+		{
+			Name:       "TTL",
+			Registered: registered,
+			Other:      func(d Definition) Definition { d.TTL = time.Minute; return d },
+			Check:      IsConflictingDefinition,
+		},
+	}
+
+	for _, tc := range testCases {
+		registered := tc.Registered
+		other := registered
+		if registered.Kind == "" {
+			other = Definition{Help: "Help.", Kind: KindCounter, Name: "c"}
+		}
+
+		err := registered.Check(tc.Other(other))
+		if !tc.Check(err) {
+			t.Fatalf("%s: unexpected error %v", tc.Name, err)
+		}
+	}
+}
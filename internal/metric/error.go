@@ -0,0 +1,138 @@
+// Package metric provides the errors and helpers shared by all publisher
+// implementations, so that every kind of publisher behaves the same and its
+// errors can be asserted without knowing the kind.
+package metric
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/juju/errgo"
+)
+
+var (
+	maskAny = errgo.MaskFunc(errgo.Any)
+)
+
+func maskAnyf(err error, f string, v ...interface{}) error {
+	if err == nil {
+		return nil
+	}
+
+	f = fmt.Sprintf("%s: %s", err.Error(), f)
+	newErr := errgo.WithCausef(nil, errgo.Cause(err), f, v...)
+	newErr.(*errgo.Err).SetLocation(1)
+
+	return newErr
+}
+
+// InvalidConfigError is returned in case a publisher or one of its metrics is
+// not configured properly.
+var InvalidConfigError = errgo.New("invalid config")
+
+// IsInvalidConfig asserts InvalidConfigError.
+func IsInvalidConfig(err error) bool {
+	return hasCause(err, InvalidConfigError)
+}
+
+// AlreadyRegisteredError is returned in case a metric name is already
+// registered as another kind of metric.
+var AlreadyRegisteredError = errgo.New("already registered")
+
+// IsAlreadyRegistered asserts AlreadyRegisteredError.
+func IsAlreadyRegistered(err error) bool {
+	return hasCause(err, AlreadyRegisteredError)
+}
+
+// ConflictingDefinitionError is returned in case a metric name is already
+// registered with e.g. other labels or buckets.
+var ConflictingDefinitionError = errgo.New("conflicting definition")
+
+// IsConflictingDefinition asserts ConflictingDefinitionError.
+func IsConflictingDefinition(err error) bool {
+	return hasCause(err, ConflictingDefinitionError)
+}
+
+// PanicError is returned by wrapped actions having panicked in case the panic
+// mode is spec.PanicModeRecover.
+var PanicError = errgo.New("panic")
+
+// IsPanic asserts PanicError.
+func IsPanic(err error) bool {
+	return hasCause(err, PanicError)
+}
+
+// MissingLabelError is returned in case a label map lacks one of the labels a
+// metric has been configured with.
+var MissingLabelError = errgo.New("missing label")
+
+// IsMissingLabel asserts MissingLabelError.
+func IsMissingLabel(err error) bool {
+	return hasCause(err, MissingLabelError)
+}
+
+// UnknownLabelError is returned in case a label map holds a label a metric has
+// not been configured with.
+var UnknownLabelError = errgo.New("unknown label")
+
+// IsUnknownLabel asserts UnknownLabelError.
+func IsUnknownLabel(err error) bool {
+	return hasCause(err, UnknownLabelError)
+}
+
+// InvalidNameError is returned in case a metric name or label name is not
+// valid according to the Prometheus and OpenMetrics data model.
+var InvalidNameError = errgo.New("invalid name")
+
+// IsInvalidName asserts InvalidNameError.
+func IsInvalidName(err error) bool {
+	return hasCause(err, InvalidNameError)
+}
+
+// JoinErrors joins the given errors, e.g. of several publishers. It returns nil
+// in case no error is given. Otherwise the returned error holds the messages
+// of all given errors and can be asserted using the cause of any of them.
+func JoinErrors(errs []error) error {
+	if len(errs) == 0 {
+		return nil
+	}
+	if len(errs) == 1 {
+		return maskAny(errs[0])
+	}
+
+	return maskAny(&joinedError{errs: errs})
+}
+
+// joinedError is the cause of errors returned by JoinErrors.
+type joinedError struct {
+	errs []error
+}
+
+func (e *joinedError) Error() string {
+	var messages []string
+	for _, err := range e.errs {
+		messages = append(messages, err.Error())
+	}
+
+	return strings.Join(messages, "; ")
+}
+
+// hasCause checks whether the cause of the given error is the given cause. In
+// case the given error has been joined from several errors, all of their
+// causes are checked.
+func hasCause(err error, cause error) bool {
+	c := errgo.Cause(err)
+	if c == cause {
+		return true
+	}
+
+	if j, ok := c.(*joinedError); ok {
+		for _, e := range j.errs {
+			if hasCause(e, cause) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,55 @@
+package metric
+
+import (
+	"sort"
+	"strings"
+)
+
+// LabelValues returns the values of the given label map ordered like the given
+// label names. An error asserted by IsMissingLabel is returned in case a label
+// name is not a key of the label map. An error asserted by IsUnknownLabel is
+// returned in case the label map holds a key not being a label name.
+func LabelValues(labels []string, labelMap map[string]string) ([]string, error) {
+	var values []string
+	for _, l := range labels {
+		v, ok := labelMap[l]
+		if !ok {
+			return nil, maskAnyf(MissingLabelError, "%s", l)
+		}
+		values = append(values, v)
+	}
+
+	if len(labelMap) != len(values) {
+		var unknown []string
+		for k := range labelMap {
+			if !containsString(labels, k) {
+				unknown = append(unknown, k)
+			}
+		}
+		sort.Strings(unknown)
+
+		return nil, maskAnyf(UnknownLabelError, "%s", strings.Join(unknown, ", "))
+	}
+
+	return values, nil
+}
+
+// BindLabelValues returns the given label values preceded by the given bound
+// label values.
+func BindLabelValues(boundValues []string, values []string) []string {
+	if len(boundValues) == 0 {
+		return values
+	}
+
+	return append(append([]string(nil), boundValues...), values...)
+}
+
+func containsString(list []string, s string) bool {
+	for _, l := range list {
+		if l == s {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,136 @@
+package metric
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestLabelValues(t *testing.T) {
+	testCases := []struct {
+		Name     string
+		Labels   []string
+		LabelMap map[string]string
+		Values   []string
+		Check    func(err error) bool
+	}{
+		{
+			Name:     "ordered like labels",
+			Labels:   []string{"method", "code"},
+			LabelMap: map[string]string{"code": "200", "method": "GET"},
+			Values:   []string{"GET", "200"},
+			Check:    func(err error) bool { return err == nil },
+		},
+		{
+			Name:     "empty values",
+			Labels:   []string{"method"},
+			LabelMap: map[string]string{"method": ""},
+			Values:   []string{""},
+			Check:    func(err error) bool { return err == nil },
+		},
+		{
+			Name:     "no labels",
+			Labels:   nil,
+			LabelMap: nil,
+			Values:   nil,
+			Check:    func(err error) bool { return err == nil },
+		},
+		{
+			Name:     "missing label",
+			Labels:   []string{"method", "code"},
+			LabelMap: map[string]string{"method": "GET"},
+			Check:    IsMissingLabel,
+		},
+		{
+			Name:     "nil label map",
+			Labels:   []string{"method"},
+			LabelMap: nil,
+			Check:    IsMissingLabel,
+		},
+		{
+			Name:     "unknown label",
+			Labels:   []string{"method"},
+			LabelMap: map[string]string{"code": "200", "method": "GET"},
+			Check:    IsUnknownLabel,
+		},
+		{
+			Name:     "unknown label of unlabelled metric",
+			Labels:   nil,
+			LabelMap: map[string]string{"method": "GET"},
+			Check:    IsUnknownLabel,
+		},
+		{
+			// Missing labels are reported first, in case both apply.
+			Name:     "missing and unknown label",
+			Labels:   []string{"method"},
+			LabelMap: map[string]string{"code": "200"},
+			Check:    IsMissingLabel,
+		},
+	}
+
+	for _, tc := range testCases {
+		values, err := LabelValues(tc.Labels, tc.LabelMap)
+		if !tc.Check(err) {
+			t.Fatalf("%s: unexpected error %v", tc.Name, err)
+		}
+		if !reflect.DeepEqual(values, tc.Values) {
+			t.Fatalf("%s: expected values %q, got %q", tc.Name, tc.Values, values)
+		}
+	}
+}
+
+func TestLabelValues_UnknownLabels(t *testing.T) {
+	// All unknown labels are named in alphabetical order, so the error message
+	// does not depend on the map's iteration order.
+	_, err := LabelValues([]string{"method"}, map[string]string{"method": "GET", "path": "/", "code": "200"})
+	if !IsUnknownLabel(err) {
+		t.Fatalf("expected unknown label error, got %v", err)
+	}
+	if want := "unknown label: code, path"; err.Error() != want {
+		t.Fatalf("expected message %q, got %q", want, err.Error())
+	}
+}
+
+func TestBindLabelValues(t *testing.T) {
+	testCases := []struct {
+		Name        string
+		BoundValues []string
+		Values      []string
+		Want        []string
+	}{
+		{
+			Name:        "nothing bound",
+			BoundValues: nil,
+			Values:      []string{"GET", "200"},
+			Want:        []string{"GET", "200"},
+		},
+		{
+			Name:        "partially bound",
+			BoundValues: []string{"GET"},
+			Values:      []string{"200"},
+			Want:        []string{"GET", "200"},
+		},
+		{
+			Name:        "fully bound",
+			BoundValues: []string{"GET", "200"},
+			Values:      nil,
+			Want:        []string{"GET", "200"},
+		},
+	}
+
+	for _, tc := range testCases {
+		values := BindLabelValues(tc.BoundValues, tc.Values)
+		if !reflect.DeepEqual(values, tc.Want) {
+			t.Fatalf("%s: expected values %q, got %q", tc.Name, tc.Want, values)
+		}
+	}
+
+	// The bound values are shared by all calls of a metric returned by With,
+	// so binding values must not write into their backing array.
+	bound := make([]string, 1, 2)
+	bound[0] = "GET"
+	first := BindLabelValues(bound, []string{"200"})
+	second := BindLabelValues(bound, []string{"500"})
+	if first[1] != "200" || second[1] != "500" {
+		t.Fatalf("expected independent label values, got %q and %q", first, second)
+	}
+}
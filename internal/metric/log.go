@@ -0,0 +1,14 @@
+package metric
+
+import (
+	"log"
+)
+
+// LogError returns an error handler logging the errors of a publisher of the
+// given kind using the standard logger. It is the default handler of errors
+// publishers cannot return, e.g. the error of the final flush on shutdown.
+func LogError(kind string) func(err error) {
+	return func(err error) {
+		log.Printf("%s publisher: %s", kind, err)
+	}
+}
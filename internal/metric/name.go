@@ -0,0 +1,98 @@
+package metric
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+)
+
+const (
+	// BucketLabel is the label name reserved for the upper bounds of the
+	// buckets of histograms.
+	BucketLabel = "le"
+	// QuantileLabel is the label name reserved for the quantiles of summaries.
+	QuantileLabel = "quantile"
+	// reservedLabelPrefix is the prefix of label names reserved for internal
+	// use.
+	reservedLabelPrefix = "__"
+)
+
+var (
+	// labelNameExpression matches label names being valid according to the
+	// Prometheus and OpenMetrics data model.
+	labelNameExpression = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+	// metricNameExpression matches metric names being valid according to the
+	// Prometheus and OpenMetrics data model.
+	metricNameExpression = regexp.MustCompile(`^[a-zA-Z_:][a-zA-Z0-9_:]*$`)
+)
+
+// SanitizeName replaces all characters not being allowed in metric names by
+// underscores. Names starting with a digit are prefixed with an underscore.
+func SanitizeName(name string) string {
+	b := []byte(name)
+	for i, c := range b {
+		if (c < 'a' || c > 'z') && (c < 'A' || c > 'Z') && (c < '0' || c > '9') && c != '_' && c != ':' {
+			b[i] = '_'
+		}
+	}
+	if len(b) > 0 && b[0] >= '0' && b[0] <= '9' {
+		b = append([]byte{'_'}, b...)
+	}
+
+	return string(b)
+}
+
+// ValidateConstLabels checks whether the names of the given constant labels
+// are valid label names. Constant labels apply to every metric, so they must
+// not use the label names reserved for buckets of histograms, quantiles of
+// summaries and outcomes of wrapped actions.
+func ValidateConstLabels(constLabels map[string]string) error {
+	var names []string
+	for n := range constLabels {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+
+	err := ValidateLabels(names, nil, BucketLabel, QuantileLabel, outcomeLabel)
+	if err != nil {
+		return maskAny(err)
+	}
+
+	return nil
+}
+
+// ValidateLabels checks whether the given label names are valid and unique.
+// They must neither collide with the given constant labels nor be one of the
+// given reserved label names.
+func ValidateLabels(labels []string, constLabels map[string]string, reserved ...string) error {
+	seen := map[string]struct{}{}
+	for _, l := range labels {
+		if !labelNameExpression.MatchString(l) {
+			return maskAnyf(InvalidNameError, "label name %q must match %s", l, labelNameExpression)
+		}
+		if strings.HasPrefix(l, reservedLabelPrefix) {
+			return maskAnyf(InvalidNameError, "label name %q must not start with %s", l, reservedLabelPrefix)
+		}
+		if containsString(reserved, l) {
+			return maskAnyf(InvalidNameError, "label name %q is reserved", l)
+		}
+		if _, ok := constLabels[l]; ok {
+			return maskAnyf(InvalidNameError, "label name %q is already used by a constant label", l)
+		}
+		if _, ok := seen[l]; ok {
+			return maskAnyf(InvalidNameError, "label name %q must be unique", l)
+		}
+		seen[l] = struct{}{}
+	}
+
+	return nil
+}
+
+// ValidateName checks whether the given metric name is valid.
+func ValidateName(name string) error {
+	if !metricNameExpression.MatchString(name) {
+		return maskAnyf(InvalidNameError, "metric name %q must match %s", name, metricNameExpression)
+	}
+
+	return nil
+}
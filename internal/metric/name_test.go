@@ -0,0 +1,122 @@
+package metric
+
+import (
+	"testing"
+)
+
+func TestValidateConstLabels(t *testing.T) {
+	testCases := []struct {
+		ConstLabels map[string]string
+		Valid       bool
+	}{
+		{ConstLabels: nil, Valid: true},
+		{ConstLabels: map[string]string{"region": "eu-west", "version": "1.0"}, Valid: true},
+		// Histograms use le for the upper bounds of their buckets.
+		{ConstLabels: map[string]string{"le": "1"}, Valid: false},
+		// Summaries use quantile for their quantiles.
+		{ConstLabels: map[string]string{"quantile": "0.5"}, Valid: false},
+		// Wrapped actions use outcome for the outcome of the action.
+		{ConstLabels: map[string]string{"outcome": "success"}, Valid: false},
+		{ConstLabels: map[string]string{"__name__": "foo"}, Valid: false},
+		{ConstLabels: map[string]string{"in-valid": "foo"}, Valid: false},
+		{ConstLabels: map[string]string{"": "foo"}, Valid: false},
+	}
+
+	for i, tc := range testCases {
+		err := ValidateConstLabels(tc.ConstLabels)
+		if tc.Valid && err != nil {
+			t.Fatalf("test case %d: expected no error, got %v", i, err)
+		}
+		if !tc.Valid && !IsInvalidName(err) {
+			t.Fatalf("test case %d: expected invalid name error, got %v", i, err)
+		}
+	}
+}
+
+func TestValidateLabels(t *testing.T) {
+	testCases := []struct {
+		Labels      []string
+		ConstLabels map[string]string
+		Reserved    []string
+		Valid       bool
+	}{
+		{Labels: nil, Valid: true},
+		{Labels: []string{"method", "code", "_path", "Code2"}, Valid: true},
+		{Labels: []string{"2xx"}, Valid: false},
+		{Labels: []string{"in-valid"}, Valid: false},
+		// Label names are not allowed to contain colons, unlike metric names.
+		{Labels: []string{"http:method"}, Valid: false},
+		{Labels: []string{""}, Valid: false},
+		{Labels: []string{"__method"}, Valid: false},
+		{Labels: []string{"method", "le"}, Reserved: []string{BucketLabel}, Valid: false},
+		// Label names are only reserved if they are given.
+		{Labels: []string{"method", "le"}, Valid: true},
+		{Labels: []string{"region"}, ConstLabels: map[string]string{"region": "eu-west"}, Valid: false},
+		{Labels: []string{"method"}, ConstLabels: map[string]string{"region": "eu-west"}, Valid: true},
+		{Labels: []string{"method", "code", "method"}, Valid: false},
+	}
+
+	for i, tc := range testCases {
+		err := ValidateLabels(tc.Labels, tc.ConstLabels, tc.Reserved...)
+		if tc.Valid && err != nil {
+			t.Fatalf("test case %d: expected no error, got %v", i, err)
+		}
+		if !tc.Valid && !IsInvalidName(err) {
+			t.Fatalf("test case %d: expected invalid name error, got %v", i, err)
+		}
+	}
+}
+
+func TestValidateName(t *testing.T) {
+	testCases := []struct {
+		Name  string
+		Valid bool
+	}{
+		{Name: "requests_total", Valid: true},
+		{Name: "http:requests:rate5m", Valid: true},
+		{Name: "_requests", Valid: true},
+		{Name: "Requests2", Valid: true},
+		{Name: "", Valid: false},
+		{Name: "2xx_total", Valid: false},
+		{Name: "requests-total", Valid: false},
+		{Name: "requests total", Valid: false},
+		{Name: "requests.total", Valid: false},
+	}
+
+	for _, tc := range testCases {
+		err := ValidateName(tc.Name)
+		if tc.Valid && err != nil {
+			t.Fatalf("%q: expected no error, got %v", tc.Name, err)
+		}
+		if !tc.Valid && !IsInvalidName(err) {
+			t.Fatalf("%q: expected invalid name error, got %v", tc.Name, err)
+		}
+	}
+}
+
+func TestSanitizeName(t *testing.T) {
+	testCases := []struct {
+		Name string
+		Want string
+	}{
+		{Name: "", Want: ""},
+		{Name: "requests_total", Want: "requests_total"},
+		{Name: "http:requests", Want: "http:requests"},
+		{Name: "requests-total", Want: "requests_total"},
+		{Name: "api.v1/users", Want: "api_v1_users"},
+		{Name: "2xx", Want: "_2xx"},
+		// Multibyte characters are replaced byte by byte.
+		{Name: "größe", Want: "gr____e"},
+	}
+
+	for _, tc := range testCases {
+		got := SanitizeName(tc.Name)
+		if got != tc.Want {
+			t.Fatalf("%q: expected %q, got %q", tc.Name, tc.Want, got)
+		}
+		// Sanitized names are valid metric names.
+		if got != "" && ValidateName(got) != nil {
+			t.Fatalf("%q: expected valid name, got %q", tc.Name, got)
+		}
+	}
+}
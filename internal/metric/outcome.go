@@ -0,0 +1,74 @@
+package metric
+
+import (
+	"context"
+	"sort"
+
+	"github.com/juju/errgo"
+)
+
+const (
+	// outcomeLabel is the name of the label describing the outcome of an action
+	// wrapped using WrapContextFunc.
+	outcomeLabel = "outcome"
+
+	outcomeCanceled         = "canceled"
+	outcomeDeadlineExceeded = "deadline_exceeded"
+	outcomeError            = "error"
+	outcomePanic            = "panic"
+	outcomeSuccess          = "success"
+)
+
+// wrapBuckets are the buckets in milliseconds of the histograms tracking the
+// durations of actions wrapped using WrapFunc and WrapContextFunc.
+var wrapBuckets = []float64{1, 2, 3, 4, 5, 10, 20, 30, 40, 50, 100, 200, 300, 400, 500, 1000, 2000, 3000, 4000, 5000, 10000}
+
+// outcome describes the result of an action executed using the given context
+// and returning the given error.
+func outcome(ctx context.Context, err error) string {
+	if err == nil {
+		return outcomeSuccess
+	}
+
+	if errgo.Cause(err) == context.Canceled || ctx.Err() == context.Canceled {
+		return outcomeCanceled
+	}
+	if errgo.Cause(err) == context.DeadlineExceeded || ctx.Err() == context.DeadlineExceeded {
+		return outcomeDeadlineExceeded
+	}
+
+	return outcomeError
+}
+
+// outcomeLabels returns the names and values of the given static labels ordered
+// by name. The names are followed by the outcome label, whose value is added
+// using withOutcome once the action's outcome is known.
+func outcomeLabels(labels map[string]string) ([]string, []string, error) {
+	var names []string
+	for n := range labels {
+		if n == outcomeLabel {
+			return nil, nil, maskAnyf(InvalidConfigError, "label %s is reserved", outcomeLabel)
+		}
+		names = append(names, n)
+	}
+	sort.Strings(names)
+
+	var values []string
+	for _, n := range names {
+		values = append(values, labels[n])
+	}
+
+	names = append(names, outcomeLabel)
+
+	return names, values, nil
+}
+
+// withOutcome returns a copy of the given static label values having the given
+// outcome appended as the value of the outcome label.
+func withOutcome(values []string, outcome string) []string {
+	newValues := make([]string, 0, len(values)+1)
+	newValues = append(newValues, values...)
+	newValues = append(newValues, outcome)
+
+	return newValues
+}
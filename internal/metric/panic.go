@@ -0,0 +1,32 @@
+package metric
+
+import (
+	"runtime/debug"
+
+	"github.com/the-anna-project/instrumentor/spec"
+)
+
+// handlePanic must be deferred by wrapped functions in case the given panic
+// mode is spec.PanicModeRecover or spec.PanicModeRepanic. It recovers a panic
+// of the wrapped action and records it using the given counter, which is
+// incremented using the given label values, if any. Depending on the panic
+// mode, the panic is either continued or turned into PanicError, which is
+// assigned to the given error.
+func handlePanic(panicMode string, c spec.Counter, values []string, err *error) {
+	r := recover()
+	if r == nil {
+		return
+	}
+
+	if len(values) == 0 {
+		c.Increment(1)
+	} else {
+		c.IncrementWithLabels(1, values...)
+	}
+
+	if panicMode == spec.PanicModeRepanic {
+		panic(r)
+	}
+
+	*err = maskAnyf(PanicError, "%v\n%s", r, debug.Stack())
+}
@@ -0,0 +1,72 @@
+package metric
+
+import (
+	"strings"
+	"time"
+
+	"github.com/the-anna-project/instrumentor/spec"
+)
+
+// NewTimer creates a new timer being started now. The measured duration is
+// converted to the given unit and observed using the given observe function.
+func NewTimer(unit string, observe func(sample float64) error) spec.Timer {
+	newTimer := &timer{
+		// Internals.
+		observe: observe,
+		start:   time.Now(),
+
+		// Settings.
+		unit: unit,
+	}
+
+	return newTimer
+}
+
+type timer struct {
+	// Internals.
+	observe func(sample float64) error
+	start   time.Time
+
+	// Settings.
+	unit string
+}
+
+func (t *timer) ObserveDuration() (time.Duration, error) {
+	d := time.Since(t.start)
+
+	err := t.observe(durationValue(d, t.unit))
+	if err != nil {
+		return d, maskAny(err)
+	}
+
+	return d, nil
+}
+
+func (t *timer) Stop() {
+	t.ObserveDuration()
+}
+
+// durationValue converts the given duration to the given unit. Durations are
+// converted to seconds in case no unit is given.
+func durationValue(d time.Duration, unit string) float64 {
+	if unit == spec.UnitMilliseconds {
+		return float64(d) / float64(time.Millisecond)
+	}
+
+	return d.Seconds()
+}
+
+// UnitName returns the given metric name having the given unit appended, unless
+// the name already ends with it.
+func UnitName(name string, unit string) string {
+	if unit == "" || strings.HasSuffix(name, "_"+unit) {
+		return name
+	}
+
+	return name + "_" + unit
+}
+
+// ValidUnit checks whether the given unit is supported.
+func ValidUnit(unit string) bool {
+	return unit == "" || unit == spec.UnitMilliseconds || unit == spec.UnitSeconds
+}
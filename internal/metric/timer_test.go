@@ -0,0 +1,120 @@
+package metric
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/the-anna-project/instrumentor/spec"
+)
+
+func TestDurationValue(t *testing.T) {
+	testCases := []struct {
+		Duration time.Duration
+		Unit     string
+		Want     float64
+	}{
+		{Duration: 1500 * time.Millisecond, Unit: spec.UnitSeconds, Want: 1.5},
+		{Duration: 1500 * time.Millisecond, Unit: spec.UnitMilliseconds, Want: 1500},
+		{Duration: 1500 * time.Millisecond, Unit: "", Want: 1.5},
+		// Fractions of milliseconds are kept.
+		{Duration: 250 * time.Microsecond, Unit: spec.UnitMilliseconds, Want: 0.25},
+		{Duration: 0, Unit: spec.UnitMilliseconds, Want: 0},
+	}
+
+	for _, tc := range testCases {
+		v := durationValue(tc.Duration, tc.Unit)
+		if v != tc.Want {
+			t.Fatalf("%v in %q: expected %v, got %v", tc.Duration, tc.Unit, tc.Want, v)
+		}
+	}
+}
+
+func TestNewTimer(t *testing.T) {
+	for _, unit := range []string{spec.UnitSeconds, spec.UnitMilliseconds, ""} {
+		var samples []float64
+		timer := NewTimer(unit, func(sample float64) error {
+			samples = append(samples, sample)
+			return nil
+		})
+		time.Sleep(10 * time.Millisecond)
+
+		d, err := timer.ObserveDuration()
+		if err != nil {
+			t.Fatalf("%q: %v", unit, err)
+		}
+		if d < 10*time.Millisecond {
+			t.Fatalf("%q: expected duration of at least 10ms, got %v", unit, d)
+		}
+		// The returned duration is the observed one, converted to the unit.
+		if len(samples) != 1 || samples[0] != durationValue(d, unit) {
+			t.Fatalf("%q: expected sample of %v, got %v", unit, d, samples)
+		}
+
+		// Every call observes the duration since the timer has been started.
+		timer.Stop()
+		if len(samples) != 2 || samples[1] < samples[0] {
+			t.Fatalf("%q: expected a second, longer sample, got %v", unit, samples)
+		}
+	}
+}
+
+func TestNewTimer_Error(t *testing.T) {
+	observeError := errors.New("observe failed")
+	timer := NewTimer(spec.UnitSeconds, func(sample float64) error {
+		return observeError
+	})
+
+	// The duration is returned even though it could not be observed.
+	d, err := timer.ObserveDuration()
+	if err == nil || err.Error() != observeError.Error() {
+		t.Fatalf("expected error %v, got %v", observeError, err)
+	}
+	if d <= 0 {
+		t.Fatalf("expected positive duration, got %v", d)
+	}
+
+	// Stop ignores the error.
+	timer.Stop()
+}
+
+func TestUnitName(t *testing.T) {
+	testCases := []struct {
+		Name string
+		Unit string
+		Want string
+	}{
+		{Name: "duration", Unit: spec.UnitSeconds, Want: "duration_seconds"},
+		{Name: "duration", Unit: spec.UnitMilliseconds, Want: "duration_milliseconds"},
+		{Name: "duration_seconds", Unit: spec.UnitSeconds, Want: "duration_seconds"},
+		{Name: "duration_seconds", Unit: spec.UnitMilliseconds, Want: "duration_seconds_milliseconds"},
+		{Name: "durationseconds", Unit: spec.UnitSeconds, Want: "durationseconds_seconds"},
+		{Name: "size", Unit: "", Want: "size"},
+	}
+
+	for _, tc := range testCases {
+		name := UnitName(tc.Name, tc.Unit)
+		if name != tc.Want {
+			t.Fatalf("%s in %q: expected %s, got %s", tc.Name, tc.Unit, tc.Want, name)
+		}
+	}
+}
+
+func TestValidUnit(t *testing.T) {
+	testCases := []struct {
+		Unit  string
+		Valid bool
+	}{
+		{Unit: "", Valid: true},
+		{Unit: spec.UnitMilliseconds, Valid: true},
+		{Unit: spec.UnitSeconds, Valid: true},
+		{Unit: "minutes", Valid: false},
+		{Unit: "Seconds", Valid: false},
+	}
+
+	for _, tc := range testCases {
+		if v := ValidUnit(tc.Unit); v != tc.Valid {
+			t.Fatalf("%q: expected %v, got %v", tc.Unit, tc.Valid, v)
+		}
+	}
+}
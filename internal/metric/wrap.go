@@ -0,0 +1,122 @@
+package metric
+
+import (
+	"context"
+	"time"
+
+	"github.com/the-anna-project/instrumentor/spec"
+)
+
+// WrapContextFunc implements spec.Publisher.WrapContextFunc using the metrics
+// of the given publisher. Panics of the wrapped action are handled according to
+// the given panic mode.
+func WrapContextFunc(p spec.Publisher, panicMode string, key string, labels map[string]string, action func(ctx context.Context) error) func(ctx context.Context) error {
+	wrappedFunc := func(ctx context.Context) (err error) {
+		names, values, err := outcomeLabels(labels)
+		if err != nil {
+			return maskAny(err)
+		}
+
+		histogramConfig := p.HistogramConfig()
+		histogramConfig.SetBuckets(wrapBuckets)
+		histogramConfig.SetHelp("Duration of the wrapped action in milliseconds.")
+		histogramConfig.SetLabels(names)
+		histogramConfig.SetName(p.NewKey(key))
+		histogramConfig.SetUnit(spec.UnitMilliseconds)
+		h, err := p.Histogram(histogramConfig)
+		if err != nil {
+			return maskAny(err)
+		}
+		counterConfig := p.CounterConfig()
+		counterConfig.SetHelp("Number of errors returned by the wrapped action.")
+		counterConfig.SetLabels(names)
+		counterConfig.SetName(p.NewKey(key, "error", "total"))
+		c, err := p.Counter(counterConfig)
+		if err != nil {
+			return maskAny(err)
+		}
+		var pc spec.Counter
+		if panicMode != spec.PanicModeNone {
+			counterConfig := p.CounterConfig()
+			counterConfig.SetHelp("Number of panics of the wrapped action.")
+			counterConfig.SetLabels(names)
+			counterConfig.SetName(p.NewKey(key, "panic", "total"))
+			pc, err = p.Counter(counterConfig)
+			if err != nil {
+				return maskAny(err)
+			}
+		}
+
+		// The duration is observed once the outcome of the action is known,
+		// using the label values including the outcome. In case the action
+		// panics the outcome remains a panic.
+		start := time.Now()
+		result := outcomePanic
+		defer func() {
+			h.ObserveWithLabels(durationValue(time.Since(start), spec.UnitMilliseconds), withOutcome(values, result)...)
+		}()
+		if pc != nil {
+			defer handlePanic(panicMode, pc, withOutcome(values, outcomePanic), &err)
+		}
+
+		err = action(ctx)
+		result = outcome(ctx, err)
+		if err != nil {
+			c.IncrementWithLabels(1, withOutcome(values, result)...)
+			return maskAny(err)
+		}
+
+		return nil
+	}
+
+	return wrappedFunc
+}
+
+// WrapFunc implements spec.Publisher.WrapFunc using the metrics of the given
+// publisher. Panics of the wrapped action are handled according to the given
+// panic mode.
+func WrapFunc(p spec.Publisher, panicMode string, key string, action func() error) func() error {
+	wrappedFunc := func() (err error) {
+		histogramConfig := p.HistogramConfig()
+		histogramConfig.SetBuckets(wrapBuckets)
+		histogramConfig.SetHelp("Duration of the wrapped action in milliseconds.")
+		histogramConfig.SetName(p.NewKey(key))
+		histogramConfig.SetUnit(spec.UnitMilliseconds)
+		h, err := p.Histogram(histogramConfig)
+		if err != nil {
+			return maskAny(err)
+		}
+		counterConfig := p.CounterConfig()
+		counterConfig.SetHelp("Number of errors returned by the wrapped action.")
+		counterConfig.SetName(p.NewKey(key, "error", "total"))
+		c, err := p.Counter(counterConfig)
+		if err != nil {
+			return maskAny(err)
+		}
+		var pc spec.Counter
+		if panicMode != spec.PanicModeNone {
+			counterConfig := p.CounterConfig()
+			counterConfig.SetHelp("Number of panics of the wrapped action.")
+			counterConfig.SetName(p.NewKey(key, "panic", "total"))
+			pc, err = p.Counter(counterConfig)
+			if err != nil {
+				return maskAny(err)
+			}
+		}
+
+		defer h.StartTimer().Stop()
+		if pc != nil {
+			defer handlePanic(panicMode, pc, nil, &err)
+		}
+
+		err = action()
+		if err != nil {
+			c.Increment(1)
+			return maskAny(err)
+		}
+
+		return nil
+	}
+
+	return wrappedFunc
+}
@@ -0,0 +1,129 @@
+package metric_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/the-anna-project/instrumentor/internal/metric"
+	memorypublisher "github.com/the-anna-project/instrumentor/memory/publisher"
+	"github.com/the-anna-project/instrumentor/memory/storage"
+	"github.com/the-anna-project/instrumentor/spec"
+)
+
+// copyingPublisher creates histograms copying the label values given to
+// StartTimerWithLabels, so label values changed by the caller afterwards are
+// not seen by the timer.
+type copyingPublisher struct {
+	spec.Publisher
+}
+
+func (p copyingPublisher) Histogram(config spec.HistogramConfig) (spec.Histogram, error) {
+	h, err := p.Publisher.Histogram(config)
+	if err != nil {
+		return nil, err
+	}
+
+	return copyingHistogram{Histogram: h}, nil
+}
+
+type copyingHistogram struct {
+	spec.Histogram
+}
+
+func (h copyingHistogram) StartTimerWithLabels(values ...string) spec.Timer {
+	return h.Histogram.StartTimerWithLabels(append([]string(nil), values...)...)
+}
+
+func TestWrapContextFunc_Outcome(t *testing.T) {
+	testCases := []struct {
+		Name    string
+		Action  func(ctx context.Context) error
+		Outcome string
+		Errors  float64
+		Panics  float64
+	}{
+		{
+			Name: "success",
+			Action: func(ctx context.Context) error {
+				return nil
+			},
+			Outcome: "success",
+		},
+		{
+			Name: "error",
+			Action: func(ctx context.Context) error {
+				return errors.New("test error")
+			},
+			Outcome: "error",
+			Errors:  1,
+		},
+		{
+			Name: "canceled",
+			Action: func(ctx context.Context) error {
+				return context.Canceled
+			},
+			Outcome: "canceled",
+			Errors:  1,
+		},
+		{
+			Name: "panic",
+			Action: func(ctx context.Context) error {
+				panic("test panic")
+			},
+			Outcome: "panic",
+			Panics:  1,
+		},
+	}
+
+	for _, tc := range testCases {
+		newStorage, err := storage.NewStorage(storage.DefaultStorageConfig())
+		if err != nil {
+			t.Fatal(err)
+		}
+		config := memorypublisher.DefaultServiceConfig()
+		config.Storage = newStorage
+		s, err := memorypublisher.NewService(config)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		p := copyingPublisher{Publisher: s}
+		wrapped := metric.WrapContextFunc(p, spec.PanicModeRecover, "action", map[string]string{"route": "/a"}, tc.Action)
+		err = wrapped(context.Background())
+		if tc.Outcome == "success" && err != nil {
+			t.Fatalf("%s: expected no error, got %v", tc.Name, err)
+		}
+		if tc.Outcome == "panic" && !metric.IsPanic(err) {
+			t.Fatalf("%s: expected panic error, got %v", tc.Name, err)
+		}
+
+		// The duration is observed using the outcome of the action, even though
+		// the histogram does not see label values changed after starting a timer.
+		m, err := newStorage.Metric("action_milliseconds")
+		if err != nil {
+			t.Fatal(err)
+		}
+		series := m.SeriesList()
+		if len(series) != 1 || series[0].Count != 1 || series[0].LabelValues[1] != tc.Outcome {
+			t.Fatalf("%s: expected single observation having outcome %s, got %+v", tc.Name, tc.Outcome, series)
+		}
+
+		for name, want := range map[string]float64{"action_error_total": tc.Errors, "action_panic_total": tc.Panics} {
+			m, err := newStorage.Metric(name)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got float64
+			for _, s := range m.SeriesList() {
+				if s.LabelValues[1] != tc.Outcome {
+					t.Fatalf("%s: expected %s to have outcome %s, got %v", tc.Name, name, tc.Outcome, s.LabelValues)
+				}
+				got += s.Value
+			}
+			if got != want {
+				t.Fatalf("%s: expected %s to be %v, got %v", tc.Name, name, want, got)
+			}
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package consumer
+
+// HistogramSample represents the state of a memory storage histogram at the
+// time it has been fetched.
+type HistogramSample struct {
+	// Settings.
+	buckets             map[float64]uint64
+	count               uint64
+	nativeNegative      map[int]uint64
+	nativePositive      map[int]uint64
+	nativeSchema        int32
+	nativeZeroCount     uint64
+	nativeZeroThreshold float64
+	sum                 float64
+}
+
+func (hs *HistogramSample) Buckets() map[float64]uint64 {
+	return hs.buckets
+}
+
+func (hs *HistogramSample) Count() uint64 {
+	return hs.count
+}
+
+func (hs *HistogramSample) NativeNegativeBuckets() map[int]uint64 {
+	return hs.nativeNegative
+}
+
+func (hs *HistogramSample) NativePositiveBuckets() map[int]uint64 {
+	return hs.nativePositive
+}
+
+func (hs *HistogramSample) NativeSchema() int32 {
+	return hs.nativeSchema
+}
+
+func (hs *HistogramSample) NativeZeroCount() uint64 {
+	return hs.nativeZeroCount
+}
+
+func (hs *HistogramSample) NativeZeroThreshold() float64 {
+	return hs.nativeZeroThreshold
+}
+
+func (hs *HistogramSample) Sum() float64 {
+	return hs.sum
+}
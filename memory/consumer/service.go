@@ -4,22 +4,47 @@ package consumer
 
 import (
 	"sync"
+
+	"github.com/the-anna-project/instrumentor/memory/storage"
+	"github.com/the-anna-project/instrumentor/spec"
 )
 
 // ServiceConfig represents the configuration used to create a new memory
 // consumer service.
 type ServiceConfig struct {
+	// Dependencies.
+
+	// Storage represents the storage metric values are read from. It should be
+	// the storage of the memory publisher whose metrics are consumed. In case it
+	// is nil, which is the default, a new storage is created.
+	Storage *storage.Storage
 }
 
 // DefaultServiceConfig provides a default configuration to create a new memory
 // consumer service by best effort.
 func DefaultServiceConfig() ServiceConfig {
-	return ServiceConfig{}
+	return ServiceConfig{
+		// Dependencies.
+		Storage: nil,
+	}
 }
 
 // NewService creates a new memory consumer service.
 func NewService(config ServiceConfig) (*Service, error) {
+	// Dependencies.
+	newStorage := config.Storage
+	if newStorage == nil {
+		var err error
+		newStorage, err = storage.NewStorage(storage.DefaultStorageConfig())
+		if err != nil {
+			return nil, maskAny(err)
+		}
+	}
+
 	newService := &Service{
+		// Dependencies.
+		storage: newStorage,
+
 		// Internals.
 		bootOnce:     sync.Once{},
 		closer:       make(chan struct{}, 1),
@@ -30,6 +55,12 @@ func NewService(config ServiceConfig) (*Service, error) {
 }
 
 type Service struct {
+	// Dependencies.
+
+	// storage represents the storage the memory publisher records metric values
+	// in.
+	storage *storage.Storage
+
 	// Internals.
 	bootOnce     sync.Once
 	closer       chan struct{}
@@ -42,8 +73,95 @@ func (s *Service) Boot() {
 	})
 }
 
+func (s *Service) Counter(name string, values ...string) (float64, error) {
+	_, series, err := s.series(storage.KindCounter, name, values)
+	if err != nil {
+		return 0, maskAny(err)
+	}
+
+	return series.Value, nil
+}
+
+func (s *Service) Gauge(name string, values ...string) (float64, error) {
+	_, series, err := s.series(storage.KindGauge, name, values)
+	if err != nil {
+		return 0, maskAny(err)
+	}
+
+	return series.Value, nil
+}
+
+func (s *Service) Histogram(name string, values ...string) (spec.HistogramSample, error) {
+	m, series, err := s.series(storage.KindHistogram, name, values)
+	if err != nil {
+		return nil, maskAny(err)
+	}
+
+	buckets := map[float64]uint64{}
+	for i, b := range m.Buckets() {
+		buckets[b] = series.BucketCounts[i]
+	}
+
+	newSample := &HistogramSample{
+		buckets:         buckets,
+		count:           series.Count,
+		nativeNegative:  series.NativeNegativeBuckets,
+		nativePositive:  series.NativePositiveBuckets,
+		nativeSchema:    series.NativeSchema,
+		nativeZeroCount: series.NativeZeroCount,
+		sum:             series.Sum,
+	}
+	if m.NativeBucketFactor() > 0 {
+		newSample.nativeZeroThreshold = m.NativeZeroThreshold()
+	}
+
+	return newSample, nil
+}
+
+func (s *Service) Names() ([]string, error) {
+	return s.storage.Names(), nil
+}
+
 func (s *Service) Shutdown() {
 	s.shutdownOnce.Do(func() {
 		close(s.closer)
 	})
 }
+
+func (s *Service) Summary(name string, values ...string) (spec.SummarySample, error) {
+	_, series, err := s.series(storage.KindSummary, name, values)
+	if err != nil {
+		return nil, maskAny(err)
+	}
+
+	newSample := &SummarySample{
+		count:     series.Count,
+		quantiles: series.Quantiles,
+		sum:       series.Sum,
+	}
+
+	return newSample, nil
+}
+
+// series looks up the series identified by the given label values of the
+// metric registered under the given name, which must be of the given kind.
+func (s *Service) series(kind, name string, values []string) (*storage.Metric, storage.Series, error) {
+	m, err := s.storage.Metric(name)
+	if storage.IsNotFound(err) {
+		return nil, storage.Series{}, maskAnyf(notFoundError, "%s %s", kind, name)
+	} else if err != nil {
+		return nil, storage.Series{}, maskAny(err)
+	}
+	if m.Kind() != kind {
+		return nil, storage.Series{}, maskAnyf(notFoundError, "%s %s (registered as %s)", kind, name, m.Kind())
+	}
+
+	series, err := m.Series(values...)
+	if storage.IsNotFound(err) {
+		return nil, storage.Series{}, maskAnyf(notFoundError, "%s %s with label values %v", kind, name, values)
+	} else if err != nil {
+		return nil, storage.Series{}, maskAny(err)
+	}
+
+	return m, series, nil
+}
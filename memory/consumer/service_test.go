@@ -0,0 +1,176 @@
+package consumer
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/the-anna-project/instrumentor/memory/storage"
+)
+
+// newTestService creates a memory consumer service reading from a storage
+// having the given metrics registered.
+func newTestService(t *testing.T, configs ...storage.MetricConfig) (*Service, map[string]*storage.Metric) {
+	newStorage, err := storage.NewStorage(storage.DefaultStorageConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	metrics := map[string]*storage.Metric{}
+	for _, c := range configs {
+		m, err := storage.NewMetric(c)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = newStorage.Register(m)
+		if err != nil {
+			t.Fatal(err)
+		}
+		metrics[c.Name] = m
+	}
+
+	config := DefaultServiceConfig()
+	config.Storage = newStorage
+	s, err := NewService(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return s, metrics
+}
+
+func TestService(t *testing.T) {
+	counterConfig := storage.DefaultMetricConfig()
+	counterConfig.Kind = storage.KindCounter
+	counterConfig.Labels = []string{"method"}
+	counterConfig.Name = "requests_total"
+
+	gaugeConfig := storage.DefaultMetricConfig()
+	gaugeConfig.Kind = storage.KindGauge
+	gaugeConfig.Name = "connections"
+
+	histogramConfig := storage.DefaultMetricConfig()
+	histogramConfig.Buckets = []float64{1, 2}
+	histogramConfig.Kind = storage.KindHistogram
+	histogramConfig.Name = "duration_seconds"
+
+	summaryConfig := storage.DefaultMetricConfig()
+	summaryConfig.AgeBuckets = 1
+	summaryConfig.Kind = storage.KindSummary
+	summaryConfig.MaxAge = time.Hour
+	summaryConfig.Name = "size"
+	summaryConfig.Objectives = map[float64]float64{0.5: 0.05}
+
+	s, metrics := newTestService(t, counterConfig, gaugeConfig, histogramConfig, summaryConfig)
+
+	err := metrics["requests_total"].Add(3, "GET")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = metrics["connections"].Set(-2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, sample := range []float64{0.5, 1.5, 3} {
+		err := metrics["duration_seconds"].Observe(sample)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	for i := 1; i <= 100; i++ {
+		err := metrics["size"].Observe(float64(i))
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	names, err := s.Names()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"connections", "duration_seconds", "requests_total", "size"}; !reflect.DeepEqual(names, want) {
+		t.Fatalf("expected names %v, got %v", want, names)
+	}
+
+	counter, err := s.Counter("requests_total", "GET")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if counter != 3 {
+		t.Fatalf("expected counter %v, got %v", 3, counter)
+	}
+
+	gauge, err := s.Gauge("connections")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if gauge != -2 {
+		t.Fatalf("expected gauge %v, got %v", -2, gauge)
+	}
+
+	histogram, err := s.Histogram("duration_seconds")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := map[float64]uint64{1: 1, 2: 2}; !reflect.DeepEqual(histogram.Buckets(), want) {
+		t.Fatalf("expected buckets %v, got %v", want, histogram.Buckets())
+	}
+	if histogram.Count() != 3 || histogram.Sum() != 5 {
+		t.Fatalf("expected count 3 and sum 5, got count %d and sum %v", histogram.Count(), histogram.Sum())
+	}
+
+	summary, err := s.Summary("size")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The median of the samples 1 to 100 is estimated within its objective's
+	// error of 0.05, i.e. 5 ranks.
+	if q := summary.Quantiles()[0.5]; len(summary.Quantiles()) != 1 || q < 45 || q > 55 {
+		t.Fatalf("expected median within [45, 55], got quantiles %v", summary.Quantiles())
+	}
+	if summary.Count() != 100 || summary.Sum() != 5050 {
+		t.Fatalf("expected count 100 and sum 5050, got count %d and sum %v", summary.Count(), summary.Sum())
+	}
+}
+
+func TestService_NotFound(t *testing.T) {
+	config := storage.DefaultMetricConfig()
+	config.Kind = storage.KindCounter
+	config.Labels = []string{"method"}
+	config.Name = "requests_total"
+	s, metrics := newTestService(t, config)
+
+	err := metrics["requests_total"].Add(1, "GET")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		Name  string
+		Query func() error
+	}{
+		{
+			Name:  "unknown name",
+			Query: func() error { _, err := s.Counter("unknown", "GET"); return err },
+		},
+		{
+			Name:  "other kind",
+			Query: func() error { _, err := s.Gauge("requests_total", "GET"); return err },
+		},
+		{
+			Name:  "unknown label values",
+			Query: func() error { _, err := s.Counter("requests_total", "POST"); return err },
+		},
+		{
+			Name:  "missing label values",
+			Query: func() error { _, err := s.Counter("requests_total"); return err },
+		},
+	}
+
+	for _, tc := range testCases {
+		err := tc.Query()
+		if !IsNotFound(err) {
+			t.Fatalf("%s: expected not found error, got %v", tc.Name, err)
+		}
+	}
+}
@@ -0,0 +1,22 @@
+package consumer
+
+// SummarySample represents the state of a memory storage summary at the time
+// it has been fetched.
+type SummarySample struct {
+	// Settings.
+	count     uint64
+	quantiles map[float64]float64
+	sum       float64
+}
+
+func (ss *SummarySample) Count() uint64 {
+	return ss.count
+}
+
+func (ss *SummarySample) Quantiles() map[float64]float64 {
+	return ss.quantiles
+}
+
+func (ss *SummarySample) Sum() float64 {
+	return ss.sum
+}
@@ -1,6 +1,8 @@
 package publisher
 
 import (
+	"github.com/the-anna-project/instrumentor/internal/metric"
+	"github.com/the-anna-project/instrumentor/memory/storage"
 	"github.com/the-anna-project/instrumentor/spec"
 )
 
@@ -48,20 +50,166 @@ func DefaultCounterConfig() *CounterConfig {
 	}
 }
 
-// NewCounter creates a new configured prometheus publisher counter object.
+// NewCounter creates a new configured memory publisher counter object.
 func NewCounter(config spec.CounterConfig) (*Counter, error) {
-	newCounter := &Counter{}
+	return newCounterWithConstLabels(config, nil)
+}
+
+// newCounterWithConstLabels creates a new configured memory publisher counter
+// object having the given constant labels.
+func newCounterWithConstLabels(config spec.CounterConfig, constLabels map[string]string) (*Counter, error) {
+	// Settings.
+	if config.Name() == "" {
+		return nil, maskAnyf(invalidConfigError, "name must not be empty")
+	}
+	err := metric.ValidateName(config.Name())
+	if err != nil {
+		return nil, maskAny(err)
+	}
+	err = metric.ValidateLabels(config.Labels(), constLabels)
+	if err != nil {
+		return nil, maskAny(err)
+	}
+
+	metricConfig := storage.DefaultMetricConfig()
+	metricConfig.ConstLabels = constLabels
+	metricConfig.Help = config.Help()
+	metricConfig.Kind = storage.KindCounter
+	metricConfig.Labels = config.Labels()
+	metricConfig.Name = config.Name()
+	newMetric, err := storage.NewMetric(metricConfig)
+	if err != nil {
+		return nil, maskAny(err)
+	}
+
+	newCounter := &Counter{
+		Metric: newMetric,
+	}
 
 	return newCounter, nil
 }
 
 type Counter struct {
+	// Public.
+	Metric *storage.Metric
+
+	// Settings.
+	boundValues []string
+}
+
+// DeleteLabelValues deletes the series of the given label values, if any.
+func (c *Counter) DeleteLabelValues(values ...string) error {
+	labels := c.labels()
+	if len(labels) == 0 {
+		// This error indicates that the counter has not been configured with
+		// labels or all of its label values are already bound.
+		return maskAnyf(invalidConfigError, "counter must be configured")
+	}
+	if len(values) != len(labels) {
+		return maskAnyf(invalidConfigError, "%d label values must be given", len(labels))
+	}
+
+	err := c.Metric.Delete(metric.BindLabelValues(c.boundValues, values)...)
+	if err != nil {
+		return maskAny(err)
+	}
+
+	return nil
 }
 
 func (c *Counter) Increment(delta float64) error {
+	if len(c.labels()) != 0 {
+		// This error indicates that the counter has been configured with labels.
+		// Therefore Counter.IncrementWithLabels must be used.
+		return maskAnyf(invalidConfigError, "counter must be configured")
+	}
+	if delta < 0 {
+		return maskAnyf(invalidConfigError, "counter %s cannot decrease in value", c.Metric.Name())
+	}
+
+	err := c.Metric.Add(delta, c.boundValues...)
+	if err != nil {
+		return maskAny(err)
+	}
+
+	return nil
+}
+
+func (c *Counter) IncrementWithLabelMap(delta float64, labels map[string]string) error {
+	values, err := metric.LabelValues(c.labels(), labels)
+	if err != nil {
+		return maskAny(err)
+	}
+
+	err = c.IncrementWithLabels(delta, values...)
+	if err != nil {
+		return maskAny(err)
+	}
+
 	return nil
 }
 
 func (c *Counter) IncrementWithLabels(delta float64, values ...string) error {
+	if len(c.labels()) == 0 {
+		// This error indicates that the counter has not been configured with
+		// labels. Therefore Counter.Increment must be used.
+		return maskAnyf(invalidConfigError, "counter must be configured")
+	}
+	if len(values) == 0 {
+		return maskAnyf(invalidConfigError, "labels must not be empty")
+	}
+	if delta < 0 {
+		return maskAnyf(invalidConfigError, "counter %s cannot decrease in value", c.Metric.Name())
+	}
+
+	err := c.Metric.Add(delta, metric.BindLabelValues(c.boundValues, values)...)
+	if err != nil {
+		return maskAny(err)
+	}
+
+	return nil
+}
+
+// Reset deletes all series of the counter.
+func (c *Counter) Reset() error {
+	if len(c.Metric.Labels()) == 0 || len(c.boundValues) != 0 {
+		// This error indicates that the counter has not been configured with
+		// labels or has label values bound.
+		return maskAnyf(invalidConfigError, "counter must be configured")
+	}
+
+	c.Metric.Reset()
+
 	return nil
 }
+
+// With returns a counter having the given label values bound to the first
+// labels of the counter.
+func (c *Counter) With(values ...string) (spec.Counter, error) {
+	labels := c.labels()
+	if len(labels) == 0 {
+		// This error indicates that the counter has not been configured with
+		// labels or all of its label values are already bound.
+		return nil, maskAnyf(invalidConfigError, "counter must be configured")
+	}
+	if len(values) == 0 {
+		return nil, maskAnyf(invalidConfigError, "labels must not be empty")
+	}
+	if len(values) > len(labels) {
+		return nil, maskAnyf(invalidConfigError, "at most %d label values must be given", len(labels))
+	}
+
+	newCounter := &Counter{
+		Metric: c.Metric,
+
+		// Settings.
+		boundValues: metric.BindLabelValues(c.boundValues, values),
+	}
+
+	return newCounter, nil
+}
+
+// labels returns the labels of the counter whose values are not yet bound.
+func (c *Counter) labels() []string {
+	return c.Metric.Labels()[len(c.boundValues):]
+}
@@ -4,6 +4,8 @@ import (
 	"fmt"
 
 	"github.com/juju/errgo"
+
+	"github.com/the-anna-project/instrumentor/internal/metric"
 )
 
 var (
@@ -22,9 +24,51 @@ func maskAnyf(err error, f string, v ...interface{}) error {
 	return newErr
 }
 
-var invalidConfigError = errgo.New("invalid config")
+var invalidConfigError = metric.InvalidConfigError
 
 // IsInvalidConfig asserts invalidConfigError.
 func IsInvalidConfig(err error) bool {
-	return errgo.Cause(err) == invalidConfigError
+	return metric.IsInvalidConfig(err)
+}
+
+var alreadyRegisteredError = metric.AlreadyRegisteredError
+
+// IsAlreadyRegistered asserts alreadyRegisteredError.
+func IsAlreadyRegistered(err error) bool {
+	return metric.IsAlreadyRegistered(err)
+}
+
+var conflictingDefinitionError = metric.ConflictingDefinitionError
+
+// IsConflictingDefinition asserts conflictingDefinitionError.
+func IsConflictingDefinition(err error) bool {
+	return metric.IsConflictingDefinition(err)
+}
+
+var panicError = metric.PanicError
+
+// IsPanic asserts panicError.
+func IsPanic(err error) bool {
+	return metric.IsPanic(err)
+}
+
+var missingLabelError = metric.MissingLabelError
+
+// IsMissingLabel asserts missingLabelError.
+func IsMissingLabel(err error) bool {
+	return metric.IsMissingLabel(err)
+}
+
+var unknownLabelError = metric.UnknownLabelError
+
+// IsUnknownLabel asserts unknownLabelError.
+func IsUnknownLabel(err error) bool {
+	return metric.IsUnknownLabel(err)
+}
+
+var invalidNameError = metric.InvalidNameError
+
+// IsInvalidName asserts invalidNameError.
+func IsInvalidName(err error) bool {
+	return metric.IsInvalidName(err)
 }
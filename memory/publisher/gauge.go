@@ -1,6 +1,8 @@
 package publisher
 
 import (
+	"github.com/the-anna-project/instrumentor/internal/metric"
+	"github.com/the-anna-project/instrumentor/memory/storage"
 	"github.com/the-anna-project/instrumentor/spec"
 )
 
@@ -50,34 +52,252 @@ func DefaultGaugeConfig() *GaugeConfig {
 
 // NewGauge creates a new configured memory publisher gauge.
 func NewGauge(config spec.GaugeConfig) (*Gauge, error) {
-	newGauge := &Gauge{}
+	return newGaugeWithConstLabels(config, nil)
+}
+
+// newGaugeWithConstLabels creates a new configured memory publisher gauge
+// having the given constant labels.
+func newGaugeWithConstLabels(config spec.GaugeConfig, constLabels map[string]string) (*Gauge, error) {
+	// Settings.
+	if config.Name() == "" {
+		return nil, maskAnyf(invalidConfigError, "name must not be empty")
+	}
+	err := metric.ValidateName(config.Name())
+	if err != nil {
+		return nil, maskAny(err)
+	}
+	err = metric.ValidateLabels(config.Labels(), constLabels)
+	if err != nil {
+		return nil, maskAny(err)
+	}
+
+	metricConfig := storage.DefaultMetricConfig()
+	metricConfig.ConstLabels = constLabels
+	metricConfig.Help = config.Help()
+	metricConfig.Kind = storage.KindGauge
+	metricConfig.Labels = config.Labels()
+	metricConfig.Name = config.Name()
+	newMetric, err := storage.NewMetric(metricConfig)
+	if err != nil {
+		return nil, maskAny(err)
+	}
+
+	newGauge := &Gauge{
+		Metric: newMetric,
+	}
 
 	return newGauge, nil
 }
 
 type Gauge struct {
+	// Public.
+	Metric *storage.Metric
+
+	// Settings.
+	boundValues []string
 }
 
 func (g *Gauge) Decrement(delta float64) error {
+	if len(g.labels()) != 0 {
+		// This error indicates that the gauge has been configured with labels.
+		// Therefore Gauge.DecrementWithLabels must be used.
+		return maskAnyf(invalidConfigError, "gauge must be configured")
+	}
+
+	err := g.Metric.Add(-delta, g.boundValues...)
+	if err != nil {
+		return maskAny(err)
+	}
+
+	return nil
+}
+
+func (g *Gauge) DecrementWithLabelMap(delta float64, labels map[string]string) error {
+	values, err := metric.LabelValues(g.labels(), labels)
+	if err != nil {
+		return maskAny(err)
+	}
+
+	err = g.DecrementWithLabels(delta, values...)
+	if err != nil {
+		return maskAny(err)
+	}
+
 	return nil
 }
 
 func (g *Gauge) DecrementWithLabels(delta float64, values ...string) error {
+	if len(g.labels()) == 0 {
+		// This error indicates that the gauge has not been configured with labels.
+		// Therefore Gauge.Decrement must be used.
+		return maskAnyf(invalidConfigError, "gauge must be configured")
+	}
+	if len(values) == 0 {
+		return maskAnyf(invalidConfigError, "labels must not be empty")
+	}
+
+	err := g.Metric.Add(-delta, metric.BindLabelValues(g.boundValues, values)...)
+	if err != nil {
+		return maskAny(err)
+	}
+
+	return nil
+}
+
+// DeleteLabelValues deletes the series of the given label values, if any.
+func (g *Gauge) DeleteLabelValues(values ...string) error {
+	labels := g.labels()
+	if len(labels) == 0 {
+		// This error indicates that the gauge has not been configured with
+		// labels or all of its label values are already bound.
+		return maskAnyf(invalidConfigError, "gauge must be configured")
+	}
+	if len(values) != len(labels) {
+		return maskAnyf(invalidConfigError, "%d label values must be given", len(labels))
+	}
+
+	err := g.Metric.Delete(metric.BindLabelValues(g.boundValues, values)...)
+	if err != nil {
+		return maskAny(err)
+	}
+
 	return nil
 }
 
 func (g *Gauge) Increment(delta float64) error {
+	if len(g.labels()) != 0 {
+		// This error indicates that the gauge has been configured with labels.
+		// Therefore Gauge.IncrementWithLabels must be used.
+		return maskAnyf(invalidConfigError, "gauge must be configured")
+	}
+
+	err := g.Metric.Add(delta, g.boundValues...)
+	if err != nil {
+		return maskAny(err)
+	}
+
+	return nil
+}
+
+func (g *Gauge) IncrementWithLabelMap(delta float64, labels map[string]string) error {
+	values, err := metric.LabelValues(g.labels(), labels)
+	if err != nil {
+		return maskAny(err)
+	}
+
+	err = g.IncrementWithLabels(delta, values...)
+	if err != nil {
+		return maskAny(err)
+	}
+
 	return nil
 }
 
 func (g *Gauge) IncrementWithLabels(delta float64, values ...string) error {
+	if len(g.labels()) == 0 {
+		// This error indicates that the gauge has not been configured with labels.
+		// Therefore Gauge.Increment must be used.
+		return maskAnyf(invalidConfigError, "gauge must be configured")
+	}
+	if len(values) == 0 {
+		return maskAnyf(invalidConfigError, "labels must not be empty")
+	}
+
+	err := g.Metric.Add(delta, metric.BindLabelValues(g.boundValues, values)...)
+	if err != nil {
+		return maskAny(err)
+	}
+
 	return nil
 }
 
 func (g *Gauge) Set(value float64) error {
+	if len(g.labels()) != 0 {
+		// This error indicates that the gauge has been configured with labels.
+		// Therefore Gauge.SetWithLabels must be used.
+		return maskAnyf(invalidConfigError, "gauge must be configured")
+	}
+
+	err := g.Metric.Set(value, g.boundValues...)
+	if err != nil {
+		return maskAny(err)
+	}
+
+	return nil
+}
+
+func (g *Gauge) SetWithLabelMap(value float64, labels map[string]string) error {
+	values, err := metric.LabelValues(g.labels(), labels)
+	if err != nil {
+		return maskAny(err)
+	}
+
+	err = g.SetWithLabels(value, values...)
+	if err != nil {
+		return maskAny(err)
+	}
+
 	return nil
 }
 
 func (g *Gauge) SetWithLabels(value float64, values ...string) error {
+	if len(g.labels()) == 0 {
+		// This error indicates that the gauge has not been configured with labels.
+		// Therefore Gauge.Set must be used.
+		return maskAnyf(invalidConfigError, "gauge must be configured")
+	}
+	if len(values) == 0 {
+		return maskAnyf(invalidConfigError, "labels must not be empty")
+	}
+
+	err := g.Metric.Set(value, metric.BindLabelValues(g.boundValues, values)...)
+	if err != nil {
+		return maskAny(err)
+	}
+
 	return nil
 }
+
+// Reset deletes all series of the gauge.
+func (g *Gauge) Reset() error {
+	if len(g.Metric.Labels()) == 0 || len(g.boundValues) != 0 {
+		// This error indicates that the gauge has not been configured with
+		// labels or has label values bound.
+		return maskAnyf(invalidConfigError, "gauge must be configured")
+	}
+
+	g.Metric.Reset()
+
+	return nil
+}
+
+// With returns a gauge having the given label values bound to the first
+// labels of the gauge.
+func (g *Gauge) With(values ...string) (spec.Gauge, error) {
+	labels := g.labels()
+	if len(labels) == 0 {
+		// This error indicates that the gauge has not been configured with
+		// labels or all of its label values are already bound.
+		return nil, maskAnyf(invalidConfigError, "gauge must be configured")
+	}
+	if len(values) == 0 {
+		return nil, maskAnyf(invalidConfigError, "labels must not be empty")
+	}
+	if len(values) > len(labels) {
+		return nil, maskAnyf(invalidConfigError, "at most %d label values must be given", len(labels))
+	}
+
+	newGauge := &Gauge{
+		Metric: g.Metric,
+
+		// Settings.
+		boundValues: metric.BindLabelValues(g.boundValues, values),
+	}
+
+	return newGauge, nil
+}
+
+// labels returns the labels of the gauge whose values are not yet bound.
+func (g *Gauge) labels() []string {
+	return g.Metric.Labels()[len(g.boundValues):]
+}
@@ -1,6 +1,9 @@
 package publisher
 
 import (
+	"github.com/the-anna-project/instrumentor/bucket"
+	"github.com/the-anna-project/instrumentor/internal/metric"
+	"github.com/the-anna-project/instrumentor/memory/storage"
 	"github.com/the-anna-project/instrumentor/spec"
 )
 
@@ -8,10 +11,14 @@ import (
 // publisher histogram.
 type HistogramConfig struct {
 	// Settings.
-	buckets []float64
-	help    string
-	labels  []string
-	name    string
+	buckets               []float64
+	help                  string
+	labels                []string
+	name                  string
+	nativeBucketFactor    float64
+	nativeMaxBucketNumber uint32
+	nativeZeroThreshold   float64
+	unit                  string
 }
 
 func (hc *HistogramConfig) Buckets() []float64 {
@@ -30,6 +37,18 @@ func (hc *HistogramConfig) Name() string {
 	return hc.name
 }
 
+func (hc *HistogramConfig) NativeBucketFactor() float64 {
+	return hc.nativeBucketFactor
+}
+
+func (hc *HistogramConfig) NativeMaxBucketNumber() uint32 {
+	return hc.nativeMaxBucketNumber
+}
+
+func (hc *HistogramConfig) NativeZeroThreshold() float64 {
+	return hc.nativeZeroThreshold
+}
+
 func (hc *HistogramConfig) SetBuckets(buckets []float64) {
 	hc.buckets = buckets
 }
@@ -46,32 +65,235 @@ func (hc *HistogramConfig) SetName(name string) {
 	hc.name = name
 }
 
+func (hc *HistogramConfig) SetNativeBucketFactor(factor float64) {
+	hc.nativeBucketFactor = factor
+}
+
+func (hc *HistogramConfig) SetNativeMaxBucketNumber(number uint32) {
+	hc.nativeMaxBucketNumber = number
+}
+
+func (hc *HistogramConfig) SetNativeZeroThreshold(threshold float64) {
+	hc.nativeZeroThreshold = threshold
+}
+
+func (hc *HistogramConfig) SetUnit(unit string) {
+	hc.unit = unit
+}
+
+func (hc *HistogramConfig) Unit() string {
+	return hc.unit
+}
+
 // DefaultHistogramConfig provides a default configuration to create a new
 // memory publisher histogram by best effort.
 func DefaultHistogramConfig() *HistogramConfig {
 	return &HistogramConfig{
 		// Settings.
-		buckets: nil,
-		help:    "",
-		labels:  nil,
-		name:    "",
+		buckets:               []float64{.001, .002, .003, .004, .005, .01, .02, .03, .04, .05, .1, .2, .3, .4, .5, 1, 2, 3, 4, 5, 10},
+		help:                  "",
+		labels:                nil,
+		name:                  "",
+		nativeBucketFactor:    0,
+		nativeMaxBucketNumber: 0,
+		nativeZeroThreshold:   0,
+		unit:                  "",
 	}
 }
 
 // NewHistogram creates a new configured memory publisher histogram.
 func NewHistogram(config spec.HistogramConfig) (*Histogram, error) {
-	newHistogram := &Histogram{}
+	return newHistogramWithConstLabels(config, nil)
+}
+
+// newHistogramWithConstLabels creates a new configured memory publisher
+// histogram having the given constant labels.
+func newHistogramWithConstLabels(config spec.HistogramConfig, constLabels map[string]string) (*Histogram, error) {
+	// Settings.
+	if config.Buckets() == nil {
+		return nil, maskAnyf(invalidConfigError, "buckets must not be empty")
+	}
+	if len(config.Buckets()) < 1 {
+		return nil, maskAnyf(invalidConfigError, "buckets must contain at least 1 value")
+	}
+	err := bucket.Validate(config.Buckets())
+	if err != nil {
+		return nil, maskAny(err)
+	}
+	if config.NativeBucketFactor() != 0 && !(config.NativeBucketFactor() > 1) {
+		return nil, maskAnyf(invalidConfigError, "native bucket factor must be 0 or greater than 1")
+	}
+	if !(config.NativeZeroThreshold() >= 0) {
+		return nil, maskAnyf(invalidConfigError, "native zero threshold must not be negative")
+	}
+	if config.Name() == "" {
+		return nil, maskAnyf(invalidConfigError, "name must not be empty")
+	}
+	err = metric.ValidateName(config.Name())
+	if err != nil {
+		return nil, maskAny(err)
+	}
+	err = metric.ValidateLabels(config.Labels(), constLabels, metric.BucketLabel)
+	if err != nil {
+		return nil, maskAny(err)
+	}
+	if !metric.ValidUnit(config.Unit()) {
+		return nil, maskAnyf(invalidConfigError, "unit must be one of: %s, %s", spec.UnitMilliseconds, spec.UnitSeconds)
+	}
+
+	metricConfig := storage.DefaultMetricConfig()
+	metricConfig.Buckets = config.Buckets()
+	metricConfig.ConstLabels = constLabels
+	metricConfig.Help = config.Help()
+	metricConfig.Kind = storage.KindHistogram
+	metricConfig.Labels = config.Labels()
+	metricConfig.Name = metric.UnitName(config.Name(), config.Unit())
+	metricConfig.NativeBucketFactor = config.NativeBucketFactor()
+	metricConfig.NativeMaxBucketNumber = config.NativeMaxBucketNumber()
+	metricConfig.NativeZeroThreshold = config.NativeZeroThreshold()
+	metricConfig.Unit = config.Unit()
+	newMetric, err := storage.NewMetric(metricConfig)
+	if err != nil {
+		return nil, maskAny(err)
+	}
+
+	newHistogram := &Histogram{
+		Metric: newMetric,
+
+		// Settings.
+		unit: config.Unit(),
+	}
 
 	return newHistogram, nil
 }
 
 type Histogram struct {
+	// Public.
+	Metric *storage.Metric
+
+	// Settings.
+	boundValues []string
+	unit        string
+}
+
+// DeleteLabelValues deletes the series of the given label values, if any.
+func (h *Histogram) DeleteLabelValues(values ...string) error {
+	labels := h.labels()
+	if len(labels) == 0 {
+		// This error indicates that the histogram has not been configured with
+		// labels or all of its label values are already bound.
+		return maskAnyf(invalidConfigError, "histogram must be configured")
+	}
+	if len(values) != len(labels) {
+		return maskAnyf(invalidConfigError, "%d label values must be given", len(labels))
+	}
+
+	err := h.Metric.Delete(metric.BindLabelValues(h.boundValues, values)...)
+	if err != nil {
+		return maskAny(err)
+	}
+
+	return nil
 }
 
 func (h *Histogram) Observe(sample float64) error {
+	if len(h.labels()) != 0 {
+		// This error indicates that the histogram has been configured with labels.
+		// Therefore Histogram.ObserveWithLabels must be used.
+		return maskAnyf(invalidConfigError, "histogram must be configured")
+	}
+
+	err := h.Metric.Observe(sample, h.boundValues...)
+	if err != nil {
+		return maskAny(err)
+	}
+
+	return nil
+}
+
+func (h *Histogram) ObserveWithLabelMap(sample float64, labels map[string]string) error {
+	values, err := metric.LabelValues(h.labels(), labels)
+	if err != nil {
+		return maskAny(err)
+	}
+
+	err = h.ObserveWithLabels(sample, values...)
+	if err != nil {
+		return maskAny(err)
+	}
+
 	return nil
 }
 
 func (h *Histogram) ObserveWithLabels(sample float64, values ...string) error {
+	if len(h.labels()) == 0 {
+		// This error indicates that the histogram has not been configured with
+		// labels. Therefore Histogram.Observe must be used.
+		return maskAnyf(invalidConfigError, "histogram must be configured")
+	}
+	if len(values) == 0 {
+		return maskAnyf(invalidConfigError, "labels must not be empty")
+	}
+
+	err := h.Metric.Observe(sample, metric.BindLabelValues(h.boundValues, values)...)
+	if err != nil {
+		return maskAny(err)
+	}
+
 	return nil
 }
+
+// Reset deletes all series of the histogram.
+func (h *Histogram) Reset() error {
+	if len(h.Metric.Labels()) == 0 || len(h.boundValues) != 0 {
+		// This error indicates that the histogram has not been configured with
+		// labels or has label values bound.
+		return maskAnyf(invalidConfigError, "histogram must be configured")
+	}
+
+	h.Metric.Reset()
+
+	return nil
+}
+
+func (h *Histogram) StartTimer() spec.Timer {
+	return metric.NewTimer(h.unit, h.Observe)
+}
+
+func (h *Histogram) StartTimerWithLabels(values ...string) spec.Timer {
+	return metric.NewTimer(h.unit, func(sample float64) error {
+		return h.ObserveWithLabels(sample, values...)
+	})
+}
+
+// With returns a histogram having the given label values bound to the first
+// labels of the histogram.
+func (h *Histogram) With(values ...string) (spec.Histogram, error) {
+	labels := h.labels()
+	if len(labels) == 0 {
+		// This error indicates that the histogram has not been configured with
+		// labels or all of its label values are already bound.
+		return nil, maskAnyf(invalidConfigError, "histogram must be configured")
+	}
+	if len(values) == 0 {
+		return nil, maskAnyf(invalidConfigError, "labels must not be empty")
+	}
+	if len(values) > len(labels) {
+		return nil, maskAnyf(invalidConfigError, "at most %d label values must be given", len(labels))
+	}
+
+	newHistogram := &Histogram{
+		Metric: h.Metric,
+
+		// Settings.
+		boundValues: metric.BindLabelValues(h.boundValues, values),
+		unit:        h.unit,
+	}
+
+	return newHistogram, nil
+}
+
+// labels returns the labels of the histogram whose values are not yet bound.
+func (h *Histogram) labels() []string {
+	return h.Metric.Labels()[len(h.boundValues):]
+}
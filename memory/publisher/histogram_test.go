@@ -0,0 +1,19 @@
+package publisher
+
+import (
+	"testing"
+
+	"github.com/the-anna-project/instrumentor/bucket"
+)
+
+func TestNewHistogram_InvalidBuckets(t *testing.T) {
+	config := DefaultHistogramConfig()
+	config.SetBuckets([]float64{1, 0.5})
+	config.SetHelp("Duration of requests.")
+	config.SetName("duration")
+
+	_, err := NewHistogram(config)
+	if !bucket.IsInvalidBuckets(err) {
+		t.Fatalf("expected invalid buckets error, got %v", err)
+	}
+}
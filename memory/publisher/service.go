@@ -4,41 +4,140 @@
 package publisher
 
 import (
+	"context"
 	"net/http"
 	"strings"
 	"sync"
 
+	"github.com/the-anna-project/instrumentor/internal/metric"
+	"github.com/the-anna-project/instrumentor/memory/storage"
 	"github.com/the-anna-project/instrumentor/spec"
 )
 
 // ServiceConfig represents the configuration used to create a new memory
 // publisher service.
 type ServiceConfig struct {
+	// Dependencies.
+
+	// Storage represents the storage recorded metric values are kept in. It can
+	// be shared with a memory consumer in order to read the values back. In case
+	// it is nil, which is the default, a new storage is created.
+	Storage *storage.Storage
+
+	// Settings.
+
+	// ConstLabels represents labels having the same value for all metrics
+	// created by the service, e.g. the name of the service or its version.
+	ConstLabels map[string]string
+	PanicMode   string
+	Prefixes    []string
+	// SanitizeKeys causes NewKey to replace all characters not being allowed in
+	// metric names by underscores, e.g. in case keys are built from dynamic
+	// input. Metric names and label names are validated either way. Invalid
+	// ones cause errors asserted by IsInvalidName.
+	SanitizeKeys bool
 }
 
 // DefaultServiceConfig provides a default configuration to create a new memory
 // publisher service by best effort.
 func DefaultServiceConfig() ServiceConfig {
-	return ServiceConfig{}
+	return ServiceConfig{
+		// Dependencies.
+		Storage: nil,
+
+		// Settings.
+		ConstLabels:  map[string]string{},
+		PanicMode:    spec.PanicModeNone,
+		Prefixes:     []string{},
+		SanitizeKeys: false,
+	}
 }
 
 // NewService creates a new memory publisher service.
 func NewService(config ServiceConfig) (*Service, error) {
+	// Dependencies.
+	newStorage := config.Storage
+	if newStorage == nil {
+		var err error
+		newStorage, err = storage.NewStorage(storage.DefaultStorageConfig())
+		if err != nil {
+			return nil, maskAny(err)
+		}
+	}
+
+	// Settings.
+	if config.ConstLabels == nil {
+		return nil, maskAnyf(invalidConfigError, "const labels must not be empty")
+	}
+	err := metric.ValidateConstLabels(config.ConstLabels)
+	if err != nil {
+		return nil, maskAny(err)
+	}
+	if config.PanicMode != spec.PanicModeNone && config.PanicMode != spec.PanicModeRecover && config.PanicMode != spec.PanicModeRepanic {
+		return nil, maskAnyf(invalidConfigError, "panic mode must be one of: %s, %s, %s", spec.PanicModeNone, spec.PanicModeRecover, spec.PanicModeRepanic)
+	}
+	if config.Prefixes == nil {
+		return nil, maskAnyf(invalidConfigError, "prefixes must not be empty")
+	}
+
+	constLabels := map[string]string{}
+	for n, v := range config.ConstLabels {
+		constLabels[n] = v
+	}
+
 	newService := &Service{
+		// Dependencies.
+		storage: newStorage,
+
 		// Internals.
 		closer:       make(chan struct{}, 1),
+		counters:     map[string]*Counter{},
 		bootOnce:     sync.Once{},
+		definitions:  map[string]metric.Definition{},
+		gauges:       map[string]*Gauge{},
+		histograms:   map[string]*Histogram{},
+		mutex:        sync.Mutex{},
 		shutdownOnce: sync.Once{},
+		summaries:    map[string]*Summary{},
+
+		// Settings.
+		constLabels:  constLabels,
+		panicMode:    config.PanicMode,
+		prefixes:     config.Prefixes,
+		sanitizeKeys: config.SanitizeKeys,
 	}
 
 	return newService, nil
 }
 
 type Service struct {
+	// Dependencies.
+
+	// storage represents the storage recorded metric values are kept in.
+	storage *storage.Storage
+
 	// Internals.
 	closer       chan struct{}
+	counters     map[string]*Counter
 	bootOnce     sync.Once
+	definitions  map[string]metric.Definition
+	gauges       map[string]*Gauge
+	histograms   map[string]*Histogram
+	mutex        sync.Mutex
 	shutdownOnce sync.Once
+	summaries    map[string]*Summary
+
+	// Settings.
+
+	// constLabels represents labels having the same value for all metrics
+	// created by the service.
+	constLabels map[string]string
+	// panicMode describes how panics of wrapped actions are handled.
+	panicMode string
+	// prefixes represents the Instrumentor's ordered prefixes.
+	prefixes []string
+	// sanitizeKeys describes whether NewKey sanitizes the keys it returns.
+	sanitizeKeys bool
 }
 
 func (s *Service) Boot() {
@@ -48,10 +147,34 @@ func (s *Service) Boot() {
 }
 
 func (s *Service) Counter(config spec.CounterConfig) (spec.Counter, error) {
-	newCounter, err := NewCounter(config)
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	newDefinition := metric.Definition{
+		Help:   config.Help(),
+		Kind:   metric.KindCounter,
+		Labels: config.Labels(),
+		Name:   config.Name(),
+	}
+	d, ok := s.definitions[config.Name()]
+	err := d.Check(newDefinition)
+	if err != nil {
+		return nil, maskAny(err)
+	}
+	if ok {
+		return s.counters[config.Name()], nil
+	}
+
+	newCounter, err := newCounterWithConstLabels(config, s.constLabels)
+	if err != nil {
+		return nil, maskAny(err)
+	}
+
+	err = s.register(newDefinition, newCounter.Metric)
 	if err != nil {
 		return nil, maskAny(err)
 	}
+	s.counters[config.Name()] = newCounter
 
 	return newCounter, nil
 }
@@ -61,10 +184,34 @@ func (s *Service) CounterConfig() spec.CounterConfig {
 }
 
 func (s *Service) Gauge(config spec.GaugeConfig) (spec.Gauge, error) {
-	newGauge, err := NewGauge(config)
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	newDefinition := metric.Definition{
+		Help:   config.Help(),
+		Kind:   metric.KindGauge,
+		Labels: config.Labels(),
+		Name:   config.Name(),
+	}
+	d, ok := s.definitions[config.Name()]
+	err := d.Check(newDefinition)
+	if err != nil {
+		return nil, maskAny(err)
+	}
+	if ok {
+		return s.gauges[config.Name()], nil
+	}
+
+	newGauge, err := newGaugeWithConstLabels(config, s.constLabels)
+	if err != nil {
+		return nil, maskAny(err)
+	}
+
+	err = s.register(newDefinition, newGauge.Metric)
 	if err != nil {
 		return nil, maskAny(err)
 	}
+	s.gauges[config.Name()] = newGauge
 
 	return newGauge, nil
 }
@@ -74,10 +221,42 @@ func (s *Service) GaugeConfig() spec.GaugeConfig {
 }
 
 func (s *Service) Histogram(config spec.HistogramConfig) (spec.Histogram, error) {
-	newHistogram, err := NewHistogram(config)
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	// The name of the histogram has its unit appended, so that histograms are
+	// looked up by the name they are actually reported with.
+	name := metric.UnitName(config.Name(), config.Unit())
+
+	newDefinition := metric.Definition{
+		Buckets:               config.Buckets(),
+		Help:                  config.Help(),
+		Kind:                  metric.KindHistogram,
+		Labels:                config.Labels(),
+		Name:                  name,
+		NativeBucketFactor:    config.NativeBucketFactor(),
+		NativeMaxBucketNumber: config.NativeMaxBucketNumber(),
+		NativeZeroThreshold:   config.NativeZeroThreshold(),
+	}
+	d, ok := s.definitions[name]
+	err := d.Check(newDefinition)
+	if err != nil {
+		return nil, maskAny(err)
+	}
+	if ok {
+		return s.histograms[name], nil
+	}
+
+	newHistogram, err := newHistogramWithConstLabels(config, s.constLabels)
+	if err != nil {
+		return nil, maskAny(err)
+	}
+
+	err = s.register(newDefinition, newHistogram.Metric)
 	if err != nil {
 		return nil, maskAny(err)
 	}
+	s.histograms[name] = newHistogram
 
 	return newHistogram, nil
 }
@@ -95,11 +274,31 @@ func (s *Service) HTTPHandler() http.Handler {
 }
 
 func (s *Service) Prefixes() []string {
-	return nil
+	return s.prefixes
 }
 
 func (s *Service) NewKey(str ...string) string {
-	return strings.Join(str, "_")
+	key := strings.Join(append(append([]string{}, s.prefixes...), str...), "_")
+	if s.sanitizeKeys {
+		key = metric.SanitizeName(key)
+	}
+
+	return key
+}
+
+// register registers the given metric in the configured storage and remembers
+// the given definition of the metric. It must only be called while holding the
+// mutex.
+func (s *Service) register(d metric.Definition, metric *storage.Metric) error {
+	err := s.storage.Register(metric)
+	if storage.IsAlreadyRegistered(err) {
+		return maskAnyf(alreadyRegisteredError, "%s %s is already registered in the storage", d.Kind, d.Name)
+	} else if err != nil {
+		return maskAny(err)
+	}
+	s.definitions[d.Name] = d
+
+	return nil
 }
 
 func (s *Service) Shutdown() {
@@ -108,15 +307,50 @@ func (s *Service) Shutdown() {
 	})
 }
 
-func (s *Service) WrapFunc(key string, action func() error) func() error {
-	wrappedFunc := func() error {
-		err := action()
-		if err != nil {
-			return maskAny(err)
-		}
+func (s *Service) Summary(config spec.SummaryConfig) (spec.Summary, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	newDefinition := metric.Definition{
+		AgeBuckets: config.AgeBuckets(),
+		Help:       config.Help(),
+		Kind:       metric.KindSummary,
+		Labels:     config.Labels(),
+		MaxAge:     config.MaxAge(),
+		Name:       config.Name(),
+		Objectives: config.Objectives(),
+	}
+	d, ok := s.definitions[config.Name()]
+	err := d.Check(newDefinition)
+	if err != nil {
+		return nil, maskAny(err)
+	}
+	if ok {
+		return s.summaries[config.Name()], nil
+	}
+
+	newSummary, err := newSummaryWithConstLabels(config, s.constLabels)
+	if err != nil {
+		return nil, maskAny(err)
+	}
 
-		return nil
+	err = s.register(newDefinition, newSummary.Metric)
+	if err != nil {
+		return nil, maskAny(err)
 	}
+	s.summaries[config.Name()] = newSummary
+
+	return newSummary, nil
+}
 
-	return wrappedFunc
+func (s *Service) SummaryConfig() spec.SummaryConfig {
+	return DefaultSummaryConfig()
+}
+
+func (s *Service) WrapContextFunc(key string, labels map[string]string, action func(ctx context.Context) error) func(ctx context.Context) error {
+	return metric.WrapContextFunc(s, s.panicMode, key, labels, action)
+}
+
+func (s *Service) WrapFunc(key string, action func() error) func() error {
+	return metric.WrapFunc(s, s.panicMode, key, action)
 }
@@ -0,0 +1,319 @@
+package publisher
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/the-anna-project/instrumentor/memory/storage"
+	"github.com/the-anna-project/instrumentor/spec"
+)
+
+// newTestService creates a memory publisher service recording metric values in
+// the returned storage.
+func newTestService(t *testing.T) (*Service, *storage.Storage) {
+	newStorage, err := storage.NewStorage(storage.DefaultStorageConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	config := DefaultServiceConfig()
+	config.ConstLabels = map[string]string{"service": "api"}
+	config.Prefixes = []string{"app"}
+	config.Storage = newStorage
+	s, err := NewService(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return s, newStorage
+}
+
+// series returns the series of the given label values of the metric registered
+// in the given storage under the given name.
+func series(t *testing.T, s *storage.Storage, name string, values ...string) storage.Series {
+	m, err := s.Metric(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	series, err := m.Series(values...)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return series
+}
+
+func TestService_Counter(t *testing.T) {
+	s, st := newTestService(t)
+
+	config := s.CounterConfig()
+	config.SetHelp("Number of requests.")
+	config.SetLabels([]string{"method", "code"})
+	config.SetName(s.NewKey("requests", "total"))
+	c, err := s.Counter(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		Name   string
+		Record func() error
+		Values []string
+		Value  float64
+	}{
+		{
+			Name:   "labels",
+			Record: func() error { return c.IncrementWithLabels(2, "GET", "200") },
+			Values: []string{"GET", "200"},
+			Value:  2,
+		},
+		{
+			Name:   "label map",
+			Record: func() error { return c.IncrementWithLabelMap(3, map[string]string{"code": "200", "method": "GET"}) },
+			Values: []string{"GET", "200"},
+			Value:  5,
+		},
+		{
+			Name: "bound labels",
+			Record: func() error {
+				bound, err := c.With("POST")
+				if err != nil {
+					return err
+				}
+				return bound.IncrementWithLabels(1, "500")
+			},
+			Values: []string{"POST", "500"},
+			Value:  1,
+		},
+	}
+
+	for _, tc := range testCases {
+		err := tc.Record()
+		if err != nil {
+			t.Fatalf("%s: %v", tc.Name, err)
+		}
+		if v := series(t, st, "app_requests_total", tc.Values...).Value; v != tc.Value {
+			t.Fatalf("%s: expected value %v, got %v", tc.Name, tc.Value, v)
+		}
+	}
+
+	err = c.IncrementWithLabels(-1, "GET", "200")
+	if !IsInvalidConfig(err) {
+		t.Fatalf("expected invalid config error for negative delta, got %v", err)
+	}
+
+	m, err := st.Metric("app_requests_total")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := map[string]string{"service": "api"}; !reflect.DeepEqual(m.ConstLabels(), want) {
+		t.Fatalf("expected const labels %v, got %v", want, m.ConstLabels())
+	}
+	if m.Help() != "Number of requests." {
+		t.Fatalf("expected help %q, got %q", "Number of requests.", m.Help())
+	}
+
+	err = c.DeleteLabelValues("GET", "200")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = m.Series("GET", "200")
+	if !storage.IsNotFound(err) {
+		t.Fatalf("expected not found error for deleted series, got %v", err)
+	}
+	err = c.Reset()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if l := m.SeriesList(); len(l) != 0 {
+		t.Fatalf("expected no series after reset, got %v", l)
+	}
+}
+
+func TestService_Gauge(t *testing.T) {
+	s, st := newTestService(t)
+
+	config := s.GaugeConfig()
+	config.SetHelp("Number of connections.")
+	config.SetName(s.NewKey("connections"))
+	g, err := s.Gauge(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		Name   string
+		Record func() error
+		Value  float64
+	}{
+		{
+			Name:   "set",
+			Record: func() error { return g.Set(5) },
+			Value:  5,
+		},
+		{
+			Name:   "increment",
+			Record: func() error { return g.Increment(2) },
+			Value:  7,
+		},
+		{
+			Name:   "decrement",
+			Record: func() error { return g.Decrement(10) },
+			Value:  -3,
+		},
+	}
+
+	for _, tc := range testCases {
+		err := tc.Record()
+		if err != nil {
+			t.Fatalf("%s: %v", tc.Name, err)
+		}
+		if v := series(t, st, "app_connections").Value; v != tc.Value {
+			t.Fatalf("%s: expected value %v, got %v", tc.Name, tc.Value, v)
+		}
+	}
+
+	err = g.SetWithLabels(1, "x")
+	if !IsInvalidConfig(err) {
+		t.Fatalf("expected invalid config error for label values of unlabelled gauge, got %v", err)
+	}
+}
+
+func TestService_Histogram(t *testing.T) {
+	s, st := newTestService(t)
+
+	config := s.HistogramConfig()
+	config.SetBuckets([]float64{0.1, 1})
+	config.SetHelp("Duration of requests.")
+	config.SetLabels([]string{"method"})
+	config.SetName(s.NewKey("duration"))
+	config.SetUnit(spec.UnitSeconds)
+	h, err := s.Histogram(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, sample := range []float64{0.05, 0.5, 2} {
+		err := h.ObserveWithLabels(sample, "GET")
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// The histogram is registered with its unit appended to its name.
+	got := series(t, st, "app_duration_seconds", "GET")
+	if want := []uint64{1, 2}; !reflect.DeepEqual(got.BucketCounts, want) {
+		t.Fatalf("expected bucket counts %v, got %v", want, got.BucketCounts)
+	}
+	if got.Count != 3 {
+		t.Fatalf("expected count %d, got %d", 3, got.Count)
+	}
+	if got.Sum != 2.55 {
+		t.Fatalf("expected sum %v, got %v", 2.55, got.Sum)
+	}
+
+	// The same histogram is returned for the same config, so no new metric is
+	// registered in the storage.
+	other, err := s.Histogram(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if other != h {
+		t.Fatalf("expected the registered histogram to be returned")
+	}
+	if want := []string{"app_duration_seconds"}; !reflect.DeepEqual(st.Names(), want) {
+		t.Fatalf("expected names %v, got %v", want, st.Names())
+	}
+}
+
+func TestService_Register(t *testing.T) {
+	s, st := newTestService(t)
+
+	newConfig := func(name, help string, labels []string) spec.CounterConfig {
+		config := s.CounterConfig()
+		config.SetHelp(help)
+		config.SetLabels(labels)
+		config.SetName(name)
+		return config
+	}
+
+	c, err := s.Counter(newConfig("requests_total", "Number of requests.", []string{"method"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		Name     string
+		Register func() (interface{}, error)
+		Check    func(err error) bool
+	}{
+		{
+			Name: "same definition",
+			Register: func() (interface{}, error) {
+				return s.Counter(newConfig("requests_total", "Number of requests.", []string{"method"}))
+			},
+			Check: func(err error) bool { return err == nil },
+		},
+		{
+			Name: "other kind",
+			Register: func() (interface{}, error) {
+				config := s.GaugeConfig()
+				config.SetHelp("Number of requests.")
+				config.SetLabels([]string{"method"})
+				config.SetName("requests_total")
+				return s.Gauge(config)
+			},
+			Check: IsAlreadyRegistered,
+		},
+		{
+			Name: "other help",
+			Register: func() (interface{}, error) {
+				return s.Counter(newConfig("requests_total", "Number of handled requests.", []string{"method"}))
+			},
+			Check: IsConflictingDefinition,
+		},
+		{
+			Name: "other labels",
+			Register: func() (interface{}, error) {
+				return s.Counter(newConfig("requests_total", "Number of requests.", []string{"method", "code"}))
+			},
+			Check: IsConflictingDefinition,
+		},
+		{
+			Name: "empty help",
+			Register: func() (interface{}, error) {
+				return s.Counter(newConfig("errors_total", "", nil))
+			},
+			Check: IsInvalidConfig,
+		},
+		{
+			Name: "registered by other service",
+			Register: func() (interface{}, error) {
+				// Services sharing a storage do not share their definitions, so the
+				// conflict is detected by the storage.
+				config := DefaultServiceConfig()
+				config.Storage = st
+				other, err := NewService(config)
+				if err != nil {
+					return nil, err
+				}
+				return other.Counter(newConfig("requests_total", "Number of requests.", []string{"method"}))
+			},
+			Check: IsAlreadyRegistered,
+		},
+	}
+
+	for _, tc := range testCases {
+		registered, err := tc.Register()
+		if !tc.Check(err) {
+			t.Fatalf("%s: unexpected error %v", tc.Name, err)
+		}
+		if err == nil && registered != c {
+			t.Fatalf("%s: expected the registered counter to be returned", tc.Name)
+		}
+	}
+
+	if want := []string{"requests_total"}; !reflect.DeepEqual(st.Names(), want) {
+		t.Fatalf("expected names %v, got %v", want, st.Names())
+	}
+}
@@ -0,0 +1,252 @@
+package publisher
+
+import (
+	"time"
+
+	"github.com/the-anna-project/instrumentor/internal/metric"
+	"github.com/the-anna-project/instrumentor/memory/storage"
+	"github.com/the-anna-project/instrumentor/spec"
+)
+
+// SummaryConfig represents the configuration used to create a new memory
+// publisher summary.
+type SummaryConfig struct {
+	// Settings.
+	ageBuckets uint32
+	help       string
+	labels     []string
+	maxAge     time.Duration
+	name       string
+	objectives map[float64]float64
+}
+
+func (sc *SummaryConfig) AgeBuckets() uint32 {
+	return sc.ageBuckets
+}
+
+func (sc *SummaryConfig) Help() string {
+	return sc.help
+}
+
+func (sc *SummaryConfig) Labels() []string {
+	return sc.labels
+}
+
+func (sc *SummaryConfig) MaxAge() time.Duration {
+	return sc.maxAge
+}
+
+func (sc *SummaryConfig) Name() string {
+	return sc.name
+}
+
+func (sc *SummaryConfig) Objectives() map[float64]float64 {
+	return sc.objectives
+}
+
+func (sc *SummaryConfig) SetAgeBuckets(ageBuckets uint32) {
+	sc.ageBuckets = ageBuckets
+}
+
+func (sc *SummaryConfig) SetHelp(help string) {
+	sc.help = help
+}
+
+func (sc *SummaryConfig) SetLabels(labels []string) {
+	sc.labels = labels
+}
+
+func (sc *SummaryConfig) SetMaxAge(maxAge time.Duration) {
+	sc.maxAge = maxAge
+}
+
+func (sc *SummaryConfig) SetName(name string) {
+	sc.name = name
+}
+
+func (sc *SummaryConfig) SetObjectives(objectives map[float64]float64) {
+	sc.objectives = objectives
+}
+
+// DefaultSummaryConfig provides a default configuration to create a new memory
+// publisher summary by best effort.
+func DefaultSummaryConfig() *SummaryConfig {
+	return &SummaryConfig{
+		// Settings.
+		ageBuckets: 5,
+		help:       "",
+		labels:     nil,
+		maxAge:     10 * time.Minute,
+		name:       "",
+		objectives: map[float64]float64{0.5: 0.05, 0.9: 0.01, 0.99: 0.001},
+	}
+}
+
+// NewSummary creates a new configured memory publisher summary.
+func NewSummary(config spec.SummaryConfig) (*Summary, error) {
+	return newSummaryWithConstLabels(config, nil)
+}
+
+// newSummaryWithConstLabels creates a new configured memory publisher summary
+// having the given constant labels.
+func newSummaryWithConstLabels(config spec.SummaryConfig, constLabels map[string]string) (*Summary, error) {
+	// Settings.
+	if config.Name() == "" {
+		return nil, maskAnyf(invalidConfigError, "name must not be empty")
+	}
+	err := metric.ValidateName(config.Name())
+	if err != nil {
+		return nil, maskAny(err)
+	}
+	err = metric.ValidateLabels(config.Labels(), constLabels, metric.QuantileLabel)
+	if err != nil {
+		return nil, maskAny(err)
+	}
+	for q, e := range config.Objectives() {
+		if q < 0 || q > 1 {
+			return nil, maskAnyf(invalidConfigError, "objective quantile %v must be between 0 and 1", q)
+		}
+		if e < 0 {
+			return nil, maskAnyf(invalidConfigError, "objective error %v must not be negative", e)
+		}
+	}
+
+	metricConfig := storage.DefaultMetricConfig()
+	metricConfig.AgeBuckets = config.AgeBuckets()
+	metricConfig.ConstLabels = constLabels
+	metricConfig.Help = config.Help()
+	metricConfig.Kind = storage.KindSummary
+	metricConfig.Labels = config.Labels()
+	metricConfig.MaxAge = config.MaxAge()
+	metricConfig.Name = config.Name()
+	metricConfig.Objectives = config.Objectives()
+	newMetric, err := storage.NewMetric(metricConfig)
+	if err != nil {
+		return nil, maskAny(err)
+	}
+
+	newSummary := &Summary{
+		Metric: newMetric,
+	}
+
+	return newSummary, nil
+}
+
+type Summary struct {
+	// Public.
+	Metric *storage.Metric
+
+	// Settings.
+	boundValues []string
+}
+
+// DeleteLabelValues deletes the series of the given label values, if any.
+func (s *Summary) DeleteLabelValues(values ...string) error {
+	labels := s.labels()
+	if len(labels) == 0 {
+		// This error indicates that the summary has not been configured with
+		// labels or all of its label values are already bound.
+		return maskAnyf(invalidConfigError, "summary must be configured")
+	}
+	if len(values) != len(labels) {
+		return maskAnyf(invalidConfigError, "%d label values must be given", len(labels))
+	}
+
+	err := s.Metric.Delete(metric.BindLabelValues(s.boundValues, values)...)
+	if err != nil {
+		return maskAny(err)
+	}
+
+	return nil
+}
+
+func (s *Summary) Observe(sample float64) error {
+	if len(s.labels()) != 0 {
+		// This error indicates that the summary has been configured with labels.
+		// Therefore Summary.ObserveWithLabels must be used.
+		return maskAnyf(invalidConfigError, "summary must be configured")
+	}
+
+	err := s.Metric.Observe(sample, s.boundValues...)
+	if err != nil {
+		return maskAny(err)
+	}
+
+	return nil
+}
+
+func (s *Summary) ObserveWithLabelMap(sample float64, labels map[string]string) error {
+	values, err := metric.LabelValues(s.labels(), labels)
+	if err != nil {
+		return maskAny(err)
+	}
+
+	err = s.ObserveWithLabels(sample, values...)
+	if err != nil {
+		return maskAny(err)
+	}
+
+	return nil
+}
+
+func (s *Summary) ObserveWithLabels(sample float64, values ...string) error {
+	if len(s.labels()) == 0 {
+		// This error indicates that the summary has not been configured with
+		// labels. Therefore Summary.Observe must be used.
+		return maskAnyf(invalidConfigError, "summary must be configured")
+	}
+	if len(values) == 0 {
+		return maskAnyf(invalidConfigError, "labels must not be empty")
+	}
+
+	err := s.Metric.Observe(sample, metric.BindLabelValues(s.boundValues, values)...)
+	if err != nil {
+		return maskAny(err)
+	}
+
+	return nil
+}
+
+// Reset deletes all series of the summary.
+func (s *Summary) Reset() error {
+	if len(s.Metric.Labels()) == 0 || len(s.boundValues) != 0 {
+		// This error indicates that the summary has not been configured with
+		// labels or has label values bound.
+		return maskAnyf(invalidConfigError, "summary must be configured")
+	}
+
+	s.Metric.Reset()
+
+	return nil
+}
+
+// With returns a summary having the given label values bound to the first
+// labels of the summary.
+func (s *Summary) With(values ...string) (spec.Summary, error) {
+	labels := s.labels()
+	if len(labels) == 0 {
+		// This error indicates that the summary has not been configured with
+		// labels or all of its label values are already bound.
+		return nil, maskAnyf(invalidConfigError, "summary must be configured")
+	}
+	if len(values) == 0 {
+		return nil, maskAnyf(invalidConfigError, "labels must not be empty")
+	}
+	if len(values) > len(labels) {
+		return nil, maskAnyf(invalidConfigError, "at most %d label values must be given", len(labels))
+	}
+
+	newSummary := &Summary{
+		Metric: s.Metric,
+
+		// Settings.
+		boundValues: metric.BindLabelValues(s.boundValues, values),
+	}
+
+	return newSummary, nil
+}
+
+// labels returns the labels of the summary whose values are not yet bound.
+func (s *Summary) labels() []string {
+	return s.Metric.Labels()[len(s.boundValues):]
+}
@@ -0,0 +1,494 @@
+package storage
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// KindCounter is the kind of a metric that can only be incremented.
+	KindCounter = "counter"
+	// KindGauge is the kind of a metric that can be arbitrarily incremented,
+	// decremented and set.
+	KindGauge = "gauge"
+	// KindHistogram is the kind of a metric that counts observed samples in
+	// configured buckets.
+	KindHistogram = "histogram"
+	// KindSummary is the kind of a metric that estimates configured quantiles of
+	// observed samples over a sliding time window.
+	KindSummary = "summary"
+)
+
+// labelValueSeparator is used to join label values in order to create the key
+// of a series. It is a byte that is not expected to be part of any label value.
+const labelValueSeparator = "\xff"
+
+// MetricConfig represents the configuration used to create a new memory
+// storage metric.
+type MetricConfig struct {
+	// Settings.
+
+	// AgeBuckets represents the number of buckets the sliding time window of a
+	// summary is divided into. It is only used in case Kind is KindSummary.
+	AgeBuckets uint32
+	// Buckets represents the ordered upper bounds of the buckets of a histogram.
+	// It is only used in case Kind is KindHistogram.
+	Buckets []float64
+	// ConstLabels represents labels having the same value for all series of the
+	// metric. They are not part of the label values identifying a series.
+	ConstLabels map[string]string
+	// Help represents some sort of informative description of the metric.
+	Help string
+	// Kind represents the kind of the metric. It is one of KindCounter,
+	// KindGauge, KindHistogram or KindSummary.
+	Kind string
+	// Labels represents the ordered label names partitioning the metric.
+	Labels []string
+	// MaxAge represents the duration of the sliding time window of a summary.
+	// It is only used in case Kind is KindSummary.
+	MaxAge time.Duration
+	// Name represents the metric's key as it is registered in the storage.
+	Name string
+	// NativeBucketFactor represents the growth factor of the buckets of the
+	// native histogram maintained next to Buckets. Native histograms are
+	// disabled in case it is 0. It is only used in case Kind is KindHistogram.
+	NativeBucketFactor float64
+	// NativeMaxBucketNumber represents the maximum number of buckets of a series
+	// of the native histogram. There is no limit in case it is 0.
+	NativeMaxBucketNumber uint32
+	// NativeZeroThreshold represents the width of the zero bucket of the native
+	// histogram. DefaultNativeZeroThreshold is used in case it is 0.
+	NativeZeroThreshold float64
+	// Objectives represents the quantiles a summary estimates, mapped to their
+	// absolute error. It is only used in case Kind is KindSummary.
+	Objectives map[float64]float64
+	// Unit represents the unit of the samples observed by a histogram, which is
+	// one of spec.UnitMilliseconds and spec.UnitSeconds. Histograms not tracking
+	// durations have no unit, which is the empty string.
+	Unit string
+}
+
+// DefaultMetricConfig provides a default configuration to create a new memory
+// storage metric by best effort.
+func DefaultMetricConfig() MetricConfig {
+	return MetricConfig{
+		// Settings.
+		AgeBuckets:            0,
+		Buckets:               nil,
+		ConstLabels:           nil,
+		Help:                  "",
+		Kind:                  "",
+		Labels:                nil,
+		MaxAge:                0,
+		Name:                  "",
+		NativeBucketFactor:    0,
+		NativeMaxBucketNumber: 0,
+		NativeZeroThreshold:   0,
+		Objectives:            nil,
+		Unit:                  "",
+	}
+}
+
+// NewMetric creates a new configured memory storage metric.
+func NewMetric(config MetricConfig) (*Metric, error) {
+	// Settings.
+	if config.Kind != KindCounter && config.Kind != KindGauge && config.Kind != KindHistogram && config.Kind != KindSummary {
+		return nil, maskAnyf(invalidConfigError, "kind must be one of: %s, %s, %s, %s", KindCounter, KindGauge, KindHistogram, KindSummary)
+	}
+	if config.Kind == KindHistogram && len(config.Buckets) == 0 {
+		return nil, maskAnyf(invalidConfigError, "buckets must not be empty")
+	}
+	if config.Kind == KindSummary {
+		if config.AgeBuckets == 0 {
+			return nil, maskAnyf(invalidConfigError, "age buckets must be greater than 0")
+		}
+		if config.MaxAge < time.Duration(config.AgeBuckets) {
+			return nil, maskAnyf(invalidConfigError, "max age must be at least 1 nanosecond per age bucket")
+		}
+	}
+	if config.Name == "" {
+		return nil, maskAnyf(invalidConfigError, "name must not be empty")
+	}
+	if config.NativeBucketFactor != 0 && !(config.NativeBucketFactor > 1) {
+		return nil, maskAnyf(invalidConfigError, "native bucket factor must be 0 or greater than 1")
+	}
+	if !(config.NativeZeroThreshold >= 0) {
+		return nil, maskAnyf(invalidConfigError, "native zero threshold must not be negative")
+	}
+	for n := range config.ConstLabels {
+		if n == "" {
+			return nil, maskAnyf(invalidConfigError, "const label names must not be empty")
+		}
+		for _, l := range config.Labels {
+			if l == n {
+				return nil, maskAnyf(invalidConfigError, "label %s must not be used as const label", n)
+			}
+		}
+	}
+
+	nativeZeroThreshold := config.NativeZeroThreshold
+	if nativeZeroThreshold == 0 {
+		nativeZeroThreshold = DefaultNativeZeroThreshold
+	}
+
+	newMetric := &Metric{
+		// Internals.
+		mutex:  sync.Mutex{},
+		series: map[string]*Series{},
+
+		// Settings.
+		ageBuckets:            config.AgeBuckets,
+		buckets:               append([]float64(nil), config.Buckets...),
+		constLabels:           copyConstLabels(config.ConstLabels),
+		help:                  config.Help,
+		kind:                  config.Kind,
+		labels:                append([]string(nil), config.Labels...),
+		maxAge:                config.MaxAge,
+		name:                  config.Name,
+		nativeBucketFactor:    config.NativeBucketFactor,
+		nativeMaxBucketNumber: config.NativeMaxBucketNumber,
+		nativeZeroThreshold:   nativeZeroThreshold,
+		objectives:            copyObjectives(config.Objectives),
+		unit:                  config.Unit,
+	}
+
+	return newMetric, nil
+}
+
+// Metric holds all series of a single metric. A series is identified by the
+// label values partitioning the metric. All methods are safe for concurrent
+// use.
+type Metric struct {
+	// Internals.
+	mutex  sync.Mutex
+	series map[string]*Series
+
+	// Settings.
+	ageBuckets            uint32
+	buckets               []float64
+	constLabels           map[string]string
+	help                  string
+	kind                  string
+	labels                []string
+	maxAge                time.Duration
+	name                  string
+	nativeBucketFactor    float64
+	nativeMaxBucketNumber uint32
+	nativeZeroThreshold   float64
+	objectives            map[float64]float64
+	unit                  string
+}
+
+// Add adds the given delta to the value of the series identified by the given
+// label values. Counters must not be decremented, so a negative delta is
+// rejected in this case.
+func (m *Metric) Add(delta float64, values ...string) error {
+	if m.kind != KindCounter && m.kind != KindGauge {
+		return maskAnyf(invalidConfigError, "%s %s cannot be added to", m.kind, m.name)
+	}
+	if m.kind == KindCounter && delta < 0 {
+		return maskAnyf(invalidConfigError, "counter %s cannot decrease in value", m.name)
+	}
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	s, err := m.getOrCreateSeries(values)
+	if err != nil {
+		return maskAny(err)
+	}
+	s.Value += delta
+
+	return nil
+}
+
+func (m *Metric) AgeBuckets() uint32 {
+	return m.ageBuckets
+}
+
+func (m *Metric) Buckets() []float64 {
+	return m.buckets
+}
+
+func (m *Metric) ConstLabels() map[string]string {
+	return m.constLabels
+}
+
+// Delete deletes the series identified by the given label values, if any.
+func (m *Metric) Delete(values ...string) error {
+	if len(values) != len(m.labels) {
+		return maskAnyf(invalidConfigError, "%s expects %d label values, got %d", m.name, len(m.labels), len(values))
+	}
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	delete(m.series, strings.Join(values, labelValueSeparator))
+
+	return nil
+}
+
+func (m *Metric) Help() string {
+	return m.help
+}
+
+func (m *Metric) Kind() string {
+	return m.kind
+}
+
+func (m *Metric) Labels() []string {
+	return m.labels
+}
+
+func (m *Metric) MaxAge() time.Duration {
+	return m.maxAge
+}
+
+func (m *Metric) Name() string {
+	return m.name
+}
+
+func (m *Metric) NativeBucketFactor() float64 {
+	return m.nativeBucketFactor
+}
+
+func (m *Metric) NativeMaxBucketNumber() uint32 {
+	return m.nativeMaxBucketNumber
+}
+
+func (m *Metric) NativeZeroThreshold() float64 {
+	return m.nativeZeroThreshold
+}
+
+func (m *Metric) Objectives() map[float64]float64 {
+	return m.objectives
+}
+
+// Observe tracks the given sample in the histogram or summary series
+// identified by the given label values.
+func (m *Metric) Observe(sample float64, values ...string) error {
+	if m.kind != KindHistogram && m.kind != KindSummary {
+		return maskAnyf(invalidConfigError, "%s %s cannot be observed", m.kind, m.name)
+	}
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	s, err := m.getOrCreateSeries(values)
+	if err != nil {
+		return maskAny(err)
+	}
+	for i, b := range m.buckets {
+		if sample <= b {
+			s.BucketCounts[i]++
+		}
+	}
+	if m.nativeBucketFactor > 0 {
+		s.observeNative(sample, m.nativeZeroThreshold, m.nativeMaxBucketNumber)
+	}
+	if s.streams != nil {
+		s.streams.insert(sample)
+	}
+	s.Count++
+	s.Sum += sample
+
+	return nil
+}
+
+// Reset deletes all series of the metric. Series being used again afterwards
+// restart, like deleted ones.
+func (m *Metric) Reset() {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.series = map[string]*Series{}
+}
+
+// Series returns a copy of the series identified by the given label values.
+func (m *Metric) Series(values ...string) (Series, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	s, ok := m.series[strings.Join(values, labelValueSeparator)]
+	if !ok || len(values) != len(m.labels) {
+		return Series{}, maskAnyf(notFoundError, "series %v of %s", values, m.name)
+	}
+
+	return s.copy(m.objectives), nil
+}
+
+// SeriesList returns copies of all series of the metric, ordered by their
+// label values.
+func (m *Metric) SeriesList() []Series {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	var list []Series
+	for _, s := range m.series {
+		list = append(list, s.copy(m.objectives))
+	}
+	// The series are not ordered by their keys, since the label value separator
+	// would order label values after the ones they are a prefix of.
+	sort.Slice(list, func(i, j int) bool {
+		return lessLabelValues(list[i].LabelValues, list[j].LabelValues)
+	})
+
+	return list
+}
+
+// lessLabelValues reports whether the label values a are ordered before the
+// label values b, comparing them one by one.
+func lessLabelValues(a, b []string) bool {
+	for i := range a {
+		if i >= len(b) {
+			return false
+		}
+		if a[i] != b[i] {
+			return a[i] < b[i]
+		}
+	}
+
+	return len(a) < len(b)
+}
+
+// Set sets the value of the gauge series identified by the given label values.
+func (m *Metric) Set(value float64, values ...string) error {
+	if m.kind != KindGauge {
+		return maskAnyf(invalidConfigError, "%s %s cannot be set", m.kind, m.name)
+	}
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	s, err := m.getOrCreateSeries(values)
+	if err != nil {
+		return maskAny(err)
+	}
+	s.Value = value
+
+	return nil
+}
+
+func (m *Metric) Unit() string {
+	return m.unit
+}
+
+// getOrCreateSeries must only be called while holding the mutex.
+func (m *Metric) getOrCreateSeries(values []string) (*Series, error) {
+	if len(values) != len(m.labels) {
+		return nil, maskAnyf(invalidConfigError, "%s expects %d label values, got %d", m.name, len(m.labels), len(values))
+	}
+
+	key := strings.Join(values, labelValueSeparator)
+	s, ok := m.series[key]
+	if !ok {
+		s = &Series{
+			LabelValues: append([]string(nil), values...),
+			Start:       time.Now(),
+		}
+		if m.kind == KindHistogram {
+			s.BucketCounts = make([]uint64, len(m.buckets))
+		}
+		if m.kind == KindHistogram && m.nativeBucketFactor > 0 {
+			s.NativeNegativeBuckets = map[int]uint64{}
+			s.NativePositiveBuckets = map[int]uint64{}
+			s.NativeSchema = nativeSchema(m.nativeBucketFactor)
+		}
+		if m.kind == KindSummary {
+			s.streams = newAgeingQuantileStreams(m.objectives, m.maxAge, m.ageBuckets)
+		}
+		m.series[key] = s
+	}
+
+	return s, nil
+}
+
+// Series represents the state of a single partition of a metric.
+type Series struct {
+	// BucketCounts holds the cumulative sample counts of a histogram series. The
+	// count at index i corresponds to the upper bound at index i of the metric's
+	// buckets.
+	BucketCounts []uint64
+	// Count holds the number of samples observed by a histogram or summary
+	// series.
+	Count uint64
+	// LabelValues holds the label values identifying the series.
+	LabelValues []string
+	// NativeNegativeBuckets holds the sample counts of the negative buckets of
+	// a native histogram series, keyed by their index. The bucket having index
+	// i counts the samples whose absolute value is within (base^(i-1), base^i],
+	// where base is 2^(2^-NativeSchema).
+	NativeNegativeBuckets map[int]uint64
+	// NativePositiveBuckets holds the sample counts of the positive buckets of
+	// a native histogram series, keyed by their index like
+	// NativeNegativeBuckets.
+	NativePositiveBuckets map[int]uint64
+	// NativeSchema holds the current schema of a native histogram series. It
+	// decreases in case the maximum number of buckets is exceeded.
+	NativeSchema int32
+	// NativeZeroCount holds the number of samples of a native histogram series
+	// counted in the zero bucket.
+	NativeZeroCount uint64
+	// Quantiles holds the estimated quantiles of a summary series, keyed by the
+	// metric's objectives. It is only set on copies of a series.
+	Quantiles map[float64]float64
+	// Start holds the time the series has been created. Series being deleted,
+	// e.g. by resetting the metric, start again once they are used again.
+	Start time.Time
+	// Sum holds the sum of all samples observed by a histogram or summary
+	// series.
+	Sum float64
+	// Value holds the current value of a counter or gauge series.
+	Value float64
+
+	// Internals.
+	streams *ageingQuantileStreams
+}
+
+func (s *Series) copy(objectives map[float64]float64) Series {
+	newSeries := Series{
+		BucketCounts:          append([]uint64(nil), s.BucketCounts...),
+		Count:                 s.Count,
+		LabelValues:           append([]string(nil), s.LabelValues...),
+		NativeNegativeBuckets: copyNativeBuckets(s.NativeNegativeBuckets),
+		NativePositiveBuckets: copyNativeBuckets(s.NativePositiveBuckets),
+		NativeSchema:          s.NativeSchema,
+		NativeZeroCount:       s.NativeZeroCount,
+		Start:                 s.Start,
+		Sum:                   s.Sum,
+		Value:                 s.Value,
+	}
+
+	if s.streams != nil {
+		newSeries.Quantiles = map[float64]float64{}
+		for q := range objectives {
+			newSeries.Quantiles[q] = s.streams.query(q)
+		}
+	}
+
+	return newSeries
+}
+
+func copyConstLabels(constLabels map[string]string) map[string]string {
+	newConstLabels := map[string]string{}
+	for n, v := range constLabels {
+		newConstLabels[n] = v
+	}
+
+	return newConstLabels
+}
+
+func copyObjectives(objectives map[float64]float64) map[float64]float64 {
+	if objectives == nil {
+		return nil
+	}
+
+	newObjectives := map[float64]float64{}
+	for q, e := range objectives {
+		newObjectives[q] = e
+	}
+
+	return newObjectives
+}
@@ -0,0 +1,127 @@
+package storage
+
+import (
+	"math"
+	"sort"
+)
+
+// DefaultNativeZeroThreshold is the width of the zero bucket of native
+// histograms in case none is configured. It is 2^-128, like the default of the
+// prometheus client library.
+const DefaultNativeZeroThreshold = 2.938735877055719e-39
+
+const (
+	// maxNativeSchema is the highest schema of native histograms, having the
+	// narrowest buckets.
+	maxNativeSchema = 8
+	// minNativeSchema is the lowest schema of native histograms, having the
+	// widest buckets.
+	minNativeSchema = -4
+)
+
+// nativeSchema returns the highest schema of native histograms whose buckets
+// grow at most by the given factor, which must be greater than 1. The schema is
+// chosen the same way the prometheus client library chooses it.
+func nativeSchema(factor float64) int32 {
+	floor := math.Floor(math.Log2(math.Log2(factor)))
+
+	switch {
+	case floor <= -maxNativeSchema:
+		return maxNativeSchema
+	case floor >= -minNativeSchema:
+		return minNativeSchema
+	default:
+		return -int32(floor)
+	}
+}
+
+// nativeBucketIndex returns the index of the bucket of a native histogram having
+// the given schema, which counts the given positive and finite sample.
+func nativeBucketIndex(sample float64, schema int32) int {
+	// The sample is frac * 2^exp with frac being within [0.5, 1).
+	frac, exp := math.Frexp(sample)
+
+	if schema > 0 {
+		// There are 2^schema buckets between subsequent powers of 2. Within
+		// [0.5, 1) their lower bounds are 2^(j/2^schema-1).
+		n := 1 << uint(schema)
+		j := sort.Search(n, func(j int) bool {
+			return math.Exp2(float64(j)/float64(n)-1) >= frac
+		})
+
+		return j + (exp-1)*n
+	}
+
+	// Buckets of schemas up to 0 span 2^-schema powers of 2. The bucket of an
+	// exact power of 2 is the one of the next lower exponent, since upper bounds
+	// are inclusive.
+	i := exp
+	if frac == 0.5 {
+		i--
+	}
+	offset := (1 << uint(-schema)) - 1
+
+	return (i + offset) >> uint(-schema)
+}
+
+// observeNative counts the given sample in the buckets of the native histogram
+// of the series. Samples being NaN or infinite are not counted in any bucket.
+// In case a new bucket causes the number of buckets to exceed the given
+// maximum, the width of all buckets is doubled, like the prometheus client
+// library does.
+func (s *Series) observeNative(sample float64, zeroThreshold float64, maxBucketNumber uint32) {
+	if math.IsNaN(sample) || math.IsInf(sample, 0) {
+		return
+	}
+
+	if math.Abs(sample) <= zeroThreshold {
+		s.NativeZeroCount++
+		return
+	}
+
+	buckets := s.NativePositiveBuckets
+	if sample < 0 {
+		buckets = s.NativeNegativeBuckets
+	}
+	i := nativeBucketIndex(math.Abs(sample), s.NativeSchema)
+	_, ok := buckets[i]
+	buckets[i]++
+
+	// The number of buckets is only checked in case a new bucket has been
+	// created.
+	if ok || maxBucketNumber == 0 || len(s.NativePositiveBuckets)+len(s.NativeNegativeBuckets) <= int(maxBucketNumber) {
+		return
+	}
+	if s.NativeSchema == minNativeSchema {
+		return
+	}
+
+	s.NativeNegativeBuckets = mergeNativeBuckets(s.NativeNegativeBuckets)
+	s.NativePositiveBuckets = mergeNativeBuckets(s.NativePositiveBuckets)
+	s.NativeSchema--
+}
+
+func copyNativeBuckets(buckets map[int]uint64) map[int]uint64 {
+	if buckets == nil {
+		return nil
+	}
+
+	newBuckets := map[int]uint64{}
+	for i, c := range buckets {
+		newBuckets[i] = c
+	}
+
+	return newBuckets
+}
+
+// mergeNativeBuckets merges every two adjacent buckets of a native histogram,
+// which results in the buckets of the next lower schema. The bucket having
+// index i becomes the bucket having index ceil(i/2).
+func mergeNativeBuckets(buckets map[int]uint64) map[int]uint64 {
+	newBuckets := map[int]uint64{}
+	for i, c := range buckets {
+		newBuckets[(i+1)>>1] += c
+	}
+
+	return newBuckets
+}
@@ -0,0 +1,173 @@
+package storage
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestNativeSchema(t *testing.T) {
+	testCases := []struct {
+		Factor float64
+		Want   int32
+	}{
+		{Factor: 1.0001, Want: 8},
+		{Factor: 1.1, Want: 3},
+		{Factor: 1.5, Want: 1},
+		{Factor: 2, Want: 0},
+		{Factor: 4, Want: -1},
+		{Factor: 20, Want: -2},
+		{Factor: 1e10, Want: -4},
+	}
+
+	for _, tc := range testCases {
+		schema := nativeSchema(tc.Factor)
+		if schema != tc.Want {
+			t.Fatalf("factor %v: expected schema %d, got %d", tc.Factor, tc.Want, schema)
+		}
+	}
+}
+
+func TestNativeBucketIndex(t *testing.T) {
+	// The bucket having index i covers (base^(i-1), base^i] with base being
+	// 2^(2^-schema).
+	testCases := []struct {
+		Sample float64
+		Schema int32
+		Want   int
+	}{
+		{Sample: 1, Schema: 0, Want: 0},
+		{Sample: 1.5, Schema: 0, Want: 1},
+		{Sample: 2, Schema: 0, Want: 1},
+		{Sample: 0.3, Schema: 0, Want: -1},
+		{Sample: 1, Schema: -1, Want: 0},
+		{Sample: 2, Schema: -1, Want: 1},
+		{Sample: 4, Schema: -1, Want: 1},
+		{Sample: 5, Schema: -1, Want: 2},
+		{Sample: 1, Schema: 1, Want: 0},
+		{Sample: 1.2, Schema: 1, Want: 1},
+		{Sample: 1.5, Schema: 1, Want: 2},
+		{Sample: 2, Schema: 1, Want: 2},
+		{Sample: 1.1, Schema: 3, Want: 2},
+	}
+
+	for _, tc := range testCases {
+		i := nativeBucketIndex(tc.Sample, tc.Schema)
+		if i != tc.Want {
+			t.Fatalf("sample %v of schema %d: expected index %d, got %d", tc.Sample, tc.Schema, tc.Want, i)
+		}
+	}
+
+	// Every sample must be within the bounds of its bucket.
+	for schema := int32(minNativeSchema); schema <= maxNativeSchema; schema++ {
+		base := math.Exp2(math.Exp2(-float64(schema)))
+		for _, sample := range []float64{1e-9, 0.001, 0.3, 1, 1.7, 2, 3, 100, 12345.678, 1e9} {
+			i := nativeBucketIndex(sample, schema)
+			lower := math.Pow(base, float64(i-1))
+			upper := math.Pow(base, float64(i))
+			if !(sample > lower*(1-1e-12) && sample <= upper*(1+1e-12)) {
+				t.Fatalf("sample %v of schema %d: expected to be within (%v, %v] of bucket %d", sample, schema, lower, upper, i)
+			}
+		}
+	}
+}
+
+func TestMetric_Observe_Native(t *testing.T) {
+	config := DefaultMetricConfig()
+	config.Buckets = []float64{1}
+	config.Kind = KindHistogram
+	config.Name = "size"
+	config.NativeBucketFactor = 2
+	config.NativeZeroThreshold = 0.01
+	m, err := NewMetric(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, sample := range []float64{0, 0.01, -0.005, 1, 1.5, 2, -3, math.NaN(), math.Inf(1)} {
+		err := m.Observe(sample)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	s, err := m.Series()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.NativeSchema != 0 {
+		t.Fatalf("expected schema 0, got %d", s.NativeSchema)
+	}
+	// Samples within the zero threshold, including the threshold itself, are
+	// counted in the zero bucket. NaN and infinite samples are not counted in
+	// any bucket.
+	if s.NativeZeroCount != 3 {
+		t.Fatalf("expected zero count 3, got %d", s.NativeZeroCount)
+	}
+	if want := map[int]uint64{0: 1, 1: 2}; !reflect.DeepEqual(s.NativePositiveBuckets, want) {
+		t.Fatalf("expected positive buckets %v, got %v", want, s.NativePositiveBuckets)
+	}
+	if want := map[int]uint64{2: 1}; !reflect.DeepEqual(s.NativeNegativeBuckets, want) {
+		t.Fatalf("expected negative buckets %v, got %v", want, s.NativeNegativeBuckets)
+	}
+}
+
+func TestMetric_Observe_NativeMaxBucketNumber(t *testing.T) {
+	config := DefaultMetricConfig()
+	config.Buckets = []float64{1}
+	config.Kind = KindHistogram
+	config.Name = "size"
+	config.NativeBucketFactor = 2
+	config.NativeMaxBucketNumber = 2
+	m, err := NewMetric(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, sample := range []float64{1, 2} {
+		err := m.Observe(sample)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	s, err := m.Series()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.NativeSchema != 0 {
+		t.Fatalf("expected schema 0 within the bucket limit, got %d", s.NativeSchema)
+	}
+
+	// The third bucket exceeds the limit, so the width of all buckets is
+	// doubled. The buckets (0.5, 1], (1, 2] and (2, 4] of schema 0 become the
+	// buckets (0.25, 1] and (1, 4] of schema -1.
+	err = m.Observe(4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err = m.Series()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.NativeSchema != -1 {
+		t.Fatalf("expected schema -1 after exceeding the bucket limit, got %d", s.NativeSchema)
+	}
+	if want := map[int]uint64{0: 1, 1: 2}; !reflect.DeepEqual(s.NativePositiveBuckets, want) {
+		t.Fatalf("expected positive buckets %v, got %v", want, s.NativePositiveBuckets)
+	}
+
+	// The schema is not reduced below its minimum.
+	for i := 0; i < 100; i++ {
+		err := m.Observe(math.Pow(10, float64(i%60)-30))
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	s, err = m.Series()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.NativeSchema != minNativeSchema {
+		t.Fatalf("expected schema %d, got %d", minNativeSchema, s.NativeSchema)
+	}
+}
@@ -0,0 +1,229 @@
+package storage
+
+import (
+	"math"
+	"sort"
+	"time"
+)
+
+// quantileBufferSize is the number of samples buffered by a quantile stream
+// before they are merged into the stream's compressed summary.
+const quantileBufferSize = 500
+
+// quantileSample is a compressed sample of a quantile stream. It represents
+// width samples having value as their maximum. delta is the maximum error of
+// the sample's rank.
+type quantileSample struct {
+	delta float64
+	value float64
+	width float64
+}
+
+// quantileStream estimates targeted quantiles of a stream of samples using the
+// algorithm described by Cormode, Korn, Muthukrishnan and Srivastava in
+// "Effective Computation of Biased Quantiles over Data Streams". The memory
+// needed by the stream is bounded by the configured objectives instead of the
+// number of observed samples.
+type quantileStream struct {
+	buffer     []float64
+	n          float64
+	objectives map[float64]float64
+	samples    []quantileSample
+}
+
+func newQuantileStream(objectives map[float64]float64) *quantileStream {
+	return &quantileStream{
+		buffer:     make([]float64, 0, quantileBufferSize),
+		n:          0,
+		objectives: objectives,
+		samples:    nil,
+	}
+}
+
+// insert adds the given sample to the stream.
+func (qs *quantileStream) insert(sample float64) {
+	qs.buffer = append(qs.buffer, sample)
+	if len(qs.buffer) == cap(qs.buffer) {
+		qs.flush()
+	}
+}
+
+// query returns the estimated value of the given quantile. NaN is returned in
+// case the stream is empty.
+func (qs *quantileStream) query(quantile float64) float64 {
+	if len(qs.samples) == 0 {
+		// As long as no samples have been merged into the compressed summary, the
+		// exact quantile of the buffered samples is returned. This is more
+		// accurate for small numbers of samples and matches the prometheus
+		// client library.
+		if len(qs.buffer) == 0 {
+			return math.NaN()
+		}
+		sort.Float64s(qs.buffer)
+		i := int(math.Ceil(float64(len(qs.buffer)) * quantile))
+		if i > 0 {
+			i--
+		}
+		return qs.buffer[i]
+	}
+
+	qs.flush()
+
+	t := math.Ceil(quantile * qs.n)
+	t += math.Ceil(qs.invariant(t) / 2)
+	p := qs.samples[0]
+	var r float64
+	for _, c := range qs.samples[1:] {
+		r += p.width
+		if r+c.width+c.delta > t {
+			return p.value
+		}
+		p = c
+	}
+
+	return p.value
+}
+
+// reset drops all samples of the stream.
+func (qs *quantileStream) reset() {
+	qs.buffer = qs.buffer[:0]
+	qs.n = 0
+	qs.samples = nil
+}
+
+// compress merges adjacent samples as long as the error bounds given by the
+// objectives allow it.
+func (qs *quantileStream) compress() {
+	if len(qs.samples) < 2 {
+		return
+	}
+
+	x := qs.samples[len(qs.samples)-1]
+	xi := len(qs.samples) - 1
+	r := qs.n - 1 - x.width
+
+	for i := len(qs.samples) - 2; i >= 0; i-- {
+		c := qs.samples[i]
+		if c.width+x.width+x.delta <= qs.invariant(r) {
+			x.width += c.width
+			qs.samples[xi] = x
+			qs.samples = append(qs.samples[:i], qs.samples[i+1:]...)
+			xi--
+		} else {
+			x = c
+			xi = i
+		}
+		r -= c.width
+	}
+}
+
+// flush merges all buffered samples into the compressed summary.
+func (qs *quantileStream) flush() {
+	if len(qs.buffer) == 0 {
+		return
+	}
+
+	sort.Float64s(qs.buffer)
+
+	var r float64
+	i := 0
+	for _, v := range qs.buffer {
+		inserted := false
+		for ; i < len(qs.samples); i++ {
+			c := qs.samples[i]
+			if c.value > v {
+				qs.samples = append(qs.samples, quantileSample{})
+				copy(qs.samples[i+1:], qs.samples[i:])
+				qs.samples[i] = quantileSample{
+					delta: math.Max(0, math.Floor(qs.invariant(r))-1),
+					value: v,
+					width: 1,
+				}
+				i++
+				inserted = true
+				break
+			}
+			r += c.width
+		}
+		if !inserted {
+			qs.samples = append(qs.samples, quantileSample{delta: 0, value: v, width: 1})
+			i++
+		}
+		qs.n++
+		r++
+	}
+	qs.buffer = qs.buffer[:0]
+
+	qs.compress()
+}
+
+// invariant returns the maximum error allowed for the given rank according to
+// the configured objectives.
+func (qs *quantileStream) invariant(r float64) float64 {
+	m := math.MaxFloat64
+	for quantile, epsilon := range qs.objectives {
+		var f float64
+		if r >= quantile*qs.n {
+			f = 2 * epsilon * r / quantile
+		} else {
+			f = 2 * epsilon * (qs.n - r) / (1 - quantile)
+		}
+		if f < m {
+			m = f
+		}
+	}
+
+	return m
+}
+
+// ageingQuantileStreams holds a ring of quantile streams in order to estimate
+// quantiles over a sliding time window of maxAge. Each sample is inserted into
+// all streams. Every maxAge/ageBuckets the oldest stream is reset and the next
+// one becomes the stream being queried.
+type ageingQuantileStreams struct {
+	headExpiration time.Time
+	headIndex      int
+	streamDuration time.Duration
+	streams        []*quantileStream
+}
+
+func newAgeingQuantileStreams(objectives map[float64]float64, maxAge time.Duration, ageBuckets uint32) *ageingQuantileStreams {
+	var streams []*quantileStream
+	for i := uint32(0); i < ageBuckets; i++ {
+		streams = append(streams, newQuantileStream(objectives))
+	}
+
+	streamDuration := maxAge / time.Duration(ageBuckets)
+
+	return &ageingQuantileStreams{
+		headExpiration: time.Now().Add(streamDuration),
+		headIndex:      0,
+		streamDuration: streamDuration,
+		streams:        streams,
+	}
+}
+
+func (as *ageingQuantileStreams) insert(sample float64) {
+	as.rotate()
+	for _, s := range as.streams {
+		s.insert(sample)
+	}
+}
+
+func (as *ageingQuantileStreams) query(quantile float64) float64 {
+	as.rotate()
+	return as.streams[as.headIndex].query(quantile)
+}
+
+// rotate resets all streams having expired since the last rotation.
+func (as *ageingQuantileStreams) rotate() {
+	now := time.Now()
+	for !now.Before(as.headExpiration) {
+		as.streams[as.headIndex].reset()
+		as.headIndex++
+		if as.headIndex >= len(as.streams) {
+			as.headIndex = 0
+		}
+		as.headExpiration = as.headExpiration.Add(as.streamDuration)
+	}
+}
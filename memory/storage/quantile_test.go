@@ -0,0 +1,150 @@
+package storage
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+	"testing"
+	"time"
+)
+
+func TestQuantileStream(t *testing.T) {
+	objectives := map[float64]float64{0.5: 0.05, 0.9: 0.01, 0.99: 0.001}
+
+	testCases := []struct {
+		Name    string
+		Samples func(r *rand.Rand, n int) []float64
+	}{
+		{
+			Name: "ascending",
+			Samples: func(r *rand.Rand, n int) []float64 {
+				var samples []float64
+				for i := 0; i < n; i++ {
+					samples = append(samples, float64(i))
+				}
+				return samples
+			},
+		},
+		{
+			Name: "shuffled",
+			Samples: func(r *rand.Rand, n int) []float64 {
+				var samples []float64
+				for _, i := range r.Perm(n) {
+					samples = append(samples, float64(i))
+				}
+				return samples
+			},
+		},
+		{
+			Name: "uniform",
+			Samples: func(r *rand.Rand, n int) []float64 {
+				var samples []float64
+				for i := 0; i < n; i++ {
+					samples = append(samples, r.Float64())
+				}
+				return samples
+			},
+		},
+		{
+			Name: "exponential",
+			Samples: func(r *rand.Rand, n int) []float64 {
+				var samples []float64
+				for i := 0; i < n; i++ {
+					samples = append(samples, r.ExpFloat64())
+				}
+				return samples
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		// The number of samples exceeds the buffer size several times, so the
+		// buffered samples are merged into the compressed summary repeatedly.
+		n := 10 * quantileBufferSize
+		samples := tc.Samples(rand.New(rand.NewSource(42)), n)
+
+		qs := newQuantileStream(objectives)
+		for _, s := range samples {
+			qs.insert(s)
+		}
+
+		sorted := append([]float64(nil), samples...)
+		sort.Float64s(sorted)
+
+		for quantile, epsilon := range objectives {
+			// The estimated value must have a rank within the allowed error of the
+			// rank of the quantile.
+			v := qs.query(quantile)
+			lower := sort.SearchFloat64s(sorted, v)
+			upper := lower
+			for upper < len(sorted) && sorted[upper] == v {
+				upper++
+			}
+			want := quantile * float64(n)
+			if float64(upper) < want-epsilon*float64(n) || float64(lower) > want+epsilon*float64(n) {
+				t.Fatalf("%s: quantile %v: expected rank within %v±%v, got [%d, %d]", tc.Name, quantile, want, epsilon*float64(n), lower, upper)
+			}
+		}
+
+		// The memory needed by the stream is bounded by the objectives, not by
+		// the number of samples.
+		if len(qs.samples) >= n/4 {
+			t.Fatalf("%s: expected compressed samples, got %d of %d", tc.Name, len(qs.samples), n)
+		}
+	}
+}
+
+func TestQuantileStream_Buffered(t *testing.T) {
+	qs := newQuantileStream(map[float64]float64{0.5: 0.05})
+	if v := qs.query(0.5); !math.IsNaN(v) {
+		t.Fatalf("expected NaN for empty stream, got %v", v)
+	}
+
+	// Quantiles of samples not yet merged into the compressed summary are
+	// exact.
+	for _, sample := range []float64{3, 0.5, 1.5} {
+		qs.insert(sample)
+	}
+	if v := qs.query(0.5); v != 1.5 {
+		t.Fatalf("expected %v, got %v", 1.5, v)
+	}
+
+	qs.reset()
+	if v := qs.query(0.5); !math.IsNaN(v) {
+		t.Fatalf("expected NaN for reset stream, got %v", v)
+	}
+}
+
+func TestAgeingQuantileStreams(t *testing.T) {
+	as := newAgeingQuantileStreams(map[float64]float64{0.5: 0.05}, time.Hour, 2)
+
+	for i := 0; i < 100; i++ {
+		as.insert(1)
+	}
+
+	// Expiring the head stream makes the next stream the head, which observed
+	// the same samples.
+	as.headExpiration = time.Now().Add(-time.Second)
+	if v := as.query(0.5); v != 1 {
+		t.Fatalf("expected %v after the first rotation, got %v", 1, v)
+	}
+	if as.headIndex != 1 {
+		t.Fatalf("expected head index %d, got %d", 1, as.headIndex)
+	}
+
+	// The samples inserted after the first rotation are the only ones left in
+	// the stream becoming the head next.
+	for i := 0; i < 100; i++ {
+		as.insert(2)
+	}
+	as.headExpiration = time.Now().Add(-time.Second)
+	if v := as.query(0.5); v != 2 {
+		t.Fatalf("expected %v after the second rotation, got %v", 2, v)
+	}
+
+	// Expiring all streams at once drops all samples.
+	as.headExpiration = time.Now().Add(-2 * as.streamDuration)
+	if v := as.query(0.5); !math.IsNaN(v) {
+		t.Fatalf("expected NaN after all streams expired, got %v", v)
+	}
+}
@@ -0,0 +1,97 @@
+// Package storage implements a concurrency safe in-memory store of metrics. It
+// is shared between the memory publisher, which records metric values, and the
+// memory consumer, which reads them back.
+package storage
+
+import (
+	"sort"
+	"sync"
+)
+
+// StorageConfig represents the configuration used to create a new memory
+// storage.
+type StorageConfig struct {
+}
+
+// DefaultStorageConfig provides a default configuration to create a new memory
+// storage by best effort.
+func DefaultStorageConfig() StorageConfig {
+	return StorageConfig{}
+}
+
+// NewStorage creates a new configured memory storage.
+func NewStorage(config StorageConfig) (*Storage, error) {
+	newStorage := &Storage{
+		// Internals.
+		metrics: map[string]*Metric{},
+		mutex:   sync.RWMutex{},
+	}
+
+	return newStorage, nil
+}
+
+// Storage holds metrics keyed by their names.
+type Storage struct {
+	// Internals.
+	metrics map[string]*Metric
+	mutex   sync.RWMutex
+}
+
+// Metric returns the metric registered under the given name.
+func (s *Storage) Metric(name string) (*Metric, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	m, ok := s.metrics[name]
+	if !ok {
+		return nil, maskAnyf(notFoundError, "metric %s", name)
+	}
+
+	return m, nil
+}
+
+// Metrics returns all registered metrics ordered by their names.
+func (s *Storage) Metrics() []*Metric {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	var list []*Metric
+	for _, n := range s.names() {
+		list = append(list, s.metrics[n])
+	}
+
+	return list
+}
+
+// Names returns the ordered names of all registered metrics.
+func (s *Storage) Names() []string {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	return s.names()
+}
+
+// Register adds the given metric to the storage. Metric names are unique, so
+// registering a metric under a name already being used fails.
+func (s *Storage) Register(metric *Metric) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if _, ok := s.metrics[metric.Name()]; ok {
+		return maskAnyf(alreadyRegisteredError, "metric %s", metric.Name())
+	}
+	s.metrics[metric.Name()] = metric
+
+	return nil
+}
+
+// names must only be called while holding the lock.
+func (s *Storage) names() []string {
+	var names []string
+	for n := range s.metrics {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+
+	return names
+}
@@ -0,0 +1,256 @@
+package storage
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestStorage_Register(t *testing.T) {
+	s, err := NewStorage(DefaultStorageConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, name := range []string{"requests_total", "connections"} {
+		config := DefaultMetricConfig()
+		config.Kind = KindCounter
+		config.Name = name
+		m, err := NewMetric(config)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = s.Register(m)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	config := DefaultMetricConfig()
+	config.Kind = KindGauge
+	config.Name = "connections"
+	m, err := NewMetric(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = s.Register(m)
+	if !IsAlreadyRegistered(err) {
+		t.Fatalf("expected already registered error, got %v", err)
+	}
+
+	if want := []string{"connections", "requests_total"}; !reflect.DeepEqual(s.Names(), want) {
+		t.Fatalf("expected names %v, got %v", want, s.Names())
+	}
+	if l := s.Metrics(); len(l) != 2 || l[0].Kind() != KindCounter || l[0].Name() != "connections" {
+		t.Fatalf("expected metrics ordered by name, got %v", l)
+	}
+
+	_, err = s.Metric("unknown")
+	if !IsNotFound(err) {
+		t.Fatalf("expected not found error, got %v", err)
+	}
+}
+
+func TestMetric_Series(t *testing.T) {
+	config := DefaultMetricConfig()
+	config.Kind = KindGauge
+	config.Labels = []string{"method", "code"}
+	config.Name = "connections"
+	m, err := NewMetric(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		Name   string
+		Record func() error
+		Values []string
+		Value  float64
+	}{
+		{
+			Name:   "set",
+			Record: func() error { return m.Set(3, "GET", "200") },
+			Values: []string{"GET", "200"},
+			Value:  3,
+		},
+		{
+			Name:   "add",
+			Record: func() error { return m.Add(-5, "GET", "200") },
+			Values: []string{"GET", "200"},
+			Value:  -2,
+		},
+		{
+			Name:   "other series",
+			Record: func() error { return m.Add(1, "POST", "500") },
+			Values: []string{"POST", "500"},
+			Value:  1,
+		},
+		{
+			// Label values are joined by a separator not expected in label values,
+			// so they cannot collide by being split differently.
+			Name:   "ambiguous join",
+			Record: func() error { return m.Add(7, "GE", "T200") },
+			Values: []string{"GE", "T200"},
+			Value:  7,
+		},
+	}
+
+	for _, tc := range testCases {
+		err := tc.Record()
+		if err != nil {
+			t.Fatalf("%s: %v", tc.Name, err)
+		}
+		s, err := m.Series(tc.Values...)
+		if err != nil {
+			t.Fatalf("%s: %v", tc.Name, err)
+		}
+		if s.Value != tc.Value {
+			t.Fatalf("%s: expected value %v, got %v", tc.Name, tc.Value, s.Value)
+		}
+		if !reflect.DeepEqual(s.LabelValues, tc.Values) {
+			t.Fatalf("%s: expected label values %v, got %v", tc.Name, tc.Values, s.LabelValues)
+		}
+	}
+
+	err = m.Set(1, "GET")
+	if !IsInvalidConfig(err) {
+		t.Fatalf("expected invalid config error for missing label values, got %v", err)
+	}
+	_, err = m.Series("GET")
+	if !IsNotFound(err) {
+		t.Fatalf("expected not found error for missing label values, got %v", err)
+	}
+	err = m.Observe(1, "GET", "200")
+	if !IsInvalidConfig(err) {
+		t.Fatalf("expected invalid config error for observing a gauge, got %v", err)
+	}
+
+	var got [][]string
+	for _, s := range m.SeriesList() {
+		got = append(got, s.LabelValues)
+	}
+	if want := [][]string{{"GE", "T200"}, {"GET", "200"}, {"POST", "500"}}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected series %v, got %v", want, got)
+	}
+
+	err = m.Delete("GET", "200")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = m.Series("GET", "200")
+	if !IsNotFound(err) {
+		t.Fatalf("expected not found error for deleted series, got %v", err)
+	}
+
+	m.Reset()
+	if l := m.SeriesList(); len(l) != 0 {
+		t.Fatalf("expected no series after reset, got %v", l)
+	}
+}
+
+func TestMetric_Series_Copy(t *testing.T) {
+	config := DefaultMetricConfig()
+	config.Buckets = []float64{1, 2}
+	config.Kind = KindHistogram
+	config.Name = "duration"
+	m, err := NewMetric(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = m.Observe(0.5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := m.Series()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Series are returned as copies, so modifying them or observing further
+	// samples does not affect each other.
+	s.BucketCounts[0] = 42
+	err = m.Observe(1.5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []uint64{42, 1}; !reflect.DeepEqual(s.BucketCounts, want) {
+		t.Fatalf("expected bucket counts %v, got %v", want, s.BucketCounts)
+	}
+
+	s, err = m.Series()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []uint64{1, 2}; !reflect.DeepEqual(s.BucketCounts, want) {
+		t.Fatalf("expected bucket counts %v, got %v", want, s.BucketCounts)
+	}
+	if s.Count != 2 || s.Sum != 2 {
+		t.Fatalf("expected count 2 and sum 2, got count %d and sum %v", s.Count, s.Sum)
+	}
+}
+
+func TestNewMetric(t *testing.T) {
+	testCases := []struct {
+		Name   string
+		Config func(c MetricConfig) MetricConfig
+		Valid  bool
+	}{
+		{
+			Name:   "counter",
+			Config: func(c MetricConfig) MetricConfig { c.Kind = KindCounter; return c },
+			Valid:  true,
+		},
+		{
+			Name:   "unknown kind",
+			Config: func(c MetricConfig) MetricConfig { c.Kind = "meter"; return c },
+			Valid:  false,
+		},
+		{
+			Name:   "histogram without buckets",
+			Config: func(c MetricConfig) MetricConfig { c.Kind = KindHistogram; return c },
+			Valid:  false,
+		},
+		{
+			Name:   "summary without age buckets",
+			Config: func(c MetricConfig) MetricConfig { c.Kind = KindSummary; c.AgeBuckets = 0; return c },
+			Valid:  false,
+		},
+		{
+			Name:   "empty name",
+			Config: func(c MetricConfig) MetricConfig { c.Kind = KindCounter; c.Name = ""; return c },
+			Valid:  false,
+		},
+		{
+			Name: "label used as const label",
+			Config: func(c MetricConfig) MetricConfig {
+				c.ConstLabels = map[string]string{"method": "GET"}
+				c.Kind = KindCounter
+				c.Labels = []string{"method"}
+				return c
+			},
+			Valid: false,
+		},
+		{
+			Name: "native bucket factor of 1",
+			Config: func(c MetricConfig) MetricConfig {
+				c.Kind = KindHistogram
+				c.Buckets = []float64{1}
+				c.NativeBucketFactor = 1
+				return c
+			},
+			Valid: false,
+		},
+	}
+
+	for _, tc := range testCases {
+		config := DefaultMetricConfig()
+		config.Name = "metric"
+		_, err := NewMetric(tc.Config(config))
+		if tc.Valid && err != nil {
+			t.Fatalf("%s: expected no error, got %v", tc.Name, err)
+		}
+		if !tc.Valid && !IsInvalidConfig(err) {
+			t.Fatalf("%s: expected invalid config error, got %v", tc.Name, err)
+		}
+	}
+}
@@ -0,0 +1,210 @@
+package publisher
+
+import (
+	"time"
+
+	"github.com/the-anna-project/instrumentor/internal/metric"
+	"github.com/the-anna-project/instrumentor/spec"
+)
+
+// CounterConfig represents the configuration used to create a new multi
+// publisher counter object.
+type CounterConfig struct {
+	// Settings.
+	help   string
+	labels []string
+	name   string
+	ttl    time.Duration
+}
+
+func (cc *CounterConfig) Help() string {
+	return cc.help
+}
+
+func (cc *CounterConfig) Labels() []string {
+	return cc.labels
+}
+
+func (cc *CounterConfig) Name() string {
+	return cc.name
+}
+
+func (cc *CounterConfig) SetHelp(help string) {
+	cc.help = help
+}
+
+func (cc *CounterConfig) SetLabels(labels []string) {
+	cc.labels = labels
+}
+
+func (cc *CounterConfig) SetName(name string) {
+	cc.name = name
+}
+
+func (cc *CounterConfig) SetTTL(ttl time.Duration) {
+	cc.ttl = ttl
+}
+
+// TTL returns the duration after which series not being written expire. It is
+// forwarded to the metric configs of the prometheus publisher, which is the
+// only publisher supporting it. The other publishers ignore it. In case it is
+// 0, which is the default, the TTL configured by the prometheus publisher is
+// used.
+func (cc *CounterConfig) TTL() time.Duration {
+	return cc.ttl
+}
+
+// DefaultCounterConfig provides a default configuration to create a new multi
+// publisher counter object by best effort.
+func DefaultCounterConfig() *CounterConfig {
+	return &CounterConfig{
+		// Settings.
+		help:   "",
+		labels: nil,
+		name:   "",
+		ttl:    0,
+	}
+}
+
+// NewCounter creates a new configured multi publisher counter object. The
+// counter does not forward to any counter until Counters is set. The config is
+// validated against the requirements of all publishers, e.g. its name and label
+// names must be valid, so that an invalid config is rejected before the counter
+// is created by any publisher.
+func NewCounter(config spec.CounterConfig) (*Counter, error) {
+	// Settings.
+	if config.Name() == "" {
+		return nil, maskAnyf(invalidConfigError, "name must not be empty")
+	}
+	err := metric.ValidateName(config.Name())
+	if err != nil {
+		return nil, maskAny(err)
+	}
+	err = metric.ValidateLabels(config.Labels(), nil)
+	if err != nil {
+		return nil, maskAny(err)
+	}
+
+	newCounter := &Counter{
+		Counters: nil,
+	}
+
+	return newCounter, nil
+}
+
+// Counter forwards every call to all of its counters, which are usually
+// created by different publishers. Errors of all counters are joined.
+type Counter struct {
+	// Public.
+	Counters []spec.Counter
+}
+
+func (c *Counter) DeleteLabelValues(values ...string) error {
+	var errs []error
+	for _, m := range c.Counters {
+		err := m.DeleteLabelValues(values...)
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	err := metric.JoinErrors(errs)
+	if err != nil {
+		return maskAny(err)
+	}
+
+	return nil
+}
+
+func (c *Counter) Increment(delta float64) error {
+	var errs []error
+	for _, m := range c.Counters {
+		err := m.Increment(delta)
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	err := metric.JoinErrors(errs)
+	if err != nil {
+		return maskAny(err)
+	}
+
+	return nil
+}
+
+func (c *Counter) IncrementWithLabelMap(delta float64, labels map[string]string) error {
+	var errs []error
+	for _, m := range c.Counters {
+		err := m.IncrementWithLabelMap(delta, labels)
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	err := metric.JoinErrors(errs)
+	if err != nil {
+		return maskAny(err)
+	}
+
+	return nil
+}
+
+func (c *Counter) IncrementWithLabels(delta float64, values ...string) error {
+	var errs []error
+	for _, m := range c.Counters {
+		err := m.IncrementWithLabels(delta, values...)
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	err := metric.JoinErrors(errs)
+	if err != nil {
+		return maskAny(err)
+	}
+
+	return nil
+}
+
+func (c *Counter) Reset() error {
+	var errs []error
+	for _, m := range c.Counters {
+		err := m.Reset()
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	err := metric.JoinErrors(errs)
+	if err != nil {
+		return maskAny(err)
+	}
+
+	return nil
+}
+
+// With returns a counter forwarding to the counters returned by With of all
+// counters.
+func (c *Counter) With(values ...string) (spec.Counter, error) {
+	newCounter := &Counter{
+		Counters: nil,
+	}
+
+	var errs []error
+	for _, m := range c.Counters {
+		bound, err := m.With(values...)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		newCounter.Counters = append(newCounter.Counters, bound)
+	}
+
+	err := metric.JoinErrors(errs)
+	if err != nil {
+		return nil, maskAny(err)
+	}
+
+	return newCounter, nil
+}
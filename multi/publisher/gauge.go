@@ -0,0 +1,312 @@
+package publisher
+
+import (
+	"time"
+
+	"github.com/the-anna-project/instrumentor/internal/metric"
+	"github.com/the-anna-project/instrumentor/spec"
+)
+
+// GaugeConfig represents the configuration used to create a new multi
+// publisher gauge.
+type GaugeConfig struct {
+	// Settings.
+	help   string
+	labels []string
+	name   string
+	ttl    time.Duration
+}
+
+func (gc *GaugeConfig) Help() string {
+	return gc.help
+}
+
+func (gc *GaugeConfig) Labels() []string {
+	return gc.labels
+}
+
+func (gc *GaugeConfig) Name() string {
+	return gc.name
+}
+
+func (gc *GaugeConfig) SetHelp(help string) {
+	gc.help = help
+}
+
+func (gc *GaugeConfig) SetLabels(labels []string) {
+	gc.labels = labels
+}
+
+func (gc *GaugeConfig) SetName(name string) {
+	gc.name = name
+}
+
+func (gc *GaugeConfig) SetTTL(ttl time.Duration) {
+	gc.ttl = ttl
+}
+
+// TTL returns the duration after which series not being written expire. It is
+// forwarded to the metric configs of the prometheus publisher, which is the
+// only publisher supporting it. The other publishers ignore it. In case it is
+// 0, which is the default, the TTL configured by the prometheus publisher is
+// used.
+func (gc *GaugeConfig) TTL() time.Duration {
+	return gc.ttl
+}
+
+// DefaultGaugeConfig provides a default configuration to create a new multi
+// publisher gauge by best effort.
+func DefaultGaugeConfig() *GaugeConfig {
+	return &GaugeConfig{
+		// Settings.
+		help:   "",
+		labels: nil,
+		name:   "",
+		ttl:    0,
+	}
+}
+
+// NewGauge creates a new configured multi publisher gauge. The gauge does not
+// forward to any gauge until Gauges is set. The config is validated against the
+// requirements of all publishers, e.g. its name and label names must be valid,
+// so that an invalid config is rejected before the gauge is created by any
+// publisher.
+func NewGauge(config spec.GaugeConfig) (*Gauge, error) {
+	// Settings.
+	if config.Name() == "" {
+		return nil, maskAnyf(invalidConfigError, "name must not be empty")
+	}
+	err := metric.ValidateName(config.Name())
+	if err != nil {
+		return nil, maskAny(err)
+	}
+	err = metric.ValidateLabels(config.Labels(), nil)
+	if err != nil {
+		return nil, maskAny(err)
+	}
+
+	newGauge := &Gauge{
+		Gauges: nil,
+	}
+
+	return newGauge, nil
+}
+
+// Gauge forwards every call to all of its gauges, which are usually
+// created by different publishers. Errors of all gauges are joined.
+type Gauge struct {
+	// Public.
+	Gauges []spec.Gauge
+}
+
+func (g *Gauge) Decrement(delta float64) error {
+	var errs []error
+	for _, m := range g.Gauges {
+		err := m.Decrement(delta)
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	err := metric.JoinErrors(errs)
+	if err != nil {
+		return maskAny(err)
+	}
+
+	return nil
+}
+
+func (g *Gauge) DecrementWithLabelMap(delta float64, labels map[string]string) error {
+	var errs []error
+	for _, m := range g.Gauges {
+		err := m.DecrementWithLabelMap(delta, labels)
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	err := metric.JoinErrors(errs)
+	if err != nil {
+		return maskAny(err)
+	}
+
+	return nil
+}
+
+func (g *Gauge) DecrementWithLabels(delta float64, values ...string) error {
+	var errs []error
+	for _, m := range g.Gauges {
+		err := m.DecrementWithLabels(delta, values...)
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	err := metric.JoinErrors(errs)
+	if err != nil {
+		return maskAny(err)
+	}
+
+	return nil
+}
+
+func (g *Gauge) DeleteLabelValues(values ...string) error {
+	var errs []error
+	for _, m := range g.Gauges {
+		err := m.DeleteLabelValues(values...)
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	err := metric.JoinErrors(errs)
+	if err != nil {
+		return maskAny(err)
+	}
+
+	return nil
+}
+
+func (g *Gauge) Increment(delta float64) error {
+	var errs []error
+	for _, m := range g.Gauges {
+		err := m.Increment(delta)
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	err := metric.JoinErrors(errs)
+	if err != nil {
+		return maskAny(err)
+	}
+
+	return nil
+}
+
+func (g *Gauge) IncrementWithLabelMap(delta float64, labels map[string]string) error {
+	var errs []error
+	for _, m := range g.Gauges {
+		err := m.IncrementWithLabelMap(delta, labels)
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	err := metric.JoinErrors(errs)
+	if err != nil {
+		return maskAny(err)
+	}
+
+	return nil
+}
+
+func (g *Gauge) IncrementWithLabels(delta float64, values ...string) error {
+	var errs []error
+	for _, m := range g.Gauges {
+		err := m.IncrementWithLabels(delta, values...)
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	err := metric.JoinErrors(errs)
+	if err != nil {
+		return maskAny(err)
+	}
+
+	return nil
+}
+
+func (g *Gauge) Set(value float64) error {
+	var errs []error
+	for _, m := range g.Gauges {
+		err := m.Set(value)
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	err := metric.JoinErrors(errs)
+	if err != nil {
+		return maskAny(err)
+	}
+
+	return nil
+}
+
+func (g *Gauge) SetWithLabelMap(value float64, labels map[string]string) error {
+	var errs []error
+	for _, m := range g.Gauges {
+		err := m.SetWithLabelMap(value, labels)
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	err := metric.JoinErrors(errs)
+	if err != nil {
+		return maskAny(err)
+	}
+
+	return nil
+}
+
+func (g *Gauge) SetWithLabels(value float64, values ...string) error {
+	var errs []error
+	for _, m := range g.Gauges {
+		err := m.SetWithLabels(value, values...)
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	err := metric.JoinErrors(errs)
+	if err != nil {
+		return maskAny(err)
+	}
+
+	return nil
+}
+
+func (g *Gauge) Reset() error {
+	var errs []error
+	for _, m := range g.Gauges {
+		err := m.Reset()
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	err := metric.JoinErrors(errs)
+	if err != nil {
+		return maskAny(err)
+	}
+
+	return nil
+}
+
+// With returns a gauge forwarding to the gauges returned by With of all
+// gauges.
+func (g *Gauge) With(values ...string) (spec.Gauge, error) {
+	newGauge := &Gauge{
+		Gauges: nil,
+	}
+
+	var errs []error
+	for _, m := range g.Gauges {
+		bound, err := m.With(values...)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		newGauge.Gauges = append(newGauge.Gauges, bound)
+	}
+
+	err := metric.JoinErrors(errs)
+	if err != nil {
+		return nil, maskAny(err)
+	}
+
+	return newGauge, nil
+}
@@ -0,0 +1,308 @@
+package publisher
+
+import (
+	"time"
+
+	"github.com/the-anna-project/instrumentor/bucket"
+	"github.com/the-anna-project/instrumentor/internal/metric"
+	"github.com/the-anna-project/instrumentor/spec"
+)
+
+// HistogramConfig represents the configuration used to create a new multi
+// publisher histogram.
+type HistogramConfig struct {
+	// Settings.
+	buckets               []float64
+	help                  string
+	labels                []string
+	name                  string
+	nativeBucketFactor    float64
+	nativeMaxBucketNumber uint32
+	nativeZeroThreshold   float64
+	ttl                   time.Duration
+	unit                  string
+}
+
+func (hc *HistogramConfig) Buckets() []float64 {
+	return hc.buckets
+}
+
+func (hc *HistogramConfig) Help() string {
+	return hc.help
+}
+
+func (hc *HistogramConfig) Labels() []string {
+	return hc.labels
+}
+
+func (hc *HistogramConfig) Name() string {
+	return hc.name
+}
+
+func (hc *HistogramConfig) NativeBucketFactor() float64 {
+	return hc.nativeBucketFactor
+}
+
+func (hc *HistogramConfig) NativeMaxBucketNumber() uint32 {
+	return hc.nativeMaxBucketNumber
+}
+
+func (hc *HistogramConfig) NativeZeroThreshold() float64 {
+	return hc.nativeZeroThreshold
+}
+
+func (hc *HistogramConfig) SetBuckets(buckets []float64) {
+	hc.buckets = buckets
+}
+
+func (hc *HistogramConfig) SetHelp(help string) {
+	hc.help = help
+}
+
+func (hc *HistogramConfig) SetLabels(labels []string) {
+	hc.labels = labels
+}
+
+func (hc *HistogramConfig) SetName(name string) {
+	hc.name = name
+}
+
+func (hc *HistogramConfig) SetNativeBucketFactor(factor float64) {
+	hc.nativeBucketFactor = factor
+}
+
+func (hc *HistogramConfig) SetNativeMaxBucketNumber(number uint32) {
+	hc.nativeMaxBucketNumber = number
+}
+
+func (hc *HistogramConfig) SetNativeZeroThreshold(threshold float64) {
+	hc.nativeZeroThreshold = threshold
+}
+
+func (hc *HistogramConfig) SetUnit(unit string) {
+	hc.unit = unit
+}
+
+func (hc *HistogramConfig) SetTTL(ttl time.Duration) {
+	hc.ttl = ttl
+}
+
+// TTL returns the duration after which series not being written expire. It is
+// forwarded to the metric configs of the prometheus publisher, which is the
+// only publisher supporting it. The other publishers ignore it. In case it is
+// 0, which is the default, the TTL configured by the prometheus publisher is
+// used.
+func (hc *HistogramConfig) TTL() time.Duration {
+	return hc.ttl
+}
+
+func (hc *HistogramConfig) Unit() string {
+	return hc.unit
+}
+
+// DefaultHistogramConfig provides a default configuration to create a new
+// multi publisher histogram by best effort.
+func DefaultHistogramConfig() *HistogramConfig {
+	return &HistogramConfig{
+		// Settings.
+		buckets:               []float64{.001, .002, .003, .004, .005, .01, .02, .03, .04, .05, .1, .2, .3, .4, .5, 1, 2, 3, 4, 5, 10},
+		help:                  "",
+		labels:                nil,
+		name:                  "",
+		nativeBucketFactor:    0,
+		nativeMaxBucketNumber: 0,
+		nativeZeroThreshold:   0,
+		ttl:                   0,
+		unit:                  "",
+	}
+}
+
+// NewHistogram creates a new configured multi publisher histogram. The
+// histogram does not forward to any histogram until Histograms is set. The
+// config is validated against the requirements of all publishers, e.g. its name
+// and label names must be valid, so that an invalid config is rejected before
+// the histogram is created by any publisher.
+func NewHistogram(config spec.HistogramConfig) (*Histogram, error) {
+	// Settings.
+	if config.Buckets() == nil {
+		return nil, maskAnyf(invalidConfigError, "buckets must not be empty")
+	}
+	if len(config.Buckets()) < 1 {
+		return nil, maskAnyf(invalidConfigError, "buckets must contain at least 1 value")
+	}
+	err := bucket.Validate(config.Buckets())
+	if err != nil {
+		return nil, maskAny(err)
+	}
+	if config.NativeBucketFactor() != 0 && !(config.NativeBucketFactor() > 1) {
+		return nil, maskAnyf(invalidConfigError, "native bucket factor must be 0 or greater than 1")
+	}
+	if !(config.NativeZeroThreshold() >= 0) {
+		return nil, maskAnyf(invalidConfigError, "native zero threshold must not be negative")
+	}
+	if config.Name() == "" {
+		return nil, maskAnyf(invalidConfigError, "name must not be empty")
+	}
+	err = metric.ValidateName(config.Name())
+	if err != nil {
+		return nil, maskAny(err)
+	}
+	err = metric.ValidateLabels(config.Labels(), nil, metric.BucketLabel)
+	if err != nil {
+		return nil, maskAny(err)
+	}
+	if !metric.ValidUnit(config.Unit()) {
+		return nil, maskAnyf(invalidConfigError, "unit must be one of: %s, %s", spec.UnitMilliseconds, spec.UnitSeconds)
+	}
+
+	newHistogram := &Histogram{
+		Histograms: nil,
+	}
+
+	return newHistogram, nil
+}
+
+// Histogram forwards every call to all of its histograms, which are usually
+// created by different publishers. Errors of all histograms are joined.
+type Histogram struct {
+	// Public.
+	Histograms []spec.Histogram
+}
+
+func (h *Histogram) DeleteLabelValues(values ...string) error {
+	var errs []error
+	for _, m := range h.Histograms {
+		err := m.DeleteLabelValues(values...)
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	err := metric.JoinErrors(errs)
+	if err != nil {
+		return maskAny(err)
+	}
+
+	return nil
+}
+
+func (h *Histogram) Observe(sample float64) error {
+	var errs []error
+	for _, m := range h.Histograms {
+		err := m.Observe(sample)
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	err := metric.JoinErrors(errs)
+	if err != nil {
+		return maskAny(err)
+	}
+
+	return nil
+}
+
+func (h *Histogram) ObserveWithLabelMap(sample float64, labels map[string]string) error {
+	var errs []error
+	for _, m := range h.Histograms {
+		err := m.ObserveWithLabelMap(sample, labels)
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	err := metric.JoinErrors(errs)
+	if err != nil {
+		return maskAny(err)
+	}
+
+	return nil
+}
+
+func (h *Histogram) ObserveWithLabels(sample float64, values ...string) error {
+	var errs []error
+	for _, m := range h.Histograms {
+		err := m.ObserveWithLabels(sample, values...)
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	err := metric.JoinErrors(errs)
+	if err != nil {
+		return maskAny(err)
+	}
+
+	return nil
+}
+
+func (h *Histogram) Reset() error {
+	var errs []error
+	for _, m := range h.Histograms {
+		err := m.Reset()
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	err := metric.JoinErrors(errs)
+	if err != nil {
+		return maskAny(err)
+	}
+
+	return nil
+}
+
+// StartTimer returns a timer forwarding to the timers started by all
+// histograms.
+func (h *Histogram) StartTimer() spec.Timer {
+	newTimer := &Timer{
+		Timers: nil,
+	}
+
+	for _, m := range h.Histograms {
+		newTimer.Timers = append(newTimer.Timers, m.StartTimer())
+	}
+
+	return newTimer
+}
+
+// StartTimerWithLabels returns a timer forwarding to the timers started by all
+// histograms using the given label values.
+func (h *Histogram) StartTimerWithLabels(values ...string) spec.Timer {
+	newTimer := &Timer{
+		Timers: nil,
+	}
+
+	for _, m := range h.Histograms {
+		newTimer.Timers = append(newTimer.Timers, m.StartTimerWithLabels(values...))
+	}
+
+	return newTimer
+}
+
+// With returns a histogram forwarding to the histograms returned by With of all
+// histograms.
+func (h *Histogram) With(values ...string) (spec.Histogram, error) {
+	newHistogram := &Histogram{
+		Histograms: nil,
+	}
+
+	var errs []error
+	for _, m := range h.Histograms {
+		bound, err := m.With(values...)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		newHistogram.Histograms = append(newHistogram.Histograms, bound)
+	}
+
+	err := metric.JoinErrors(errs)
+	if err != nil {
+		return nil, maskAny(err)
+	}
+
+	return newHistogram, nil
+}
@@ -0,0 +1,434 @@
+// Package publisher implements
+// github.com/the-anna-project/instrumentor.Publisher and provides
+// instrumentation primitives to emit application metrics to several publishers
+// at the same time.
+package publisher
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/the-anna-project/instrumentor/internal/metric"
+	"github.com/the-anna-project/instrumentor/spec"
+)
+
+// ServiceConfig represents the configuration used to create a new multi
+// publisher service.
+type ServiceConfig struct {
+	// Dependencies.
+
+	// Publishers represents the publishers every metric is emitted to. They
+	// should all be configured with the same prefixes as the multi publisher.
+	Publishers []spec.Publisher
+
+	// Settings.
+
+	// PanicMode describes how panics of wrapped actions are handled. It is one
+	// of spec.PanicModeNone, spec.PanicModeRecover or spec.PanicModeRepanic.
+	PanicMode string
+	Prefixes  []string
+	// SanitizeKeys causes NewKey to replace all characters not being allowed in
+	// metric names by underscores, e.g. in case keys are built from dynamic
+	// input. Metric names and label names are validated either way. Invalid
+	// ones cause errors asserted by IsInvalidName.
+	SanitizeKeys bool
+}
+
+// DefaultServiceConfig provides a default configuration to create a new multi
+// publisher service by best effort.
+func DefaultServiceConfig() ServiceConfig {
+	return ServiceConfig{
+		// Dependencies.
+		Publishers: nil,
+
+		// Settings.
+		PanicMode:    spec.PanicModeNone,
+		Prefixes:     []string{},
+		SanitizeKeys: false,
+	}
+}
+
+// NewService creates a new multi publisher service.
+func NewService(config ServiceConfig) (*Service, error) {
+	// Dependencies.
+	if len(config.Publishers) == 0 {
+		return nil, maskAnyf(invalidConfigError, "publishers must not be empty")
+	}
+	for _, p := range config.Publishers {
+		if p == nil {
+			return nil, maskAnyf(invalidConfigError, "publishers must not contain nil")
+		}
+	}
+
+	// Settings.
+	if config.PanicMode != spec.PanicModeNone && config.PanicMode != spec.PanicModeRecover && config.PanicMode != spec.PanicModeRepanic {
+		return nil, maskAnyf(invalidConfigError, "panic mode must be one of: %s, %s, %s", spec.PanicModeNone, spec.PanicModeRecover, spec.PanicModeRepanic)
+	}
+	if config.Prefixes == nil {
+		return nil, maskAnyf(invalidConfigError, "prefixes must not be empty")
+	}
+
+	newService := &Service{
+		// Dependencies.
+		publishers: append([]spec.Publisher(nil), config.Publishers...),
+
+		// Internals.
+		counters:     map[string]*Counter{},
+		bootOnce:     sync.Once{},
+		definitions:  map[string]metric.Definition{},
+		gauges:       map[string]*Gauge{},
+		histograms:   map[string]*Histogram{},
+		mutex:        sync.Mutex{},
+		shutdownOnce: sync.Once{},
+		summaries:    map[string]*Summary{},
+
+		// Settings.
+		panicMode:    config.PanicMode,
+		prefixes:     config.Prefixes,
+		sanitizeKeys: config.SanitizeKeys,
+	}
+
+	return newService, nil
+}
+
+type Service struct {
+	// Dependencies.
+
+	// publishers represents the publishers every metric is emitted to.
+	publishers []spec.Publisher
+
+	// Internals.
+	counters     map[string]*Counter
+	bootOnce     sync.Once
+	definitions  map[string]metric.Definition
+	gauges       map[string]*Gauge
+	histograms   map[string]*Histogram
+	mutex        sync.Mutex
+	shutdownOnce sync.Once
+	summaries    map[string]*Summary
+
+	// Settings.
+
+	// panicMode describes how panics of wrapped actions are handled.
+	panicMode string
+	// prefixes represents the Instrumentor's ordered prefixes.
+	prefixes []string
+	// sanitizeKeys describes whether NewKey sanitizes the keys it returns.
+	sanitizeKeys bool
+}
+
+func (s *Service) Boot() {
+	s.bootOnce.Do(func() {
+		var wg sync.WaitGroup
+
+		for _, p := range s.publishers {
+			wg.Add(1)
+			go func(p spec.Publisher) {
+				p.Boot()
+				wg.Done()
+			}(p)
+		}
+
+		wg.Wait()
+	})
+}
+
+func (s *Service) Counter(config spec.CounterConfig) (spec.Counter, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	newDefinition := metric.Definition{
+		Help:   config.Help(),
+		Kind:   metric.KindCounter,
+		Labels: config.Labels(),
+		Name:   config.Name(),
+		TTL:    configTTL(config),
+	}
+	// The definition is checked before any publisher is asked to create the
+	// counter, so that an invalid or conflicting definition does not register
+	// the counter with some of the publishers only.
+	d, ok := s.definitions[config.Name()]
+	err := d.Check(newDefinition)
+	if err != nil {
+		return nil, maskAny(err)
+	}
+	if ok {
+		return s.counters[config.Name()], nil
+	}
+
+	newCounter, err := NewCounter(config)
+	if err != nil {
+		return nil, maskAny(err)
+	}
+
+	var errs []error
+	for _, p := range s.publishers {
+		counterConfig := p.CounterConfig()
+		counterConfig.SetHelp(config.Help())
+		counterConfig.SetLabels(config.Labels())
+		counterConfig.SetName(config.Name())
+		forwardTTL(counterConfig, newDefinition.TTL)
+		c, err := p.Counter(counterConfig)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		newCounter.Counters = append(newCounter.Counters, c)
+	}
+
+	err = metric.JoinErrors(errs)
+	if err != nil {
+		return nil, maskAny(err)
+	}
+	s.definitions[config.Name()] = newDefinition
+	s.counters[config.Name()] = newCounter
+
+	return newCounter, nil
+}
+
+func (s *Service) CounterConfig() spec.CounterConfig {
+	return DefaultCounterConfig()
+}
+
+func (s *Service) Gauge(config spec.GaugeConfig) (spec.Gauge, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	newDefinition := metric.Definition{
+		Help:   config.Help(),
+		Kind:   metric.KindGauge,
+		Labels: config.Labels(),
+		Name:   config.Name(),
+		TTL:    configTTL(config),
+	}
+	d, ok := s.definitions[config.Name()]
+	err := d.Check(newDefinition)
+	if err != nil {
+		return nil, maskAny(err)
+	}
+	if ok {
+		return s.gauges[config.Name()], nil
+	}
+
+	newGauge, err := NewGauge(config)
+	if err != nil {
+		return nil, maskAny(err)
+	}
+
+	var errs []error
+	for _, p := range s.publishers {
+		gaugeConfig := p.GaugeConfig()
+		gaugeConfig.SetHelp(config.Help())
+		gaugeConfig.SetLabels(config.Labels())
+		gaugeConfig.SetName(config.Name())
+		forwardTTL(gaugeConfig, newDefinition.TTL)
+		g, err := p.Gauge(gaugeConfig)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		newGauge.Gauges = append(newGauge.Gauges, g)
+	}
+
+	err = metric.JoinErrors(errs)
+	if err != nil {
+		return nil, maskAny(err)
+	}
+	s.definitions[config.Name()] = newDefinition
+	s.gauges[config.Name()] = newGauge
+
+	return newGauge, nil
+}
+
+func (s *Service) GaugeConfig() spec.GaugeConfig {
+	return DefaultGaugeConfig()
+}
+
+func (s *Service) Histogram(config spec.HistogramConfig) (spec.Histogram, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	// The publishers append the unit to the name of the histogram, so it is
+	// looked up by the name it is actually reported with.
+	name := metric.UnitName(config.Name(), config.Unit())
+
+	newDefinition := metric.Definition{
+		Buckets:               config.Buckets(),
+		Help:                  config.Help(),
+		Kind:                  metric.KindHistogram,
+		Labels:                config.Labels(),
+		Name:                  name,
+		NativeBucketFactor:    config.NativeBucketFactor(),
+		NativeMaxBucketNumber: config.NativeMaxBucketNumber(),
+		NativeZeroThreshold:   config.NativeZeroThreshold(),
+		TTL:                   configTTL(config),
+	}
+	d, ok := s.definitions[name]
+	err := d.Check(newDefinition)
+	if err != nil {
+		return nil, maskAny(err)
+	}
+	if ok {
+		return s.histograms[name], nil
+	}
+
+	newHistogram, err := NewHistogram(config)
+	if err != nil {
+		return nil, maskAny(err)
+	}
+
+	var errs []error
+	for _, p := range s.publishers {
+		histogramConfig := p.HistogramConfig()
+		histogramConfig.SetBuckets(config.Buckets())
+		histogramConfig.SetHelp(config.Help())
+		histogramConfig.SetLabels(config.Labels())
+		histogramConfig.SetName(config.Name())
+		histogramConfig.SetNativeBucketFactor(config.NativeBucketFactor())
+		histogramConfig.SetNativeMaxBucketNumber(config.NativeMaxBucketNumber())
+		histogramConfig.SetNativeZeroThreshold(config.NativeZeroThreshold())
+		histogramConfig.SetUnit(config.Unit())
+		forwardTTL(histogramConfig, newDefinition.TTL)
+		h, err := p.Histogram(histogramConfig)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		newHistogram.Histograms = append(newHistogram.Histograms, h)
+	}
+
+	err = metric.JoinErrors(errs)
+	if err != nil {
+		return nil, maskAny(err)
+	}
+	s.definitions[name] = newDefinition
+	s.histograms[name] = newHistogram
+
+	return newHistogram, nil
+}
+
+func (s *Service) HistogramConfig() spec.HistogramConfig {
+	return DefaultHistogramConfig()
+}
+
+// HTTPEndpoint returns the HTTP endpoint of the first publisher providing an
+// HTTP handler.
+func (s *Service) HTTPEndpoint() string {
+	for _, p := range s.publishers {
+		if p.HTTPHandler() != nil {
+			return p.HTTPEndpoint()
+		}
+	}
+
+	return ""
+}
+
+// HTTPHandler returns the HTTP handler of the first publisher providing one.
+func (s *Service) HTTPHandler() http.Handler {
+	for _, p := range s.publishers {
+		if h := p.HTTPHandler(); h != nil {
+			return h
+		}
+	}
+
+	return nil
+}
+
+func (s *Service) Prefixes() []string {
+	return s.prefixes
+}
+
+func (s *Service) NewKey(str ...string) string {
+	key := strings.Join(append(append([]string{}, s.prefixes...), str...), "_")
+	if s.sanitizeKeys {
+		key = metric.SanitizeName(key)
+	}
+
+	return key
+}
+
+func (s *Service) Shutdown() {
+	s.shutdownOnce.Do(func() {
+		var wg sync.WaitGroup
+
+		for _, p := range s.publishers {
+			wg.Add(1)
+			go func(p spec.Publisher) {
+				p.Shutdown()
+				wg.Done()
+			}(p)
+		}
+
+		wg.Wait()
+	})
+}
+
+func (s *Service) Summary(config spec.SummaryConfig) (spec.Summary, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	newDefinition := metric.Definition{
+		AgeBuckets: config.AgeBuckets(),
+		Help:       config.Help(),
+		Kind:       metric.KindSummary,
+		Labels:     config.Labels(),
+		MaxAge:     config.MaxAge(),
+		Name:       config.Name(),
+		Objectives: config.Objectives(),
+		TTL:        configTTL(config),
+	}
+	d, ok := s.definitions[config.Name()]
+	err := d.Check(newDefinition)
+	if err != nil {
+		return nil, maskAny(err)
+	}
+	if ok {
+		return s.summaries[config.Name()], nil
+	}
+
+	newSummary, err := NewSummary(config)
+	if err != nil {
+		return nil, maskAny(err)
+	}
+
+	var errs []error
+	for _, p := range s.publishers {
+		summaryConfig := p.SummaryConfig()
+		summaryConfig.SetAgeBuckets(config.AgeBuckets())
+		summaryConfig.SetHelp(config.Help())
+		summaryConfig.SetLabels(config.Labels())
+		summaryConfig.SetMaxAge(config.MaxAge())
+		summaryConfig.SetName(config.Name())
+		summaryConfig.SetObjectives(config.Objectives())
+		forwardTTL(summaryConfig, newDefinition.TTL)
+		m, err := p.Summary(summaryConfig)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		newSummary.Summaries = append(newSummary.Summaries, m)
+	}
+
+	err = metric.JoinErrors(errs)
+	if err != nil {
+		return nil, maskAny(err)
+	}
+	s.definitions[config.Name()] = newDefinition
+	s.summaries[config.Name()] = newSummary
+
+	return newSummary, nil
+}
+
+func (s *Service) SummaryConfig() spec.SummaryConfig {
+	return DefaultSummaryConfig()
+}
+
+func (s *Service) WrapContextFunc(key string, labels map[string]string, action func(ctx context.Context) error) func(ctx context.Context) error {
+	return metric.WrapContextFunc(s, s.panicMode, key, labels, action)
+}
+
+func (s *Service) WrapFunc(key string, action func() error) func() error {
+	return metric.WrapFunc(s, s.panicMode, key, action)
+}
@@ -0,0 +1,319 @@
+package publisher
+
+import (
+	"testing"
+	"time"
+
+	memorypublisher "github.com/the-anna-project/instrumentor/memory/publisher"
+	memorystorage "github.com/the-anna-project/instrumentor/memory/storage"
+	"github.com/the-anna-project/instrumentor/spec"
+)
+
+// newTestService creates a multi publisher service emitting metrics to memory
+// publishers recording metric values in the given storages.
+func newTestService(t *testing.T, storages ...*memorystorage.Storage) *Service {
+	var publishers []spec.Publisher
+	for _, st := range storages {
+		publishers = append(publishers, newMemoryPublisher(t, st))
+	}
+
+	config := DefaultServiceConfig()
+	config.Publishers = publishers
+	s, err := NewService(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return s
+}
+
+// newMemoryPublisher creates a memory publisher service recording metric values
+// in the given storage.
+func newMemoryPublisher(t *testing.T, st *memorystorage.Storage) *memorypublisher.Service {
+	config := memorypublisher.DefaultServiceConfig()
+	config.Storage = st
+	p, err := memorypublisher.NewService(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return p
+}
+
+// newStorages creates the given number of memory storages.
+func newStorages(t *testing.T, n int) []*memorystorage.Storage {
+	var storages []*memorystorage.Storage
+	for i := 0; i < n; i++ {
+		st, err := memorystorage.NewStorage(memorystorage.DefaultStorageConfig())
+		if err != nil {
+			t.Fatal(err)
+		}
+		storages = append(storages, st)
+	}
+
+	return storages
+}
+
+// newCounterConfig creates a multi publisher counter config having the given
+// name and labels.
+func newCounterConfig(name string, labels ...string) spec.CounterConfig {
+	config := DefaultCounterConfig()
+	config.SetHelp("Number of requests.")
+	config.SetLabels(labels)
+	config.SetName(name)
+
+	return config
+}
+
+func TestService_Counter(t *testing.T) {
+	storages := newStorages(t, 2)
+	s := newTestService(t, storages...)
+
+	c, err := s.Counter(newCounterConfig("requests_total", "method", "code"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		Name   string
+		Record func() error
+		Values []string
+		Value  float64
+	}{
+		{
+			Name:   "labels",
+			Record: func() error { return c.IncrementWithLabels(2, "GET", "200") },
+			Values: []string{"GET", "200"},
+			Value:  2,
+		},
+		{
+			Name:   "label map",
+			Record: func() error { return c.IncrementWithLabelMap(1, map[string]string{"code": "200", "method": "GET"}) },
+			Values: []string{"GET", "200"},
+			Value:  3,
+		},
+		{
+			Name: "bound labels",
+			Record: func() error {
+				bound, err := c.With("POST", "500")
+				if err != nil {
+					return err
+				}
+				return bound.Increment(4)
+			},
+			Values: []string{"POST", "500"},
+			Value:  4,
+		},
+	}
+
+	for _, tc := range testCases {
+		err := tc.Record()
+		if err != nil {
+			t.Fatalf("%s: %v", tc.Name, err)
+		}
+
+		// Every call is forwarded to the counters of all publishers.
+		for i, st := range storages {
+			m, err := st.Metric("requests_total")
+			if err != nil {
+				t.Fatalf("%s: publisher %d: %v", tc.Name, i, err)
+			}
+			series, err := m.Series(tc.Values...)
+			if err != nil {
+				t.Fatalf("%s: publisher %d: %v", tc.Name, i, err)
+			}
+			if series.Value != tc.Value {
+				t.Fatalf("%s: publisher %d: expected value %v, got %v", tc.Name, i, tc.Value, series.Value)
+			}
+		}
+	}
+
+	// Errors of all counters are joined and can be asserted like the error of
+	// a single counter.
+	err = c.IncrementWithLabels(-1, "GET", "200")
+	if !IsInvalidConfig(err) {
+		t.Fatalf("expected invalid config error for negative delta, got %v", err)
+	}
+}
+
+func TestService_Counter_PartialRegistration(t *testing.T) {
+	storages := newStorages(t, 2)
+	s := newTestService(t, storages...)
+
+	// The second publisher cannot register the counter, since its storage
+	// already holds a metric of the same name.
+	config := memorypublisher.DefaultGaugeConfig()
+	config.SetHelp("Number of requests.")
+	config.SetName("requests_total")
+	_, err := newMemoryPublisher(t, storages[1]).Gauge(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The counter is not remembered in case any publisher fails to create it,
+	// so it is not returned later on forwarding to some of the publishers only.
+	for i := 0; i < 2; i++ {
+		_, err := s.Counter(newCounterConfig("requests_total"))
+		if !IsAlreadyRegistered(err) {
+			t.Fatalf("attempt %d: expected already registered error, got %v", i, err)
+		}
+	}
+
+	m, err := storages[1].Metric("requests_total")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Kind() != memorystorage.KindGauge {
+		t.Fatalf("expected kind %s, got %s", memorystorage.KindGauge, m.Kind())
+	}
+}
+
+func TestService_Counter_InvalidDefinition(t *testing.T) {
+	storages := newStorages(t, 2)
+	s := newTestService(t, storages...)
+
+	_, err := s.Counter(newCounterConfig("requests_total", "method"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		Name   string
+		Config spec.CounterConfig
+		Check  func(err error) bool
+	}{
+		{
+			Name:   "invalid label",
+			Config: newCounterConfig("errors_total", "__method"),
+			Check:  IsInvalidName,
+		},
+		{
+			Name:   "invalid name",
+			Config: newCounterConfig("errors-total"),
+			Check:  IsInvalidName,
+		},
+		{
+			Name:   "conflicting definition",
+			Config: newCounterConfig("requests_total", "code"),
+			Check:  IsConflictingDefinition,
+		},
+	}
+
+	for _, tc := range testCases {
+		_, err := s.Counter(tc.Config)
+		if !tc.Check(err) {
+			t.Fatalf("%s: unexpected error %v", tc.Name, err)
+		}
+	}
+
+	// Invalid and conflicting definitions are rejected before any publisher is
+	// asked to create the counter.
+	for i, st := range storages {
+		if names := st.Names(); len(names) != 1 || names[0] != "requests_total" {
+			t.Fatalf("publisher %d: expected only requests_total, got %v", i, names)
+		}
+	}
+}
+
+// ttlPublisher is a memory publisher providing counter configs accepting a
+// TTL, like the ones of the prometheus publisher. It records the TTL of the
+// configs counters are created with.
+type ttlPublisher struct {
+	*memorypublisher.Service
+
+	ttls map[string]time.Duration
+}
+
+type ttlCounterConfig struct {
+	*memorypublisher.CounterConfig
+
+	ttl time.Duration
+}
+
+func (c *ttlCounterConfig) SetTTL(ttl time.Duration) {
+	c.ttl = ttl
+}
+
+func (p *ttlPublisher) Counter(config spec.CounterConfig) (spec.Counter, error) {
+	p.ttls[config.Name()] = config.(*ttlCounterConfig).ttl
+	return p.Service.Counter(config)
+}
+
+func (p *ttlPublisher) CounterConfig() spec.CounterConfig {
+	return &ttlCounterConfig{CounterConfig: memorypublisher.DefaultCounterConfig(), ttl: time.Hour}
+}
+
+func TestService_Counter_TTL(t *testing.T) {
+	storages := newStorages(t, 1)
+	p := &ttlPublisher{Service: newMemoryPublisher(t, storages[0]), ttls: map[string]time.Duration{}}
+
+	config := DefaultServiceConfig()
+	config.Publishers = []spec.Publisher{p}
+	s, err := NewService(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	withTTL := newCounterConfig("requests_total", "method")
+	withTTL.(*CounterConfig).SetTTL(time.Minute)
+	_, err = s.Counter(withTTL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = s.Counter(newCounterConfig("errors_total", "method"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A TTL of 0 is not forwarded, so the TTL configured by the publisher is
+	// kept.
+	want := map[string]time.Duration{"errors_total": time.Hour, "requests_total": time.Minute}
+	for name, ttl := range want {
+		if p.ttls[name] != ttl {
+			t.Fatalf("%s: expected TTL %v, got %v", name, ttl, p.ttls[name])
+		}
+	}
+
+	// The TTL is part of the definition of the counter.
+	other := newCounterConfig("requests_total", "method")
+	other.(*CounterConfig).SetTTL(time.Hour)
+	_, err = s.Counter(other)
+	if !IsConflictingDefinition(err) {
+		t.Fatalf("expected conflicting definition error, got %v", err)
+	}
+}
+
+func TestService_Counter_LabelMap(t *testing.T) {
+	s := newTestService(t, newStorages(t, 2)...)
+
+	c, err := s.Counter(newCounterConfig("requests_total", "method", "code"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The label map errors of all counters are joined and can be asserted using
+	// the helpers of this package, like the ones of any other publisher.
+	testCases := []struct {
+		Name   string
+		Labels map[string]string
+		Check  func(err error) bool
+	}{
+		{
+			Name:   "missing label",
+			Labels: map[string]string{"method": "GET"},
+			Check:  IsMissingLabel,
+		},
+		{
+			Name:   "unknown label",
+			Labels: map[string]string{"code": "200", "method": "GET", "path": "/"},
+			Check:  IsUnknownLabel,
+		},
+	}
+
+	for _, tc := range testCases {
+		err := c.IncrementWithLabelMap(1, tc.Labels)
+		if !tc.Check(err) {
+			t.Fatalf("%s: unexpected error %v", tc.Name, err)
+		}
+	}
+}
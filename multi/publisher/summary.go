@@ -0,0 +1,254 @@
+package publisher
+
+import (
+	"time"
+
+	"github.com/the-anna-project/instrumentor/internal/metric"
+	"github.com/the-anna-project/instrumentor/spec"
+)
+
+// SummaryConfig represents the configuration used to create a new multi
+// publisher summary.
+type SummaryConfig struct {
+	// Settings.
+	ageBuckets uint32
+	help       string
+	labels     []string
+	maxAge     time.Duration
+	name       string
+	objectives map[float64]float64
+	ttl        time.Duration
+}
+
+func (sc *SummaryConfig) AgeBuckets() uint32 {
+	return sc.ageBuckets
+}
+
+func (sc *SummaryConfig) Help() string {
+	return sc.help
+}
+
+func (sc *SummaryConfig) Labels() []string {
+	return sc.labels
+}
+
+func (sc *SummaryConfig) MaxAge() time.Duration {
+	return sc.maxAge
+}
+
+func (sc *SummaryConfig) Name() string {
+	return sc.name
+}
+
+func (sc *SummaryConfig) Objectives() map[float64]float64 {
+	return sc.objectives
+}
+
+func (sc *SummaryConfig) SetAgeBuckets(ageBuckets uint32) {
+	sc.ageBuckets = ageBuckets
+}
+
+func (sc *SummaryConfig) SetHelp(help string) {
+	sc.help = help
+}
+
+func (sc *SummaryConfig) SetLabels(labels []string) {
+	sc.labels = labels
+}
+
+func (sc *SummaryConfig) SetMaxAge(maxAge time.Duration) {
+	sc.maxAge = maxAge
+}
+
+func (sc *SummaryConfig) SetName(name string) {
+	sc.name = name
+}
+
+func (sc *SummaryConfig) SetObjectives(objectives map[float64]float64) {
+	sc.objectives = objectives
+}
+
+func (sc *SummaryConfig) SetTTL(ttl time.Duration) {
+	sc.ttl = ttl
+}
+
+// TTL returns the duration after which series not being written expire. It is
+// forwarded to the metric configs of the prometheus publisher, which is the
+// only publisher supporting it. The other publishers ignore it. In case it is
+// 0, which is the default, the TTL configured by the prometheus publisher is
+// used.
+func (sc *SummaryConfig) TTL() time.Duration {
+	return sc.ttl
+}
+
+// DefaultSummaryConfig provides a default configuration to create a new multi
+// publisher summary by best effort.
+func DefaultSummaryConfig() *SummaryConfig {
+	return &SummaryConfig{
+		// Settings.
+		ageBuckets: 5,
+		help:       "",
+		labels:     nil,
+		maxAge:     10 * time.Minute,
+		name:       "",
+		objectives: map[float64]float64{0.5: 0.05, 0.9: 0.01, 0.99: 0.001},
+		ttl:        0,
+	}
+}
+
+// NewSummary creates a new configured multi publisher summary. The summary does
+// not forward to any summary until Summaries is set. The config is validated
+// against the requirements of all publishers, e.g. its name and label names
+// must be valid, so that an invalid config is rejected before the summary is
+// created by any publisher.
+func NewSummary(config spec.SummaryConfig) (*Summary, error) {
+	// Settings.
+	if config.Name() == "" {
+		return nil, maskAnyf(invalidConfigError, "name must not be empty")
+	}
+	err := metric.ValidateName(config.Name())
+	if err != nil {
+		return nil, maskAny(err)
+	}
+	err = metric.ValidateLabels(config.Labels(), nil, metric.QuantileLabel)
+	if err != nil {
+		return nil, maskAny(err)
+	}
+	if config.AgeBuckets() == 0 {
+		return nil, maskAnyf(invalidConfigError, "age buckets must be greater than 0")
+	}
+	if config.MaxAge() < time.Duration(config.AgeBuckets()) {
+		return nil, maskAnyf(invalidConfigError, "max age must be at least 1 nanosecond per age bucket")
+	}
+	for q, e := range config.Objectives() {
+		if q < 0 || q > 1 {
+			return nil, maskAnyf(invalidConfigError, "objective quantile %v must be between 0 and 1", q)
+		}
+		if e < 0 {
+			return nil, maskAnyf(invalidConfigError, "objective error %v must not be negative", e)
+		}
+	}
+
+	newSummary := &Summary{
+		Summaries: nil,
+	}
+
+	return newSummary, nil
+}
+
+// Summary forwards every call to all of its summaries, which are usually
+// created by different publishers. Errors of all summaries are joined.
+type Summary struct {
+	// Public.
+	Summaries []spec.Summary
+}
+
+func (s *Summary) DeleteLabelValues(values ...string) error {
+	var errs []error
+	for _, m := range s.Summaries {
+		err := m.DeleteLabelValues(values...)
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	err := metric.JoinErrors(errs)
+	if err != nil {
+		return maskAny(err)
+	}
+
+	return nil
+}
+
+func (s *Summary) Observe(sample float64) error {
+	var errs []error
+	for _, m := range s.Summaries {
+		err := m.Observe(sample)
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	err := metric.JoinErrors(errs)
+	if err != nil {
+		return maskAny(err)
+	}
+
+	return nil
+}
+
+func (s *Summary) ObserveWithLabelMap(sample float64, labels map[string]string) error {
+	var errs []error
+	for _, m := range s.Summaries {
+		err := m.ObserveWithLabelMap(sample, labels)
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	err := metric.JoinErrors(errs)
+	if err != nil {
+		return maskAny(err)
+	}
+
+	return nil
+}
+
+func (s *Summary) ObserveWithLabels(sample float64, values ...string) error {
+	var errs []error
+	for _, m := range s.Summaries {
+		err := m.ObserveWithLabels(sample, values...)
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	err := metric.JoinErrors(errs)
+	if err != nil {
+		return maskAny(err)
+	}
+
+	return nil
+}
+
+func (s *Summary) Reset() error {
+	var errs []error
+	for _, m := range s.Summaries {
+		err := m.Reset()
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	err := metric.JoinErrors(errs)
+	if err != nil {
+		return maskAny(err)
+	}
+
+	return nil
+}
+
+// With returns a summary forwarding to the summaries returned by With of all
+// summaries.
+func (s *Summary) With(values ...string) (spec.Summary, error) {
+	newSummary := &Summary{
+		Summaries: nil,
+	}
+
+	var errs []error
+	for _, m := range s.Summaries {
+		bound, err := m.With(values...)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		newSummary.Summaries = append(newSummary.Summaries, bound)
+	}
+
+	err := metric.JoinErrors(errs)
+	if err != nil {
+		return nil, maskAny(err)
+	}
+
+	return newSummary, nil
+}
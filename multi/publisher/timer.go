@@ -0,0 +1,42 @@
+package publisher
+
+import (
+	"time"
+
+	"github.com/the-anna-project/instrumentor/internal/metric"
+	"github.com/the-anna-project/instrumentor/spec"
+)
+
+// Timer forwards every call to all of its timers, which are usually started by
+// the histograms of different publishers. Errors of all timers are joined.
+type Timer struct {
+	// Public.
+	Timers []spec.Timer
+}
+
+// ObserveDuration returns the duration measured by the first timer, or 0 in
+// case there is none.
+func (t *Timer) ObserveDuration() (time.Duration, error) {
+	var d time.Duration
+	var errs []error
+	for i, m := range t.Timers {
+		observed, err := m.ObserveDuration()
+		if err != nil {
+			errs = append(errs, err)
+		}
+		if i == 0 {
+			d = observed
+		}
+	}
+
+	err := metric.JoinErrors(errs)
+	if err != nil {
+		return d, maskAny(err)
+	}
+
+	return d, nil
+}
+
+func (t *Timer) Stop() {
+	t.ObserveDuration()
+}
@@ -0,0 +1,40 @@
+package publisher
+
+import (
+	"time"
+)
+
+// ttlConfig is implemented by metric configs providing a TTL, which are the
+// ones of the multi and prometheus publishers.
+type ttlConfig interface {
+	TTL() time.Duration
+}
+
+// ttlSetter is implemented by metric configs accepting a TTL, which are only
+// the ones of the prometheus publisher.
+type ttlSetter interface {
+	SetTTL(ttl time.Duration)
+}
+
+// configTTL returns the TTL of the given metric config, which is 0 in case the
+// config does not provide one.
+func configTTL(config interface{}) time.Duration {
+	c, ok := config.(ttlConfig)
+	if !ok {
+		return 0
+	}
+
+	return c.TTL()
+}
+
+// forwardTTL sets the given TTL on the given metric config of a publisher, in
+// case the config accepts a TTL. A TTL of 0 is not forwarded, so that the TTL
+// configured by the publisher is kept.
+func forwardTTL(config interface{}, ttl time.Duration) {
+	c, ok := config.(ttlSetter)
+	if !ok || ttl == 0 {
+		return
+	}
+
+	c.SetTTL(ttl)
+}
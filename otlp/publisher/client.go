@@ -0,0 +1,116 @@
+package publisher
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+
+	colmetricspb "go.opentelemetry.io/proto/otlp/collector/metrics/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+// ClientConfig represents the configuration used to create a new OTLP client.
+type ClientConfig struct {
+	// Settings.
+
+	// Endpoint represents the URL of the OTLP/HTTP metrics endpoint of an
+	// OpenTelemetry collector, usually ending with /v1/metrics.
+	Endpoint string
+	// Headers represents additional HTTP headers sent with every export
+	// request, e.g. in order to authenticate against the collector.
+	Headers map[string]string
+	// Timeout represents the maximum duration of a single export request.
+	Timeout time.Duration
+}
+
+// DefaultClientConfig provides a default configuration to create a new OTLP
+// client by best effort.
+func DefaultClientConfig() ClientConfig {
+	return ClientConfig{
+		// Settings.
+		Endpoint: "http://127.0.0.1:4318/v1/metrics",
+		Headers:  map[string]string{},
+		Timeout:  10 * time.Second,
+	}
+}
+
+// NewClient creates a new configured OTLP client.
+func NewClient(config ClientConfig) (*Client, error) {
+	// Settings.
+	if config.Endpoint == "" {
+		return nil, maskAnyf(invalidConfigError, "endpoint must not be empty")
+	}
+	u, err := url.Parse(config.Endpoint)
+	if err != nil {
+		return nil, maskAnyf(invalidConfigError, "endpoint must be a valid URL: %s", err.Error())
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, maskAnyf(invalidConfigError, "endpoint scheme must be one of: http, https")
+	}
+	if config.Timeout <= 0 {
+		return nil, maskAnyf(invalidConfigError, "timeout must be greater than 0")
+	}
+
+	headers := map[string]string{}
+	for k, v := range config.Headers {
+		headers[k] = v
+	}
+
+	newClient := &Client{
+		// Internals.
+		httpClient: &http.Client{Timeout: config.Timeout},
+
+		// Settings.
+		endpoint: config.Endpoint,
+		headers:  headers,
+	}
+
+	return newClient, nil
+}
+
+// Client exports metrics to the configured OTLP/HTTP endpoint using the binary
+// protobuf encoding. All methods are safe for concurrent use.
+type Client struct {
+	// Internals.
+	httpClient *http.Client
+
+	// Settings.
+	endpoint string
+	headers  map[string]string
+}
+
+// Export sends the given request to the OTLP endpoint. An error asserted by
+// IsExportFailed is returned in case the endpoint rejects the request.
+func (c *Client) Export(request *colmetricspb.ExportMetricsServiceRequest) error {
+	b, err := proto.Marshal(request)
+	if err != nil {
+		return maskAny(err)
+	}
+
+	req, err := http.NewRequest("POST", c.endpoint, bytes.NewReader(b))
+	if err != nil {
+		return maskAny(err)
+	}
+	for k, v := range c.headers {
+		req.Header.Set(k, v)
+	}
+	req.Header.Set("Content-Type", "application/x-protobuf")
+
+	res, err := c.httpClient.Do(req)
+	if err != nil {
+		return maskAny(err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		body, _ := io.ReadAll(io.LimitReader(res.Body, 512))
+		return maskAnyf(exportFailedError, "%s responded with %s: %s", c.endpoint, res.Status, bytes.TrimSpace(body))
+	}
+
+	// The response body is drained so the connection can be reused.
+	io.Copy(io.Discard, res.Body)
+
+	return nil
+}
@@ -0,0 +1,215 @@
+package publisher
+
+import (
+	"math"
+	"sort"
+
+	colmetricspb "go.opentelemetry.io/proto/otlp/collector/metrics/v1"
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	metricspb "go.opentelemetry.io/proto/otlp/metrics/v1"
+	resourcepb "go.opentelemetry.io/proto/otlp/resource/v1"
+
+	"github.com/the-anna-project/instrumentor/memory/storage"
+	"github.com/the-anna-project/instrumentor/spec"
+)
+
+// scopeName is the name of the instrumentation scope all metrics are exported
+// with.
+const scopeName = "github.com/the-anna-project/instrumentor"
+
+// newExportRequest creates an export request holding the current state of the
+// given metrics. All data points are cumulative since their series started.
+// Counters are mapped to monotonic sums, gauges to gauges, histograms to
+// explicit bucket histograms and summaries to summaries.
+func newExportRequest(metrics []*storage.Metric, resourceAttributes map[string]string, now uint64) *colmetricspb.ExportMetricsServiceRequest {
+	var list []*metricspb.Metric
+	for _, m := range metrics {
+		newMetric := &metricspb.Metric{
+			Description: m.Help(),
+			Name:        m.Name(),
+			Unit:        unit(m.Unit()),
+		}
+
+		switch m.Kind() {
+		case storage.KindCounter:
+			newMetric.Data = &metricspb.Metric_Sum{
+				Sum: &metricspb.Sum{
+					AggregationTemporality: metricspb.AggregationTemporality_AGGREGATION_TEMPORALITY_CUMULATIVE,
+					DataPoints:             numberDataPoints(m, now),
+					IsMonotonic:            true,
+				},
+			}
+		case storage.KindGauge:
+			newMetric.Data = &metricspb.Metric_Gauge{
+				Gauge: &metricspb.Gauge{
+					DataPoints: numberDataPoints(m, now),
+				},
+			}
+		case storage.KindHistogram:
+			newMetric.Data = &metricspb.Metric_Histogram{
+				Histogram: &metricspb.Histogram{
+					AggregationTemporality: metricspb.AggregationTemporality_AGGREGATION_TEMPORALITY_CUMULATIVE,
+					DataPoints:             histogramDataPoints(m, now),
+				},
+			}
+		case storage.KindSummary:
+			newMetric.Data = &metricspb.Metric_Summary{
+				Summary: &metricspb.Summary{
+					DataPoints: summaryDataPoints(m, now),
+				},
+			}
+		}
+
+		list = append(list, newMetric)
+	}
+
+	newRequest := &colmetricspb.ExportMetricsServiceRequest{
+		ResourceMetrics: []*metricspb.ResourceMetrics{
+			{
+				Resource: &resourcepb.Resource{
+					Attributes: keyValues(resourceAttributes),
+				},
+				ScopeMetrics: []*metricspb.ScopeMetrics{
+					{
+						Metrics: list,
+						Scope: &commonpb.InstrumentationScope{
+							Name: scopeName,
+						},
+					},
+				},
+			},
+		},
+	}
+
+	return newRequest
+}
+
+// attributes returns the attributes of a data point given the metric's
+// constant labels, its label names and the label values of a series. The
+// constant labels come first, ordered by their names.
+func attributes(constLabels map[string]string, labels []string, values []string) []*commonpb.KeyValue {
+	list := keyValues(constLabels)
+	for i, l := range labels {
+		if i >= len(values) {
+			break
+		}
+		list = append(list, keyValue(l, values[i]))
+	}
+
+	return list
+}
+
+func histogramDataPoints(m *storage.Metric, now uint64) []*metricspb.HistogramDataPoint {
+	var list []*metricspb.HistogramDataPoint
+	for _, s := range m.SeriesList() {
+		// The storage counts samples cumulatively, while OTLP expects the number
+		// of samples per bucket. The last bucket holds the samples greater than
+		// the last bound.
+		var bucketCounts []uint64
+		var previous uint64
+		for _, c := range s.BucketCounts {
+			bucketCounts = append(bucketCounts, c-previous)
+			previous = c
+		}
+		bucketCounts = append(bucketCounts, s.Count-previous)
+
+		sum := s.Sum
+		list = append(list, &metricspb.HistogramDataPoint{
+			Attributes:        attributes(m.ConstLabels(), m.Labels(), s.LabelValues),
+			BucketCounts:      bucketCounts,
+			Count:             s.Count,
+			ExplicitBounds:    append([]float64(nil), m.Buckets()...),
+			StartTimeUnixNano: uint64(s.Start.UnixNano()),
+			Sum:               &sum,
+			TimeUnixNano:      now,
+		})
+	}
+
+	return list
+}
+
+func keyValue(key string, value string) *commonpb.KeyValue {
+	return &commonpb.KeyValue{
+		Key: key,
+		Value: &commonpb.AnyValue{
+			Value: &commonpb.AnyValue_StringValue{StringValue: value},
+		},
+	}
+}
+
+// keyValues returns the given attributes ordered by their keys.
+func keyValues(attributes map[string]string) []*commonpb.KeyValue {
+	var keys []string
+	for k := range attributes {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var list []*commonpb.KeyValue
+	for _, k := range keys {
+		list = append(list, keyValue(k, attributes[k]))
+	}
+
+	return list
+}
+
+func numberDataPoints(m *storage.Metric, now uint64) []*metricspb.NumberDataPoint {
+	var list []*metricspb.NumberDataPoint
+	for _, s := range m.SeriesList() {
+		list = append(list, &metricspb.NumberDataPoint{
+			Attributes:        attributes(m.ConstLabels(), m.Labels(), s.LabelValues),
+			StartTimeUnixNano: uint64(s.Start.UnixNano()),
+			TimeUnixNano:      now,
+			Value:             &metricspb.NumberDataPoint_AsDouble{AsDouble: s.Value},
+		})
+	}
+
+	return list
+}
+
+func summaryDataPoints(m *storage.Metric, now uint64) []*metricspb.SummaryDataPoint {
+	var list []*metricspb.SummaryDataPoint
+	for _, s := range m.SeriesList() {
+		var quantiles []float64
+		for q := range s.Quantiles {
+			quantiles = append(quantiles, q)
+		}
+		sort.Float64s(quantiles)
+
+		// Quantiles of empty summaries are NaN and therefore omitted.
+		var quantileValues []*metricspb.SummaryDataPoint_ValueAtQuantile
+		for _, q := range quantiles {
+			if math.IsNaN(s.Quantiles[q]) {
+				continue
+			}
+			quantileValues = append(quantileValues, &metricspb.SummaryDataPoint_ValueAtQuantile{
+				Quantile: q,
+				Value:    s.Quantiles[q],
+			})
+		}
+
+		list = append(list, &metricspb.SummaryDataPoint{
+			Attributes:        attributes(m.ConstLabels(), m.Labels(), s.LabelValues),
+			Count:             s.Count,
+			QuantileValues:    quantileValues,
+			StartTimeUnixNano: uint64(s.Start.UnixNano()),
+			Sum:               s.Sum,
+			TimeUnixNano:      now,
+		})
+	}
+
+	return list
+}
+
+// unit returns the UCUM unit of the given histogram unit as expected by OTLP.
+// Histograms not tracking durations have no unit.
+func unit(u string) string {
+	switch u {
+	case spec.UnitMilliseconds:
+		return "ms"
+	case spec.UnitSeconds:
+		return "s"
+	}
+
+	return ""
+}
@@ -0,0 +1,250 @@
+// Package publisher implements
+// github.com/the-anna-project/instrumentor.Publisher and provides
+// instrumentation primitives to emit application metrics.
+package publisher
+
+import (
+	"sync"
+	"time"
+
+	"github.com/the-anna-project/instrumentor/internal/metric"
+	memorypublisher "github.com/the-anna-project/instrumentor/memory/publisher"
+	"github.com/the-anna-project/instrumentor/memory/storage"
+	"github.com/the-anna-project/instrumentor/spec"
+)
+
+// ServiceConfig represents the configuration used to create a new OTLP
+// publisher service.
+type ServiceConfig struct {
+	// Dependencies.
+
+	// Storage represents the storage metric values are aggregated in between
+	// exports. It can be shared with a memory consumer in order to read the
+	// aggregated values back. In case it is nil, which is the default, a new
+	// storage is created.
+	Storage *storage.Storage
+
+	// Settings.
+
+	// ConstLabels represents labels having the same value for all metrics
+	// created by the service, e.g. the name of the service or its version.
+	ConstLabels map[string]string
+	// Endpoint represents the URL of the OTLP/HTTP metrics endpoint of an
+	// OpenTelemetry collector, usually ending with /v1/metrics.
+	Endpoint string
+	// ErrorHandler is called with errors the service cannot return, which is
+	// the error of the final export on Shutdown. The default logs them.
+	ErrorHandler func(err error)
+	// ExportInterval represents the interval in which aggregated metrics are
+	// exported to the OTLP endpoint.
+	ExportInterval time.Duration
+	// Headers represents additional HTTP headers sent with every export
+	// request, e.g. in order to authenticate against the collector.
+	Headers map[string]string
+	// PanicMode describes how panics of wrapped actions are handled. It is one
+	// of spec.PanicModeNone, spec.PanicModeRecover or spec.PanicModeRepanic.
+	PanicMode string
+	Prefixes  []string
+	// ResourceAttributes represents the attributes of the resource all metrics
+	// are exported with, e.g. service.name.
+	ResourceAttributes map[string]string
+	// SanitizeKeys causes NewKey to replace all characters not being allowed in
+	// metric names by underscores, e.g. in case keys are built from dynamic
+	// input. Metric names and label names are validated either way. Invalid
+	// ones cause errors asserted by IsInvalidName.
+	SanitizeKeys bool
+	// Timeout represents the maximum duration of a single export request.
+	Timeout time.Duration
+}
+
+// DefaultServiceConfig provides a default configuration to create a new OTLP
+// publisher service by best effort.
+func DefaultServiceConfig() ServiceConfig {
+	clientConfig := DefaultClientConfig()
+
+	return ServiceConfig{
+		// Dependencies.
+		Storage: nil,
+
+		// Settings.
+		ConstLabels:        map[string]string{},
+		Endpoint:           clientConfig.Endpoint,
+		ErrorHandler:       metric.LogError("otlp"),
+		ExportInterval:     time.Minute,
+		Headers:            clientConfig.Headers,
+		PanicMode:          spec.PanicModeNone,
+		Prefixes:           []string{},
+		ResourceAttributes: map[string]string{},
+		SanitizeKeys:       false,
+		Timeout:            clientConfig.Timeout,
+	}
+}
+
+// NewService creates a new OTLP publisher service. Metrics are created by a
+// memory publisher service aggregating their values in the configured storage,
+// which is periodically exported to the OTLP endpoint.
+func NewService(config ServiceConfig) (*Service, error) {
+	// Dependencies.
+	newStorage := config.Storage
+	if newStorage == nil {
+		var err error
+		newStorage, err = storage.NewStorage(storage.DefaultStorageConfig())
+		if err != nil {
+			return nil, maskAny(err)
+		}
+	}
+
+	// Settings.
+	if config.ErrorHandler == nil {
+		return nil, maskAnyf(invalidConfigError, "error handler must not be empty")
+	}
+	if config.ExportInterval <= 0 {
+		return nil, maskAnyf(invalidConfigError, "export interval must be greater than 0")
+	}
+	if config.ResourceAttributes == nil {
+		return nil, maskAnyf(invalidConfigError, "resource attributes must not be empty")
+	}
+
+	var err error
+
+	var memoryService *memorypublisher.Service
+	{
+		memoryConfig := memorypublisher.DefaultServiceConfig()
+		memoryConfig.Storage = newStorage
+		memoryConfig.ConstLabels = config.ConstLabels
+		memoryConfig.PanicMode = config.PanicMode
+		memoryConfig.Prefixes = config.Prefixes
+		memoryConfig.SanitizeKeys = config.SanitizeKeys
+		memoryService, err = memorypublisher.NewService(memoryConfig)
+		if err != nil {
+			return nil, maskAny(err)
+		}
+	}
+
+	var newClient *Client
+	{
+		clientConfig := DefaultClientConfig()
+		clientConfig.Endpoint = config.Endpoint
+		clientConfig.Headers = config.Headers
+		clientConfig.Timeout = config.Timeout
+		newClient, err = NewClient(clientConfig)
+		if err != nil {
+			return nil, maskAny(err)
+		}
+	}
+
+	resourceAttributes := map[string]string{}
+	for k, v := range config.ResourceAttributes {
+		resourceAttributes[k] = v
+	}
+
+	newService := &Service{
+		// Dependencies.
+		Service: memoryService,
+		storage: newStorage,
+
+		// Internals.
+		client:       newClient,
+		closer:       make(chan struct{}, 1),
+		bootOnce:     sync.Once{},
+		done:         make(chan struct{}, 1),
+		shutdownOnce: sync.Once{},
+
+		// Settings.
+		errorHandler:       config.ErrorHandler,
+		exportInterval:     config.ExportInterval,
+		resourceAttributes: resourceAttributes,
+	}
+
+	return newService, nil
+}
+
+// Service creates metrics using the embedded memory publisher service and
+// exports their aggregated values to the OTLP endpoint.
+type Service struct {
+	// Dependencies.
+
+	// Service represents the memory publisher service creating all metrics.
+	*memorypublisher.Service
+	// storage represents the storage metric values are aggregated in.
+	storage *storage.Storage
+
+	// Internals.
+	client       *Client
+	closer       chan struct{}
+	bootOnce     sync.Once
+	done         chan struct{}
+	shutdownOnce sync.Once
+
+	// Settings.
+
+	// errorHandler is called with the error of the final export on Shutdown.
+	errorHandler func(err error)
+	// exportInterval represents the interval in which aggregated metrics are
+	// exported to the OTLP endpoint.
+	exportInterval time.Duration
+	// resourceAttributes represents the attributes of the resource all metrics
+	// are exported with.
+	resourceAttributes map[string]string
+}
+
+func (s *Service) Boot() {
+	s.bootOnce.Do(func() {
+		s.Service.Boot()
+
+		go func() {
+			ticker := time.NewTicker(s.exportInterval)
+			defer ticker.Stop()
+			defer close(s.done)
+
+			for {
+				select {
+				case <-s.closer:
+					return
+				case <-ticker.C:
+					// There is no way to report errors of the background export.
+					// Since all values are aggregated in the storage and exported
+					// cumulatively, the next successful export sends the current
+					// state again.
+					s.export()
+				}
+			}
+		}()
+	})
+}
+
+// export sends the current values of all metrics aggregated in the storage to
+// the OTLP endpoint.
+func (s *Service) export() error {
+	metrics := s.storage.Metrics()
+	if len(metrics) == 0 {
+		return nil
+	}
+
+	request := newExportRequest(metrics, s.resourceAttributes, uint64(time.Now().UnixNano()))
+	err := s.client.Export(request)
+	if err != nil {
+		return maskAny(err)
+	}
+
+	return nil
+}
+
+func (s *Service) Shutdown() {
+	s.shutdownOnce.Do(func() {
+		close(s.closer)
+		// The export loop is only running in case the service has been booted.
+		// The final export must not race with an export of the loop, so the loop
+		// is awaited first.
+		booted := true
+		s.bootOnce.Do(func() { booted = false })
+		if booted {
+			<-s.done
+		}
+		err := s.export()
+		if err != nil {
+			s.errorHandler(err)
+		}
+		s.Service.Shutdown()
+	})
+}
@@ -0,0 +1,258 @@
+package publisher
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	colmetricspb "go.opentelemetry.io/proto/otlp/collector/metrics/v1"
+	metricspb "go.opentelemetry.io/proto/otlp/metrics/v1"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/the-anna-project/instrumentor/spec"
+)
+
+// testReceiver is an OTLP/HTTP metrics receiver stub recording all export
+// requests it decoded.
+type testReceiver struct {
+	*httptest.Server
+
+	headers  []http.Header
+	mutex    sync.Mutex
+	requests []*colmetricspb.ExportMetricsServiceRequest
+}
+
+func newTestReceiver(t *testing.T, status int) *testReceiver {
+	tr := &testReceiver{}
+	tr.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+
+		request := &colmetricspb.ExportMetricsServiceRequest{}
+		err := proto.Unmarshal(b, request)
+		if err != nil {
+			t.Errorf("expected protobuf encoded export request, got %v", err)
+		}
+
+		tr.mutex.Lock()
+		tr.headers = append(tr.headers, r.Header)
+		tr.requests = append(tr.requests, request)
+		tr.mutex.Unlock()
+
+		w.WriteHeader(status)
+	}))
+
+	return tr
+}
+
+// metrics returns the metrics of the export request having the given index by
+// their names.
+func (tr *testReceiver) metrics(i int) map[string]*metricspb.Metric {
+	tr.mutex.Lock()
+	defer tr.mutex.Unlock()
+
+	metrics := map[string]*metricspb.Metric{}
+	for _, rm := range tr.requests[i].ResourceMetrics {
+		for _, sm := range rm.ScopeMetrics {
+			for _, m := range sm.Metrics {
+				metrics[m.Name] = m
+			}
+		}
+	}
+
+	return metrics
+}
+
+func TestService_Shutdown(t *testing.T) {
+	tr := newTestReceiver(t, http.StatusOK)
+	defer tr.Close()
+
+	config := DefaultServiceConfig()
+	config.Endpoint = tr.URL + "/v1/metrics"
+	config.ExportInterval = time.Hour
+	config.Headers = map[string]string{"Authorization": "Bearer secret"}
+	config.ResourceAttributes = map[string]string{"service.name": "api"}
+	s, err := NewService(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	counterConfig := s.CounterConfig()
+	counterConfig.SetHelp("Number of requests.")
+	counterConfig.SetLabels([]string{"method"})
+	counterConfig.SetName("requests_total")
+	c, err := s.Counter(counterConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = c.IncrementWithLabels(2, "GET")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, u := range []string{spec.UnitMilliseconds, spec.UnitSeconds} {
+		histogramConfig := s.HistogramConfig()
+		histogramConfig.SetBuckets([]float64{0.5, 1})
+		histogramConfig.SetHelp("Duration of requests.")
+		histogramConfig.SetName("duration")
+		histogramConfig.SetUnit(u)
+		h, err := s.Histogram(histogramConfig)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = h.Observe(0.5)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// The final export on Shutdown sends the current state, even though the
+	// export interval has not passed yet.
+	s.Shutdown()
+
+	if len(tr.requests) != 1 {
+		t.Fatalf("expected 1 export request, got %d", len(tr.requests))
+	}
+	if tr.headers[0].Get("Authorization") != "Bearer secret" {
+		t.Fatalf("expected configured header, got %q", tr.headers[0].Get("Authorization"))
+	}
+	if tr.headers[0].Get("Content-Type") != "application/x-protobuf" {
+		t.Fatalf("expected protobuf content type, got %q", tr.headers[0].Get("Content-Type"))
+	}
+	attributes := tr.requests[0].ResourceMetrics[0].Resource.Attributes
+	if len(attributes) != 1 || attributes[0].Key != "service.name" || attributes[0].Value.GetStringValue() != "api" {
+		t.Fatalf("expected resource attribute service.name=api, got %v", attributes)
+	}
+
+	metrics := tr.metrics(0)
+
+	sum := metrics["requests_total"].GetSum()
+	if sum == nil || !sum.IsMonotonic || len(sum.DataPoints) != 1 || sum.DataPoints[0].GetAsDouble() != 2 {
+		t.Fatalf("expected monotonic sum of 2, got %v", metrics["requests_total"])
+	}
+
+	// Histograms are named by their unit. The unit is exported using its UCUM
+	// symbol.
+	testCases := map[string]string{
+		"duration_milliseconds": "ms",
+		"duration_seconds":      "s",
+	}
+	for name, want := range testCases {
+		m, ok := metrics[name]
+		if !ok {
+			t.Fatalf("expected metric %s to be exported", name)
+		}
+		if m.Unit != want {
+			t.Fatalf("expected unit %q of metric %s, got %q", want, name, m.Unit)
+		}
+		dp := m.GetHistogram().DataPoints[0]
+		if dp.Count != 1 || dp.GetSum() != 0.5 {
+			t.Fatalf("expected count 1 and sum 0.5 of metric %s, got %v", name, dp)
+		}
+	}
+}
+
+func TestService_Export_StartTime(t *testing.T) {
+	tr := newTestReceiver(t, http.StatusOK)
+	defer tr.Close()
+
+	config := DefaultServiceConfig()
+	config.Endpoint = tr.URL + "/v1/metrics"
+	s, err := NewService(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Shutdown()
+
+	counterConfig := s.CounterConfig()
+	counterConfig.SetHelp("Number of requests.")
+	counterConfig.SetLabels([]string{"method"})
+	counterConfig.SetName("requests_total")
+	c, err := s.Counter(counterConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	startTime := func(i int) uint64 {
+		return tr.metrics(i)["requests_total"].GetSum().DataPoints[0].StartTimeUnixNano
+	}
+
+	err = c.IncrementWithLabels(1, "GET")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = s.export()
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = c.IncrementWithLabels(1, "GET")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = s.export()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The start time of a series does not change as long as it keeps
+	// accumulating values.
+	if startTime(0) == 0 || startTime(0) != startTime(1) {
+		t.Fatalf("expected constant start time, got %d and %d", startTime(0), startTime(1))
+	}
+
+	// Resetting the counter starts a new series, which the receiver must be able
+	// to tell apart from the previous one.
+	time.Sleep(time.Millisecond)
+	err = c.Reset()
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = c.IncrementWithLabels(1, "GET")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = s.export()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if startTime(2) <= startTime(1) {
+		t.Fatalf("expected later start time after reset, got %d and %d", startTime(1), startTime(2))
+	}
+}
+
+func TestService_Shutdown_ErrorHandler(t *testing.T) {
+	tr := newTestReceiver(t, http.StatusServiceUnavailable)
+	defer tr.Close()
+
+	var errs []error
+	config := DefaultServiceConfig()
+	config.Endpoint = tr.URL + "/v1/metrics"
+	config.ErrorHandler = func(err error) {
+		errs = append(errs, err)
+	}
+	s, err := NewService(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	gaugeConfig := s.GaugeConfig()
+	gaugeConfig.SetHelp("Number of workers.")
+	gaugeConfig.SetName("workers")
+	g, err := s.Gauge(gaugeConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = g.Set(3)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s.Shutdown()
+
+	if len(errs) != 1 || !IsExportFailed(errs[0]) {
+		t.Fatalf("expected export failed error of the final export to be handled, got %v", errs)
+	}
+}
@@ -4,22 +4,56 @@ package consumer
 
 import (
 	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+
+	"github.com/the-anna-project/instrumentor/spec"
 )
 
+// Labeler provides the ordered label names a metric has been configured with.
+// The returned boolean is false in case the metric is not known. Labeler is
+// implemented by the prometheus publisher service.
+type Labeler interface {
+	Labels(name string) ([]string, bool)
+}
+
 // ServiceConfig represents the configuration used to create a new prometheus
 // consumer service.
 type ServiceConfig struct {
+	// Dependencies.
+
+	// Gatherer is used to gather the current state of all registered metrics.
+	Gatherer prometheus.Gatherer
+	// Labeler is used to map label values given in the order of the configured
+	// labels to the label pairs of gathered metrics. Label pairs are gathered
+	// ordered by their names, which is the order label values have to be given
+	// in when Labeler is nil or does not know a metric.
+	Labeler Labeler
 }
 
 // DefaultServiceConfig provides a default configuration to create a new
 // prometheus consumer service by best effort.
 func DefaultServiceConfig() ServiceConfig {
-	return ServiceConfig{}
+	return ServiceConfig{
+		// Dependencies.
+		Gatherer: prometheus.DefaultGatherer,
+		Labeler:  nil,
+	}
 }
 
 // NewService creates a new prometheus consumer service.
 func NewService(config ServiceConfig) (*Service, error) {
+	// Dependencies.
+	if config.Gatherer == nil {
+		return nil, maskAnyf(invalidConfigError, "gatherer must not be empty")
+	}
+
 	newService := &Service{
+		// Dependencies.
+		gatherer: config.Gatherer,
+		labeler:  config.Labeler,
+
 		// Internals.
 		bootOnce:     sync.Once{},
 		closer:       make(chan struct{}, 1),
@@ -30,6 +64,10 @@ func NewService(config ServiceConfig) (*Service, error) {
 }
 
 type Service struct {
+	// Dependencies.
+	gatherer prometheus.Gatherer
+	labeler  Labeler
+
 	// Internals.
 	bootOnce     sync.Once
 	closer       chan struct{}
@@ -42,8 +80,206 @@ func (s *Service) Boot() {
 	})
 }
 
+func (s *Service) Counter(name string, values ...string) (float64, error) {
+	m, err := s.metric(dto.MetricType_COUNTER, name, values)
+	if err != nil {
+		return 0, maskAny(err)
+	}
+
+	return m.GetCounter().GetValue(), nil
+}
+
+func (s *Service) Gauge(name string, values ...string) (float64, error) {
+	m, err := s.metric(dto.MetricType_GAUGE, name, values)
+	if err != nil {
+		return 0, maskAny(err)
+	}
+
+	return m.GetGauge().GetValue(), nil
+}
+
+func (s *Service) Histogram(name string, values ...string) (spec.HistogramSample, error) {
+	m, err := s.metric(dto.MetricType_HISTOGRAM, name, values)
+	if err != nil {
+		return nil, maskAny(err)
+	}
+
+	buckets := map[float64]uint64{}
+	for _, b := range m.GetHistogram().GetBucket() {
+		buckets[b.GetUpperBound()] = b.GetCumulativeCount()
+	}
+
+	h := m.GetHistogram()
+	newSample := &HistogramSample{
+		buckets:             buckets,
+		count:               h.GetSampleCount(),
+		nativeNegative:      nativeBuckets(h.GetNegativeSpan(), h.GetNegativeDelta()),
+		nativePositive:      nativeBuckets(h.GetPositiveSpan(), h.GetPositiveDelta()),
+		nativeSchema:        h.GetSchema(),
+		nativeZeroCount:     h.GetZeroCount(),
+		nativeZeroThreshold: h.GetZeroThreshold(),
+		sum:                 h.GetSampleSum(),
+	}
+
+	return newSample, nil
+}
+
+func (s *Service) Names() ([]string, error) {
+	families, err := s.gatherer.Gather()
+	if err != nil {
+		return nil, maskAny(err)
+	}
+
+	var names []string
+	for _, f := range families {
+		names = append(names, f.GetName())
+	}
+
+	return names, nil
+}
+
 func (s *Service) Shutdown() {
 	s.shutdownOnce.Do(func() {
 		close(s.closer)
 	})
 }
+
+func (s *Service) Summary(name string, values ...string) (spec.SummarySample, error) {
+	m, err := s.metric(dto.MetricType_SUMMARY, name, values)
+	if err != nil {
+		return nil, maskAny(err)
+	}
+
+	quantiles := map[float64]float64{}
+	for _, q := range m.GetSummary().GetQuantile() {
+		quantiles[q.GetQuantile()] = q.GetValue()
+	}
+
+	newSample := &SummarySample{
+		count:     m.GetSummary().GetSampleCount(),
+		quantiles: quantiles,
+		sum:       m.GetSummary().GetSampleSum(),
+	}
+
+	return newSample, nil
+}
+
+// metric gathers all registered metrics and returns the one of the given type
+// registered under the given name, having the given label values.
+func (s *Service) metric(metricType dto.MetricType, name string, values []string) (*dto.Metric, error) {
+	families, err := s.gatherer.Gather()
+	if err != nil {
+		return nil, maskAny(err)
+	}
+
+	var family *dto.MetricFamily
+	for _, f := range families {
+		if f.GetName() == name {
+			family = f
+			break
+		}
+	}
+	if family == nil {
+		return nil, maskAnyf(notFoundError, "%s %s", typeName(metricType), name)
+	}
+	if family.GetType() != metricType {
+		return nil, maskAnyf(notFoundError, "%s %s (registered as %s)", typeName(metricType), name, typeName(family.GetType()))
+	}
+
+	var labels []string
+	var known bool
+	if s.labeler != nil {
+		labels, known = s.labeler.Labels(name)
+	}
+
+	for _, m := range family.GetMetric() {
+		if matchLabelValues(m.GetLabel(), labels, known, values) {
+			return m, nil
+		}
+	}
+
+	return nil, maskAnyf(notFoundError, "%s %s with label values %v", typeName(metricType), name, values)
+}
+
+// matchLabelValues checks whether the given label pairs hold the given values.
+// In case the label names are known, the values are expected to be in the
+// order of the given label names and label pairs of other names, e.g. of
+// constant labels, are ignored. Otherwise the values are expected to be in the
+// order of the label pairs.
+func matchLabelValues(pairs []*dto.LabelPair, labels []string, known bool, values []string) bool {
+	if !known {
+		if len(pairs) != len(values) {
+			return false
+		}
+		for i, p := range pairs {
+			if p.GetValue() != values[i] {
+				return false
+			}
+		}
+
+		return true
+	}
+
+	if len(labels) != len(values) {
+		return false
+	}
+	for i, l := range labels {
+		var found bool
+		for _, p := range pairs {
+			if p.GetName() == l && p.GetValue() == values[i] {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+
+	return true
+}
+
+func typeName(metricType dto.MetricType) string {
+	switch metricType {
+	case dto.MetricType_COUNTER:
+		return "counter"
+	case dto.MetricType_GAUGE:
+		return "gauge"
+	case dto.MetricType_HISTOGRAM:
+		return "histogram"
+	case dto.MetricType_SUMMARY:
+		return "summary"
+	default:
+		return "untyped metric"
+	}
+}
+
+// nativeBuckets decodes the given spans and deltas of a native histogram into
+// the sample counts of its populated buckets, keyed by the index of the bucket.
+// The offset of the first span is the index of its first bucket, the offsets of
+// all other spans are relative to the end of the previous span. The first delta
+// is the count of the first bucket, all other deltas are relative to the count
+// of the previous bucket.
+func nativeBuckets(spans []*dto.BucketSpan, deltas []int64) map[int]uint64 {
+	if len(spans) == 0 {
+		return nil
+	}
+
+	buckets := map[int]uint64{}
+	var count int64
+	var d int
+	var index int32
+	for _, span := range spans {
+		index += span.GetOffset()
+		for i := uint32(0); i < span.GetLength() && d < len(deltas); i++ {
+			count += deltas[d]
+			if count != 0 {
+				buckets[int(index)] = uint64(count)
+			}
+			d++
+			index++
+		}
+	}
+
+	return buckets
+}
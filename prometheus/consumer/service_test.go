@@ -0,0 +1,88 @@
+package consumer
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/the-anna-project/instrumentor/memory/storage"
+)
+
+func TestNativeBuckets(t *testing.T) {
+	// Spans skip buckets by their offsets. Deltas are relative to the previous
+	// bucket, so empty buckets within spans have a count of 0.
+	spans := []*dto.BucketSpan{
+		{Offset: proto.Int32(-2), Length: proto.Uint32(3)},
+		{Offset: proto.Int32(3), Length: proto.Uint32(1)},
+	}
+	deltas := []int64{1, -1, 3, 2}
+
+	buckets := nativeBuckets(spans, deltas)
+	if want := map[int]uint64{-2: 1, 0: 3, 4: 5}; !reflect.DeepEqual(buckets, want) {
+		t.Fatalf("expected buckets %v, got %v", want, buckets)
+	}
+
+	if buckets := nativeBuckets(nil, nil); buckets != nil {
+		t.Fatalf("expected no buckets without spans, got %v", buckets)
+	}
+}
+
+func TestNativeBuckets_MemoryStorage(t *testing.T) {
+	// The native histograms of the prometheus client library and the ones of
+	// the memory storage must agree on the schema and the buckets samples are
+	// counted in.
+	samples := []float64{-7.5, -0.2, 0, 0.001, 0.1, 0.5, 1, 1.05, 2, 3.7, 42, 1000}
+
+	for _, factor := range []float64{1.1, 2, 20} {
+		h := prometheus.NewHistogram(prometheus.HistogramOpts{
+			Help:                        "Observed samples.",
+			Name:                        "samples",
+			NativeHistogramBucketFactor: factor,
+		})
+
+		config := storage.DefaultMetricConfig()
+		config.Buckets = prometheus.DefBuckets
+		config.Kind = storage.KindHistogram
+		config.Name = "samples"
+		config.NativeBucketFactor = factor
+		m, err := storage.NewMetric(config)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for _, sample := range samples {
+			h.Observe(sample)
+			err := m.Observe(sample)
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		var metric dto.Metric
+		err = h.Write(&metric)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ph := metric.GetHistogram()
+		s, err := m.Series()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if ph.GetSchema() != s.NativeSchema {
+			t.Fatalf("factor %v: expected schema %d, got %d", factor, ph.GetSchema(), s.NativeSchema)
+		}
+		if ph.GetZeroCount() != s.NativeZeroCount {
+			t.Fatalf("factor %v: expected zero count %d, got %d", factor, ph.GetZeroCount(), s.NativeZeroCount)
+		}
+		if want := nativeBuckets(ph.GetPositiveSpan(), ph.GetPositiveDelta()); !reflect.DeepEqual(s.NativePositiveBuckets, want) {
+			t.Fatalf("factor %v: expected positive buckets %v, got %v", factor, want, s.NativePositiveBuckets)
+		}
+		if want := nativeBuckets(ph.GetNegativeSpan(), ph.GetNegativeDelta()); !reflect.DeepEqual(s.NativeNegativeBuckets, want) {
+			t.Fatalf("factor %v: expected negative buckets %v, got %v", factor, want, s.NativeNegativeBuckets)
+		}
+	}
+}
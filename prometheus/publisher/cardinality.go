@@ -0,0 +1,113 @@
+package publisher
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	// CardinalityModeDrop causes label combinations exceeding the cardinality
+	// limits to be dropped.
+	CardinalityModeDrop = "drop"
+	// CardinalityModeOverflow causes label combinations exceeding the
+	// cardinality limits to be tracked in the overflow series of the metric.
+	CardinalityModeOverflow = "overflow"
+)
+
+// OverflowLabelValue is the value of all labels of the overflow series, which
+// tracks the label combinations exceeding the cardinality limits in case the
+// cardinality mode is CardinalityModeOverflow.
+const OverflowLabelValue = "__overflow__"
+
+// cardinalityExceededName is the name of the counter tracking how often the
+// cardinality limits have been exceeded, partitioned by the metric.
+const cardinalityExceededName = "instrumentor_cardinality_limit_exceeded_total"
+
+// labelValueSeparator separates label values when joining them to the key of a
+// series. It is a byte that is not expected in label values.
+const labelValueSeparator = "\xff"
+
+// cardinalityLimiter keeps track of the series of the labelled metrics of a
+// service and limits their number. All methods are safe for concurrent use.
+type cardinalityLimiter struct {
+	// Dependencies.
+	exceeded *prometheus.CounterVec
+
+	// Internals.
+	mutex  sync.Mutex
+	series map[string]map[string]struct{}
+	total  int
+
+	// Settings.
+	maxSeries          int
+	maxSeriesPerMetric int
+	mode               string
+}
+
+// admit checks whether the series of the given metric identified by the given
+// label values may be used. Series already being known are always admitted.
+// New series are only admitted and remembered as long as neither the limit of
+// the metric nor the limit of the service is reached. Otherwise the exceeded
+// counter is incremented.
+func (l *cardinalityLimiter) admit(name string, values []string) bool {
+	key := strings.Join(values, labelValueSeparator)
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	series, ok := l.series[name]
+	if !ok {
+		series = map[string]struct{}{}
+		l.series[name] = series
+	}
+	if _, ok := series[key]; ok {
+		return true
+	}
+
+	if (l.maxSeriesPerMetric > 0 && len(series) >= l.maxSeriesPerMetric) || (l.maxSeries > 0 && l.total >= l.maxSeries) {
+		l.exceeded.WithLabelValues(name).Inc()
+		return false
+	}
+
+	series[key] = struct{}{}
+	l.total++
+
+	return true
+}
+
+// forget forgets the series of the given metric identified by the given label
+// values, e.g. because it has been deleted. The series is admitted again in
+// case it is used later on.
+func (l *cardinalityLimiter) forget(name string, values []string) {
+	key := strings.Join(values, labelValueSeparator)
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	if _, ok := l.series[name][key]; ok {
+		delete(l.series[name], key)
+		l.total--
+	}
+}
+
+// forgetAll forgets all series of the given metric.
+func (l *cardinalityLimiter) forgetAll(name string) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	l.total -= len(l.series[name])
+	delete(l.series, name)
+}
+
+// overflowLabelValues returns the label values of the overflow series of a
+// metric having the given number of labels.
+func overflowLabelValues(n int) []string {
+	values := make([]string, n)
+	for i := range values {
+		values[i] = OverflowLabelValue
+	}
+
+	return values
+}
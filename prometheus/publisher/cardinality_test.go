@@ -0,0 +1,191 @@
+package publisher
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/the-anna-project/instrumentor/spec"
+)
+
+// newLimitedCounters creates a service limiting its series to 3 in total and
+// to 2 per metric, as well as the counters a_total and b_total labelled by id.
+func newLimitedCounters(t *testing.T, mode string) (map[string]spec.Counter, func() map[string]float64) {
+	config := DefaultServiceConfig()
+	config.CardinalityMode = mode
+	config.MaxSeries = 3
+	config.MaxSeriesPerMetric = 2
+	s, registry := newTestService(t, config)
+
+	counters := map[string]spec.Counter{}
+	for _, name := range []string{"a_total", "b_total"} {
+		config := s.CounterConfig()
+		config.SetHelp("Number of requests.")
+		config.SetLabels([]string{"id"})
+		config.SetName(name)
+		c, err := s.Counter(config)
+		if err != nil {
+			t.Fatal(err)
+		}
+		counters[name] = c
+	}
+
+	return counters, func() map[string]float64 { return gathered(t, registry) }
+}
+
+func TestService_Cardinality(t *testing.T) {
+	testCases := []struct {
+		Mode string
+		Want map[string]float64
+	}{
+		{
+			Mode: CardinalityModeDrop,
+			Want: map[string]float64{
+				"a_total{0}": 2,
+				"a_total{1}": 1,
+				"b_total{0}": 1,
+				"instrumentor_cardinality_limit_exceeded_total{a_total}": 1,
+				"instrumentor_cardinality_limit_exceeded_total{b_total}": 1,
+			},
+		},
+		{
+			Mode: CardinalityModeOverflow,
+			Want: map[string]float64{
+				"a_total{0}":            2,
+				"a_total{1}":            1,
+				"a_total{__overflow__}": 1,
+				"b_total{0}":            1,
+				"b_total{__overflow__}": 1,
+				"instrumentor_cardinality_limit_exceeded_total{a_total}": 1,
+				"instrumentor_cardinality_limit_exceeded_total{b_total}": 1,
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		counters, gathered := newLimitedCounters(t, tc.Mode)
+
+		// The third series of a_total exceeds the limit per metric. Series
+		// already being known are admitted nevertheless. The second series of
+		// b_total exceeds the limit of the service.
+		increments := []struct {
+			Name string
+			ID   string
+		}{
+			{Name: "a_total", ID: "0"},
+			{Name: "a_total", ID: "1"},
+			{Name: "a_total", ID: "2"},
+			{Name: "a_total", ID: "0"},
+			{Name: "b_total", ID: "0"},
+			{Name: "b_total", ID: "1"},
+		}
+		for _, i := range increments {
+			err := counters[i.Name].IncrementWithLabels(1, i.ID)
+			if err != nil {
+				t.Fatalf("%s: %v", tc.Mode, err)
+			}
+		}
+
+		if got := gathered(); !reflect.DeepEqual(got, tc.Want) {
+			t.Fatalf("%s: expected %v, got %v", tc.Mode, tc.Want, got)
+		}
+	}
+}
+
+func TestService_Cardinality_DeletedSeries(t *testing.T) {
+	testCases := []struct {
+		Name   string
+		Delete func(c spec.Counter) error
+	}{
+		{
+			Name:   "DeleteLabelValues",
+			Delete: func(c spec.Counter) error { return c.DeleteLabelValues("1") },
+		},
+		{
+			Name:   "Reset",
+			Delete: func(c spec.Counter) error { return c.Reset() },
+		},
+	}
+
+	for _, tc := range testCases {
+		counters, gathered := newLimitedCounters(t, CardinalityModeDrop)
+		c := counters["a_total"]
+
+		for _, id := range []string{"0", "1", "2"} {
+			err := c.IncrementWithLabels(1, id)
+			if err != nil {
+				t.Fatalf("%s: %v", tc.Name, err)
+			}
+		}
+		if _, ok := gathered()["a_total{2}"]; ok {
+			t.Fatalf("%s: expected series exceeding the limit to be dropped", tc.Name)
+		}
+
+		// Deleted series are forgotten by the limiter, so new series are admitted
+		// again.
+		err := tc.Delete(c)
+		if err != nil {
+			t.Fatalf("%s: %v", tc.Name, err)
+		}
+		err = c.IncrementWithLabels(1, "2")
+		if err != nil {
+			t.Fatalf("%s: %v", tc.Name, err)
+		}
+		if v := gathered()["a_total{2}"]; v != 1 {
+			t.Fatalf("%s: expected admitted series having value 1, got %v", tc.Name, v)
+		}
+	}
+}
+
+func TestService_Cardinality_With(t *testing.T) {
+	counters, gathered := newLimitedCounters(t, CardinalityModeOverflow)
+
+	// Counters having all label values bound are limited like any other use of
+	// the counter, even though their child is cached.
+	for _, id := range []string{"0", "1", "2", "2"} {
+		bound, err := counters["a_total"].With(id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = bound.Increment(1)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	want := map[string]float64{
+		"a_total{0}":            1,
+		"a_total{1}":            1,
+		"a_total{__overflow__}": 2,
+		"instrumentor_cardinality_limit_exceeded_total{a_total}": 2,
+	}
+	if got := gathered(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+}
+
+func TestNewService_Cardinality(t *testing.T) {
+	testCases := []struct {
+		Name   string
+		Config func(c ServiceConfig) ServiceConfig
+	}{
+		{
+			Name:   "unknown mode",
+			Config: func(c ServiceConfig) ServiceConfig { c.CardinalityMode = "sample"; return c },
+		},
+		{
+			Name:   "negative max series",
+			Config: func(c ServiceConfig) ServiceConfig { c.MaxSeries = -1; return c },
+		},
+		{
+			Name:   "negative max series per metric",
+			Config: func(c ServiceConfig) ServiceConfig { c.MaxSeriesPerMetric = -1; return c },
+		},
+	}
+
+	for _, tc := range testCases {
+		_, err := NewService(tc.Config(DefaultServiceConfig()))
+		if !IsInvalidConfig(err) {
+			t.Fatalf("%s: expected invalid config error, got %v", tc.Name, err)
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package publisher
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// seriesGeneration counts how often series of a labelled metric have been
+// deleted, either explicitly or by an expiry sweep. It is shared by the metric
+// and all metrics created from it using With. All methods are safe for
+// concurrent use.
+type seriesGeneration struct {
+	n uint64
+}
+
+// bump records that series have been deleted.
+func (g *seriesGeneration) bump() {
+	atomic.AddUint64(&g.n, 1)
+}
+
+// load returns the current generation.
+func (g *seriesGeneration) load() uint64 {
+	return atomic.LoadUint64(&g.n)
+}
+
+// childCache caches the child resolved for the series of a metric having all
+// label values bound, so writing to it does not resolve the series again. The
+// cached child is only used as long as the generation of the metric's series
+// did not change, since the series might have been deleted in the meantime.
+// All methods are safe for concurrent use.
+type childCache struct {
+	// Internals.
+	child      interface{}
+	generation uint64
+	mutex      sync.Mutex
+}
+
+// get returns the cached child in case it has been resolved in the given
+// generation.
+func (c *childCache) get(generation uint64) (interface{}, bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if c.child == nil || c.generation != generation {
+		return nil, false
+	}
+
+	return c.child, true
+}
+
+// set caches the given child resolved in the given generation.
+func (c *childCache) set(generation uint64, child interface{}) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.child = child
+	c.generation = generation
+}
+
+// newChildCache returns a new childCache in case values for all of the given
+// labels are given, otherwise nil.
+func newChildCache(labels []string, values []string) *childCache {
+	if len(values) != len(labels) {
+		return nil
+	}
+
+	return &childCache{}
+}
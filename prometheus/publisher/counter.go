@@ -1,8 +1,11 @@
 package publisher
 
 import (
+	"time"
+
 	"github.com/prometheus/client_golang/prometheus"
 
+	"github.com/the-anna-project/instrumentor/internal/metric"
 	"github.com/the-anna-project/instrumentor/spec"
 )
 
@@ -21,6 +24,10 @@ type CounterConfig struct {
 	// scope of prometheus publisher  this is expected to be an underscored
 	// string.
 	name string
+	// ttl represents the duration after which a series of the metric is deleted
+	// in case it has not been touched in the meantime. Series never expire in
+	// case it is 0.
+	ttl time.Duration
 }
 
 func (cc *CounterConfig) Help() string {
@@ -47,6 +54,17 @@ func (cc *CounterConfig) SetName(name string) {
 	cc.name = name
 }
 
+func (cc *CounterConfig) SetTTL(ttl time.Duration) {
+	cc.ttl = ttl
+}
+
+// TTL returns the duration after which series of the counter not being written
+// expire. It is not part of spec.CounterConfig, since only the prometheus
+// publisher supports it.
+func (cc *CounterConfig) TTL() time.Duration {
+	return cc.ttl
+}
+
 // DefaultCounterConfig provides a default configuration to create a new
 // prometheus publisher counter object by best effort.
 func DefaultCounterConfig() *CounterConfig {
@@ -55,6 +73,7 @@ func DefaultCounterConfig() *CounterConfig {
 		help:   "",
 		labels: nil,
 		name:   "",
+		ttl:    0,
 	}
 
 	return newConfig
@@ -62,37 +81,64 @@ func DefaultCounterConfig() *CounterConfig {
 
 // NewCounter creates a new configured prometheus publisher counter object.
 func NewCounter(config spec.CounterConfig) (*Counter, error) {
+	return newCounterWithConstLabels(config, nil)
+}
+
+// newCounterWithConstLabels creates a new configured prometheus publisher
+// counter object having the given constant labels.
+func newCounterWithConstLabels(config spec.CounterConfig, constLabels map[string]string) (*Counter, error) {
 	// Settings.
-	if config.Help() == "" {
-		return nil, maskAnyf(invalidConfigError, "help must not be empty")
-	}
 	if config.Name() == "" {
 		return nil, maskAnyf(invalidConfigError, "name must not be empty")
 	}
+	err := metric.ValidateName(config.Name())
+	if err != nil {
+		return nil, maskAny(err)
+	}
+	err = metric.ValidateLabels(config.Labels(), constLabels)
+	if err != nil {
+		return nil, maskAny(err)
+	}
+	if configTTL(config) < 0 {
+		return nil, maskAnyf(invalidConfigError, "TTL must not be negative")
+	}
 
 	var clientCounter prometheus.Counter
 	var clientCounterVec *prometheus.CounterVec
+	var expiry *seriesExpiry
 
 	if len(config.Labels()) == 0 {
 		clientCounter = prometheus.NewCounter(
 			prometheus.CounterOpts{
-				Help: config.Help(),
-				Name: config.Name(),
+				ConstLabels: constLabels,
+				Help:        config.Help(),
+				Name:        config.Name(),
 			},
 		)
 	} else {
 		clientCounterVec = prometheus.NewCounterVec(
 			prometheus.CounterOpts{
-				Help: config.Help(),
-				Name: config.Name(),
+				ConstLabels: constLabels,
+				Help:        config.Help(),
+				Name:        config.Name(),
 			},
 			config.Labels(),
 		)
+		expiry = newSeriesExpiry(configTTL(config))
 	}
 
 	newCounter := &Counter{
 		ClientCounter:    clientCounter,
 		ClientCounterVec: clientCounterVec,
+
+		// Internals.
+		expiry:     expiry,
+		generation: &seriesGeneration{},
+		rootVec:    clientCounterVec,
+
+		// Settings.
+		labels: config.Labels(),
+		name:   config.Name(),
 	}
 
 	return newCounter, nil
@@ -102,22 +148,82 @@ type Counter struct {
 	// Public.
 	ClientCounter    prometheus.Counter
 	ClientCounterVec *prometheus.CounterVec
+
+	// Internals.
+	boundValues []string
+	cache       *childCache
+	expiry      *seriesExpiry
+	generation  *seriesGeneration
+	limiter     *cardinalityLimiter
+	rootVec     *prometheus.CounterVec
+
+	// Settings.
+	labels []string
+	name   string
+}
+
+// Collector returns the prometheus collector backing the counter, which is the
+// ClientCounterVec in case the counter has been configured with labels.
+func (c *Counter) Collector() prometheus.Collector {
+	if c.ClientCounterVec != nil {
+		return c.ClientCounterVec
+	}
+
+	return c.ClientCounter
+}
+
+// DeleteLabelValues deletes the series of the given label values, if any.
+func (c *Counter) DeleteLabelValues(values ...string) error {
+	if len(c.labels) == 0 {
+		// This error indicates that the counter has not been configured with
+		// labels or all of its label values are already bound.
+		return maskAnyf(invalidConfigError, "counter must be configured")
+	}
+	if len(values) != len(c.labels) {
+		return maskAnyf(invalidConfigError, "%d label values must be given", len(c.labels))
+	}
+
+	c.ClientCounterVec.DeleteLabelValues(values...)
+	forgetSeries(c.limiter, c.expiry, c.name, metric.BindLabelValues(c.boundValues, values))
+	c.generation.bump()
+
+	return nil
 }
 
 func (c *Counter) Increment(delta float64) error {
-	if c.ClientCounter == nil {
+	if len(c.labels) != 0 {
 		// This error indicates that the counter has been configured with labels.
 		// Therefore Counter.ObserveWithLabels must be used.
 		return maskAnyf(invalidConfigError, "counter must be configured")
 	}
 
-	c.ClientCounter.Add(delta)
+	child, err := c.child(nil)
+	if err != nil {
+		return maskAny(err)
+	}
+	if child != nil {
+		child.Add(delta)
+	}
+
+	return nil
+}
+
+func (c *Counter) IncrementWithLabelMap(delta float64, labels map[string]string) error {
+	values, err := metric.LabelValues(c.labels, labels)
+	if err != nil {
+		return maskAny(err)
+	}
+
+	err = c.IncrementWithLabels(delta, values...)
+	if err != nil {
+		return maskAny(err)
+	}
 
 	return nil
 }
 
 func (c *Counter) IncrementWithLabels(delta float64, values ...string) error {
-	if c.ClientCounterVec == nil {
+	if len(c.labels) == 0 {
 		// This error indicates that the counter has not been configured with
 		// labels. Therefore Counter.Observe must be used.
 		return maskAnyf(invalidConfigError, "counter must be configured")
@@ -126,7 +232,148 @@ func (c *Counter) IncrementWithLabels(delta float64, values ...string) error {
 		return maskAnyf(invalidConfigError, "labels must not be empty")
 	}
 
-	c.ClientCounterVec.WithLabelValues(values...).Add(delta)
+	child, err := c.child(values)
+	if err != nil {
+		return maskAny(err)
+	}
+	if child != nil {
+		child.Add(delta)
+	}
+
+	return nil
+}
+
+// Reset deletes all series of the counter.
+func (c *Counter) Reset() error {
+	if c.rootVec == nil || len(c.boundValues) != 0 {
+		// This error indicates that the counter has not been configured with
+		// labels or has label values bound.
+		return maskAnyf(invalidConfigError, "counter must be configured")
+	}
+
+	c.rootVec.Reset()
+	if c.limiter != nil {
+		c.limiter.forgetAll(c.name)
+	}
+	if c.expiry != nil {
+		c.expiry.reset()
+	}
+	c.generation.bump()
 
 	return nil
 }
+
+// With returns a counter having the given label values bound to the first
+// labels of the counter. The returned counter holds the ClientCounterVec
+// curried with the given label values. In case values for all labels are given,
+// the returned counter caches the child of its series, which makes it cheap to
+// use in hot paths. The child is resolved again once series of the counter have
+// been deleted, so the returned counter keeps working afterwards.
+func (c *Counter) With(values ...string) (spec.Counter, error) {
+	if len(c.labels) == 0 {
+		// This error indicates that the counter has not been configured with
+		// labels or all of its label values are already bound.
+		return nil, maskAnyf(invalidConfigError, "counter must be configured")
+	}
+	if len(values) == 0 {
+		return nil, maskAnyf(invalidConfigError, "labels must not be empty")
+	}
+	if len(values) > len(c.labels) {
+		return nil, maskAnyf(invalidConfigError, "at most %d label values must be given", len(c.labels))
+	}
+
+	curried := prometheus.Labels{}
+	for i, v := range values {
+		curried[c.labels[i]] = v
+	}
+	clientCounterVec, err := c.ClientCounterVec.CurryWith(curried)
+	if err != nil {
+		return nil, maskAny(err)
+	}
+
+	newCounter := &Counter{
+		ClientCounterVec: clientCounterVec,
+
+		// Internals.
+		boundValues: metric.BindLabelValues(c.boundValues, values),
+		cache:       newChildCache(c.labels, values),
+		expiry:      c.expiry,
+		generation:  c.generation,
+		limiter:     c.limiter,
+		rootVec:     c.rootVec,
+
+		// Settings.
+		labels: c.labels[len(values):],
+		name:   c.name,
+	}
+
+	return newCounter, nil
+}
+
+// child returns the child tracking the series of the given label values, which
+// is the ClientCounter in case it is set. In case the cardinality limits are
+// exceeded, the child tracking the overflow series is returned, or nil in case
+// the series is dropped.
+func (c *Counter) child(values []string) (prometheus.Counter, error) {
+	if c.ClientCounter != nil {
+		return c.ClientCounter, nil
+	}
+
+	// The generation is read before resolving the child, so that the child is
+	// resolved again in case its series is deleted concurrently. Series being
+	// cached have been admitted by the limiter, which only forgets them when
+	// they are deleted.
+	generation := c.generation.load()
+	if c.cache != nil {
+		child, ok := c.cache.get(generation)
+		if ok {
+			if c.expiry != nil {
+				c.expiry.touch(c.boundValues)
+			}
+
+			return child.(prometheus.Counter), nil
+		}
+	}
+
+	if c.limiter != nil && !c.limiter.admit(c.name, metric.BindLabelValues(c.boundValues, values)) {
+		if c.limiter.mode == CardinalityModeDrop {
+			return nil, nil
+		}
+
+		child, err := c.rootVec.GetMetricWithLabelValues(overflowLabelValues(len(c.boundValues) + len(values))...)
+		if err != nil {
+			return nil, maskAny(err)
+		}
+
+		return child, nil
+	}
+	if c.expiry != nil {
+		c.expiry.touch(metric.BindLabelValues(c.boundValues, values))
+	}
+
+	child, err := c.ClientCounterVec.GetMetricWithLabelValues(values...)
+	if err != nil {
+		return nil, maskAny(err)
+	}
+	if c.cache != nil {
+		c.cache.set(generation, child)
+	}
+
+	return child, nil
+}
+
+// sweep deletes the series of the counter not touched within its TTL before the
+// given time.
+func (c *Counter) sweep(now time.Time) {
+	if c.expiry == nil {
+		return
+	}
+
+	c.expiry.sweep(now, func(values []string) {
+		c.rootVec.DeleteLabelValues(values...)
+		if c.limiter != nil {
+			c.limiter.forget(c.name, values)
+		}
+		c.generation.bump()
+	})
+}
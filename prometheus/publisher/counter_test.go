@@ -0,0 +1,120 @@
+package publisher
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCounter_With(t *testing.T) {
+	s, registry := newTestService(t, DefaultServiceConfig())
+
+	config := s.CounterConfig()
+	config.SetHelp("Number of requests.")
+	config.SetLabels([]string{"method", "code"})
+	config.SetName("requests_total")
+	c, err := s.Counter(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	partial, err := c.With("GET")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = partial.IncrementWithLabels(1, "200")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	bound, err := c.With("GET", "500")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2; i++ {
+		err = bound.Increment(1)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Only counters having all label values bound cache their child.
+	if partial.(*Counter).cache != nil {
+		t.Fatal("expected partially bound counter not to cache its child")
+	}
+	if _, ok := bound.(*Counter).cache.get(c.(*Counter).generation.load()); !ok {
+		t.Fatal("expected bound counter to cache its child")
+	}
+
+	values := gathered(t, registry)
+	if values["requests_total{200,GET}"] != 1 || values["requests_total{500,GET}"] != 2 {
+		t.Fatalf("expected values of both series, got %v", values)
+	}
+}
+
+func TestCounter_With_DeletedSeries(t *testing.T) {
+	testCases := []struct {
+		Name   string
+		Delete func(c *Counter) error
+	}{
+		{
+			Name: "DeleteLabelValues",
+			Delete: func(c *Counter) error {
+				return c.DeleteLabelValues("GET")
+			},
+		},
+		{
+			Name: "Reset",
+			Delete: func(c *Counter) error {
+				return c.Reset()
+			},
+		},
+		{
+			Name: "sweep",
+			Delete: func(c *Counter) error {
+				c.sweep(time.Now().Add(time.Hour))
+				return nil
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		serviceConfig := DefaultServiceConfig()
+		serviceConfig.SeriesTTL = time.Minute
+		s, registry := newTestService(t, serviceConfig)
+
+		config := s.CounterConfig()
+		config.SetHelp("Number of requests.")
+		config.SetLabels([]string{"method"})
+		config.SetName("requests_total")
+		c, err := s.Counter(config)
+		if err != nil {
+			t.Fatal(err)
+		}
+		bound, err := c.With("GET")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		err = bound.Increment(1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = tc.Delete(c.(*Counter))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, ok := gathered(t, registry)["requests_total{GET}"]; ok {
+			t.Fatalf("%s: expected series to be deleted", tc.Name)
+		}
+
+		// The bound counter resolves the deleted series again instead of
+		// incrementing the cached child not being collected anymore.
+		err = bound.Increment(1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if v := gathered(t, registry)["requests_total{GET}"]; v != 1 {
+			t.Fatalf("%s: expected restarted series having value 1, got %v", tc.Name, v)
+		}
+	}
+}
@@ -0,0 +1,123 @@
+package publisher
+
+import (
+	"strings"
+	"sync"
+	"time"
+)
+
+// ttlConfig is implemented by the metric configs of the prometheus publisher.
+// It allows to read the TTL of a metric from its spec config.
+type ttlConfig interface {
+	TTL() time.Duration
+}
+
+// configTTL returns the TTL of the given metric config, which is 0 in case the
+// config does not provide one.
+func configTTL(config interface{}) time.Duration {
+	c, ok := config.(ttlConfig)
+	if !ok {
+		return 0
+	}
+
+	return c.TTL()
+}
+
+// expiringSeries represents a series tracked by seriesExpiry.
+type expiringSeries struct {
+	touched time.Time
+	values  []string
+}
+
+// newSeriesExpiry creates a new seriesExpiry for the given TTL. It returns nil
+// in case the TTL is 0, since series do not expire then.
+func newSeriesExpiry(ttl time.Duration) *seriesExpiry {
+	if ttl <= 0 {
+		return nil
+	}
+
+	newExpiry := &seriesExpiry{
+		// Internals.
+		mutex:  sync.Mutex{},
+		series: map[string]*expiringSeries{},
+
+		// Settings.
+		ttl: ttl,
+	}
+
+	return newExpiry
+}
+
+// seriesExpiry keeps track of the time the series of a labelled metric have
+// been touched the last time. All methods are safe for concurrent use.
+type seriesExpiry struct {
+	// Internals.
+	mutex  sync.Mutex
+	series map[string]*expiringSeries
+
+	// Settings.
+	ttl time.Duration
+}
+
+// delete stops tracking the series identified by the given label values.
+func (e *seriesExpiry) delete(values []string) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	delete(e.series, strings.Join(values, labelValueSeparator))
+}
+
+// reset stops tracking all series.
+func (e *seriesExpiry) reset() {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	e.series = map[string]*expiringSeries{}
+}
+
+// sweep calls the given delete function with the label values of all series not
+// touched within the TTL before the given time and stops tracking them. The
+// delete function is called while holding the mutex, so that a series being
+// touched concurrently is not deleted.
+func (e *seriesExpiry) sweep(now time.Time, deleteFunc func(values []string)) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	for k, s := range e.series {
+		if now.Sub(s.touched) < e.ttl {
+			continue
+		}
+
+		deleteFunc(s.values)
+		delete(e.series, k)
+	}
+}
+
+// touch remembers the current time as the time the series identified by the
+// given label values has been touched the last time.
+func (e *seriesExpiry) touch(values []string) {
+	key := strings.Join(values, labelValueSeparator)
+
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	s, ok := e.series[key]
+	if !ok {
+		s = &expiringSeries{
+			values: append([]string(nil), values...),
+		}
+		e.series[key] = s
+	}
+	s.touched = time.Now()
+}
+
+// forgetSeries removes the series of the given metric identified by the given
+// label values from the given limiter and expiry, if any.
+func forgetSeries(limiter *cardinalityLimiter, expiry *seriesExpiry, name string, values []string) {
+	if limiter != nil {
+		limiter.forget(name, values)
+	}
+	if expiry != nil {
+		expiry.delete(values)
+	}
+}
@@ -0,0 +1,221 @@
+package publisher
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestSeriesExpiry(t *testing.T) {
+	if e := newSeriesExpiry(0); e != nil {
+		t.Fatalf("expected no expiry for TTL 0, got %v", e)
+	}
+
+	e := newSeriesExpiry(time.Minute)
+	e.touch([]string{"GET", "200"})
+	e.touch([]string{"POST", "500"})
+	e.touch([]string{"PUT", "201"})
+	e.delete([]string{"PUT", "201"})
+	touched := time.Now()
+
+	testCases := []struct {
+		Name  string
+		Now   time.Time
+		Swept []string
+	}{
+		{
+			Name:  "within TTL",
+			Now:   touched.Add(59 * time.Second),
+			Swept: nil,
+		},
+		{
+			// Deleted series are not tracked anymore, so they are not swept.
+			Name:  "exceeding TTL",
+			Now:   touched.Add(time.Minute),
+			Swept: []string{"GET,200", "POST,500"},
+		},
+		{
+			// Swept series are not tracked anymore, so they are swept only once.
+			Name:  "swept before",
+			Now:   touched.Add(time.Hour),
+			Swept: nil,
+		},
+	}
+
+	for _, tc := range testCases {
+		var swept []string
+		e.sweep(tc.Now, func(values []string) {
+			swept = append(swept, strings.Join(values, ","))
+		})
+		sort.Strings(swept)
+
+		if !reflect.DeepEqual(swept, tc.Swept) {
+			t.Fatalf("%s: expected swept series %v, got %v", tc.Name, tc.Swept, swept)
+		}
+	}
+
+	// Swept series being touched again are tracked again.
+	e.touch([]string{"GET", "200"})
+	var swept []string
+	e.sweep(time.Now().Add(time.Hour), func(values []string) {
+		swept = append(swept, strings.Join(values, ","))
+	})
+	if want := []string{"GET,200"}; !reflect.DeepEqual(swept, want) {
+		t.Fatalf("expected swept series %v, got %v", want, swept)
+	}
+
+	e.touch([]string{"GET", "200"})
+	e.reset()
+	e.sweep(time.Now().Add(time.Hour), func(values []string) {
+		t.Fatalf("expected no series after reset, got %v", values)
+	})
+}
+
+// seriesNames returns the names of the metrics gathered from the given
+// registry, each one listed once per series.
+func seriesNames(t *testing.T, registry *prometheus.Registry) []string {
+	families, err := registry.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var names []string
+	for _, f := range families {
+		for range f.GetMetric() {
+			names = append(names, f.GetName())
+		}
+	}
+
+	return names
+}
+
+func TestService_Sweep(t *testing.T) {
+	config := DefaultServiceConfig()
+	config.SeriesTTL = time.Minute
+	s, registry := newTestService(t, config)
+
+	counterConfig := s.CounterConfig()
+	counterConfig.SetHelp("Number of requests.")
+	counterConfig.SetLabels([]string{"method"})
+	counterConfig.SetName("requests_total")
+	c, err := s.Counter(counterConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	gaugeConfig := s.GaugeConfig()
+	gaugeConfig.SetHelp("Number of connections.")
+	gaugeConfig.SetLabels([]string{"method"})
+	gaugeConfig.SetName("connections")
+	gaugeConfig.(*GaugeConfig).SetTTL(2 * time.Hour)
+	g, err := s.Gauge(gaugeConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	histogramConfig := s.HistogramConfig()
+	histogramConfig.SetHelp("Duration of requests.")
+	histogramConfig.SetLabels([]string{"method"})
+	histogramConfig.SetName("duration")
+	h, err := s.Histogram(histogramConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	summaryConfig := s.SummaryConfig()
+	summaryConfig.SetHelp("Size of responses.")
+	summaryConfig.SetLabels([]string{"method"})
+	summaryConfig.SetName("size")
+	summaryConfig.(*SummaryConfig).SetTTL(0)
+	m, err := s.Summary(summaryConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, method := range []string{"GET", "POST"} {
+		err := c.IncrementWithLabels(1, method)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = g.SetWithLabels(1, method)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = h.ObserveWithLabels(1, method)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = m.ObserveWithLabels(1, method)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	testCases := []struct {
+		Name  string
+		Now   time.Time
+		Names []string
+	}{
+		{
+			Name:  "within TTL",
+			Now:   time.Now().Add(30 * time.Second),
+			Names: []string{"connections", "connections", "duration", "duration", "requests_total", "requests_total", "size", "size"},
+		},
+		{
+			// The gauge has a TTL of its own. The summary does not expire at all,
+			// since its TTL is 0.
+			Name:  "exceeding the TTL of the service",
+			Now:   time.Now().Add(time.Hour),
+			Names: []string{"connections", "connections", "size", "size"},
+		},
+		{
+			Name:  "exceeding the TTL of the gauge",
+			Now:   time.Now().Add(3 * time.Hour),
+			Names: []string{"size", "size"},
+		},
+	}
+
+	for _, tc := range testCases {
+		s.sweep(tc.Now)
+		if names := seriesNames(t, registry); !reflect.DeepEqual(names, tc.Names) {
+			t.Fatalf("%s: expected series %v, got %v", tc.Name, tc.Names, names)
+		}
+	}
+}
+
+func TestService_Boot_Sweep(t *testing.T) {
+	config := DefaultServiceConfig()
+	config.SeriesTTL = time.Millisecond
+	config.SweepInterval = 10 * time.Millisecond
+	s, registry := newTestService(t, config)
+
+	// The service is booted before the first labelled metric having a TTL is
+	// registered, so sweeping starts later on.
+	s.Boot()
+	defer s.Shutdown()
+
+	counterConfig := s.CounterConfig()
+	counterConfig.SetHelp("Number of requests.")
+	counterConfig.SetLabels([]string{"method"})
+	counterConfig.SetName("requests_total")
+	c, err := s.Counter(counterConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = c.IncrementWithLabels(1, "GET")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for len(seriesNames(t, registry)) != 0 {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected series to be swept, got %v", seriesNames(t, registry))
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -1,8 +1,11 @@
 package publisher
 
 import (
+	"time"
+
 	"github.com/prometheus/client_golang/prometheus"
 
+	"github.com/the-anna-project/instrumentor/internal/metric"
 	"github.com/the-anna-project/instrumentor/spec"
 )
 
@@ -20,6 +23,10 @@ type GaugeConfig struct {
 	// name represents the metric's key as it is supposed to be registered. In the
 	// scope of prometheus this is expected to be an underscored string.
 	name string
+	// ttl represents the duration after which a series of the metric is deleted
+	// in case it has not been touched in the meantime. Series never expire in
+	// case it is 0.
+	ttl time.Duration
 }
 
 func (gc *GaugeConfig) Help() string {
@@ -46,6 +53,17 @@ func (gc *GaugeConfig) SetName(name string) {
 	gc.name = name
 }
 
+func (gc *GaugeConfig) SetTTL(ttl time.Duration) {
+	gc.ttl = ttl
+}
+
+// TTL returns the duration after which series of the gauge not being written
+// expire. It is not part of spec.GaugeConfig, since only the prometheus
+// publisher supports it.
+func (gc *GaugeConfig) TTL() time.Duration {
+	return gc.ttl
+}
+
 // DefaultGaugeConfig provides a default configuration to create a new
 // prometheus publisher gauge by best effort.
 func DefaultGaugeConfig() *GaugeConfig {
@@ -54,42 +72,70 @@ func DefaultGaugeConfig() *GaugeConfig {
 		help:   "",
 		labels: nil,
 		name:   "",
+		ttl:    0,
 	}
 }
 
 // NewGauge creates a new configured prometheus publisher gauge.
 func NewGauge(config spec.GaugeConfig) (*Gauge, error) {
+	return newGaugeWithConstLabels(config, nil)
+}
+
+// newGaugeWithConstLabels creates a new configured prometheus publisher gauge
+// having the given constant labels.
+func newGaugeWithConstLabels(config spec.GaugeConfig, constLabels map[string]string) (*Gauge, error) {
 	// Settings.
-	if config.Help() == "" {
-		return nil, maskAnyf(invalidConfigError, "help must not be empty")
-	}
 	if config.Name() == "" {
 		return nil, maskAnyf(invalidConfigError, "name must not be empty")
 	}
+	err := metric.ValidateName(config.Name())
+	if err != nil {
+		return nil, maskAny(err)
+	}
+	err = metric.ValidateLabels(config.Labels(), constLabels)
+	if err != nil {
+		return nil, maskAny(err)
+	}
+	if configTTL(config) < 0 {
+		return nil, maskAnyf(invalidConfigError, "TTL must not be negative")
+	}
 
 	var clientGauge prometheus.Gauge
 	var clientGaugeVec *prometheus.GaugeVec
+	var expiry *seriesExpiry
 
 	if len(config.Labels()) == 0 {
 		clientGauge = prometheus.NewGauge(
 			prometheus.GaugeOpts{
-				Help: config.Help(),
-				Name: config.Name(),
+				ConstLabels: constLabels,
+				Help:        config.Help(),
+				Name:        config.Name(),
 			},
 		)
 	} else {
 		clientGaugeVec = prometheus.NewGaugeVec(
 			prometheus.GaugeOpts{
-				Help: config.Help(),
-				Name: config.Name(),
+				ConstLabels: constLabels,
+				Help:        config.Help(),
+				Name:        config.Name(),
 			},
 			config.Labels(),
 		)
+		expiry = newSeriesExpiry(configTTL(config))
 	}
 
 	newGauge := &Gauge{
 		ClientGauge:    clientGauge,
 		ClientGaugeVec: clientGaugeVec,
+
+		// Internals.
+		expiry:     expiry,
+		generation: &seriesGeneration{},
+		rootVec:    clientGaugeVec,
+
+		// Settings.
+		labels: config.Labels(),
+		name:   config.Name(),
 	}
 
 	return newGauge, nil
@@ -99,22 +145,64 @@ type Gauge struct {
 	// Public.
 	ClientGauge    prometheus.Gauge
 	ClientGaugeVec *prometheus.GaugeVec
+
+	// Internals.
+	boundValues []string
+	cache       *childCache
+	expiry      *seriesExpiry
+	generation  *seriesGeneration
+	limiter     *cardinalityLimiter
+	rootVec     *prometheus.GaugeVec
+
+	// Settings.
+	labels []string
+	name   string
+}
+
+// Collector returns the prometheus collector backing the gauge, which is the
+// ClientGaugeVec in case the gauge has been configured with labels.
+func (g *Gauge) Collector() prometheus.Collector {
+	if g.ClientGaugeVec != nil {
+		return g.ClientGaugeVec
+	}
+
+	return g.ClientGauge
 }
 
 func (g *Gauge) Decrement(delta float64) error {
-	if g.ClientGauge == nil {
+	if len(g.labels) != 0 {
 		// This error indicates that the gauge has been configured with labels.
 		// Therefore Gauge.ObserveWithLabels must be used.
 		return maskAnyf(invalidConfigError, "gauge must be configured")
 	}
 
-	g.ClientGauge.Sub(delta)
+	child, err := g.child(nil)
+	if err != nil {
+		return maskAny(err)
+	}
+	if child != nil {
+		child.Sub(delta)
+	}
+
+	return nil
+}
+
+func (g *Gauge) DecrementWithLabelMap(delta float64, labels map[string]string) error {
+	values, err := metric.LabelValues(g.labels, labels)
+	if err != nil {
+		return maskAny(err)
+	}
+
+	err = g.DecrementWithLabels(delta, values...)
+	if err != nil {
+		return maskAny(err)
+	}
 
 	return nil
 }
 
 func (g *Gauge) DecrementWithLabels(delta float64, values ...string) error {
-	if g.ClientGaugeVec == nil {
+	if len(g.labels) == 0 {
 		// This error indicates that the gauge has not been configured with labels.
 		// Therefore Gauge.Observe must be used.
 		return maskAnyf(invalidConfigError, "gauge must be configured")
@@ -123,25 +211,69 @@ func (g *Gauge) DecrementWithLabels(delta float64, values ...string) error {
 		return maskAnyf(invalidConfigError, "labels must not be empty")
 	}
 
-	g.ClientGaugeVec.WithLabelValues(values...).Sub(delta)
+	child, err := g.child(values)
+	if err != nil {
+		return maskAny(err)
+	}
+	if child != nil {
+		child.Sub(delta)
+	}
+
+	return nil
+}
+
+// DeleteLabelValues deletes the series of the given label values, if any.
+func (g *Gauge) DeleteLabelValues(values ...string) error {
+	if len(g.labels) == 0 {
+		// This error indicates that the gauge has not been configured with
+		// labels or all of its label values are already bound.
+		return maskAnyf(invalidConfigError, "gauge must be configured")
+	}
+	if len(values) != len(g.labels) {
+		return maskAnyf(invalidConfigError, "%d label values must be given", len(g.labels))
+	}
+
+	g.ClientGaugeVec.DeleteLabelValues(values...)
+	forgetSeries(g.limiter, g.expiry, g.name, metric.BindLabelValues(g.boundValues, values))
+	g.generation.bump()
 
 	return nil
 }
 
 func (g *Gauge) Increment(delta float64) error {
-	if g.ClientGauge == nil {
+	if len(g.labels) != 0 {
 		// This error indicates that the gauge has been configured with labels.
 		// Therefore Gauge.ObserveWithLabels must be used.
 		return maskAnyf(invalidConfigError, "gauge must be configured")
 	}
 
-	g.ClientGauge.Add(delta)
+	child, err := g.child(nil)
+	if err != nil {
+		return maskAny(err)
+	}
+	if child != nil {
+		child.Add(delta)
+	}
+
+	return nil
+}
+
+func (g *Gauge) IncrementWithLabelMap(delta float64, labels map[string]string) error {
+	values, err := metric.LabelValues(g.labels, labels)
+	if err != nil {
+		return maskAny(err)
+	}
+
+	err = g.IncrementWithLabels(delta, values...)
+	if err != nil {
+		return maskAny(err)
+	}
 
 	return nil
 }
 
 func (g *Gauge) IncrementWithLabels(delta float64, values ...string) error {
-	if g.ClientGaugeVec == nil {
+	if len(g.labels) == 0 {
 		// This error indicates that the gauge has not been configured with labels.
 		// Therefore Gauge.Observe must be used.
 		return maskAnyf(invalidConfigError, "gauge must be configured")
@@ -150,25 +282,51 @@ func (g *Gauge) IncrementWithLabels(delta float64, values ...string) error {
 		return maskAnyf(invalidConfigError, "labels must not be empty")
 	}
 
-	g.ClientGaugeVec.WithLabelValues(values...).Add(delta)
+	child, err := g.child(values)
+	if err != nil {
+		return maskAny(err)
+	}
+	if child != nil {
+		child.Add(delta)
+	}
 
 	return nil
 }
 
 func (g *Gauge) Set(value float64) error {
-	if g.ClientGauge == nil {
+	if len(g.labels) != 0 {
 		// This error indicates that the gauge has been configured with labels.
 		// Therefore Gauge.ObserveWithLabels must be used.
 		return maskAnyf(invalidConfigError, "gauge must be configured")
 	}
 
-	g.ClientGauge.Set(value)
+	child, err := g.child(nil)
+	if err != nil {
+		return maskAny(err)
+	}
+	if child != nil {
+		child.Set(value)
+	}
+
+	return nil
+}
+
+func (g *Gauge) SetWithLabelMap(value float64, labels map[string]string) error {
+	values, err := metric.LabelValues(g.labels, labels)
+	if err != nil {
+		return maskAny(err)
+	}
+
+	err = g.SetWithLabels(value, values...)
+	if err != nil {
+		return maskAny(err)
+	}
 
 	return nil
 }
 
 func (g *Gauge) SetWithLabels(value float64, values ...string) error {
-	if g.ClientGaugeVec == nil {
+	if len(g.labels) == 0 {
 		// This error indicates that the gauge has not been configured with labels.
 		// Therefore Gauge.Observe must be used.
 		return maskAnyf(invalidConfigError, "gauge must be configured")
@@ -177,7 +335,148 @@ func (g *Gauge) SetWithLabels(value float64, values ...string) error {
 		return maskAnyf(invalidConfigError, "labels must not be empty")
 	}
 
-	g.ClientGaugeVec.WithLabelValues(values...).Set(value)
+	child, err := g.child(values)
+	if err != nil {
+		return maskAny(err)
+	}
+	if child != nil {
+		child.Set(value)
+	}
 
 	return nil
 }
+
+// Reset deletes all series of the gauge.
+func (g *Gauge) Reset() error {
+	if g.rootVec == nil || len(g.boundValues) != 0 {
+		// This error indicates that the gauge has not been configured with
+		// labels or has label values bound.
+		return maskAnyf(invalidConfigError, "gauge must be configured")
+	}
+
+	g.rootVec.Reset()
+	if g.limiter != nil {
+		g.limiter.forgetAll(g.name)
+	}
+	if g.expiry != nil {
+		g.expiry.reset()
+	}
+	g.generation.bump()
+
+	return nil
+}
+
+// With returns a gauge having the given label values bound to the first labels
+// of the gauge. The returned gauge holds the ClientGaugeVec curried with the
+// given label values. In case values for all labels are given, the returned
+// gauge caches the child of its series, which makes it cheap to use in hot
+// paths. The child is resolved again once series of the gauge have been
+// deleted, so the returned gauge keeps working afterwards.
+func (g *Gauge) With(values ...string) (spec.Gauge, error) {
+	if len(g.labels) == 0 {
+		// This error indicates that the gauge has not been configured with
+		// labels or all of its label values are already bound.
+		return nil, maskAnyf(invalidConfigError, "gauge must be configured")
+	}
+	if len(values) == 0 {
+		return nil, maskAnyf(invalidConfigError, "labels must not be empty")
+	}
+	if len(values) > len(g.labels) {
+		return nil, maskAnyf(invalidConfigError, "at most %d label values must be given", len(g.labels))
+	}
+
+	curried := prometheus.Labels{}
+	for i, v := range values {
+		curried[g.labels[i]] = v
+	}
+	clientGaugeVec, err := g.ClientGaugeVec.CurryWith(curried)
+	if err != nil {
+		return nil, maskAny(err)
+	}
+
+	newGauge := &Gauge{
+		ClientGaugeVec: clientGaugeVec,
+
+		// Internals.
+		boundValues: metric.BindLabelValues(g.boundValues, values),
+		cache:       newChildCache(g.labels, values),
+		expiry:      g.expiry,
+		generation:  g.generation,
+		limiter:     g.limiter,
+		rootVec:     g.rootVec,
+
+		// Settings.
+		labels: g.labels[len(values):],
+		name:   g.name,
+	}
+
+	return newGauge, nil
+}
+
+// child returns the child tracking the series of the given label values, which
+// is the ClientGauge in case it is set. In case the cardinality limits are
+// exceeded, the child tracking the overflow series is returned, or nil in case
+// the series is dropped.
+func (g *Gauge) child(values []string) (prometheus.Gauge, error) {
+	if g.ClientGauge != nil {
+		return g.ClientGauge, nil
+	}
+
+	// The generation is read before resolving the child, so that the child is
+	// resolved again in case its series is deleted concurrently. Series being
+	// cached have been admitted by the limiter, which only forgets them when
+	// they are deleted.
+	generation := g.generation.load()
+	if g.cache != nil {
+		child, ok := g.cache.get(generation)
+		if ok {
+			if g.expiry != nil {
+				g.expiry.touch(g.boundValues)
+			}
+
+			return child.(prometheus.Gauge), nil
+		}
+	}
+
+	if g.limiter != nil && !g.limiter.admit(g.name, metric.BindLabelValues(g.boundValues, values)) {
+		if g.limiter.mode == CardinalityModeDrop {
+			return nil, nil
+		}
+
+		child, err := g.rootVec.GetMetricWithLabelValues(overflowLabelValues(len(g.boundValues) + len(values))...)
+		if err != nil {
+			return nil, maskAny(err)
+		}
+
+		return child, nil
+	}
+	if g.expiry != nil {
+		g.expiry.touch(metric.BindLabelValues(g.boundValues, values))
+	}
+
+	child, err := g.ClientGaugeVec.GetMetricWithLabelValues(values...)
+	if err != nil {
+		return nil, maskAny(err)
+	}
+	if g.cache != nil {
+		g.cache.set(generation, child)
+	}
+
+	return child, nil
+}
+
+// sweep deletes the series of the gauge not touched within its TTL before the
+// given time.
+func (g *Gauge) sweep(now time.Time) {
+	if g.expiry == nil {
+		return
+	}
+
+	g.expiry.sweep(now, func(values []string) {
+		g.rootVec.DeleteLabelValues(values...)
+		if g.limiter != nil {
+			g.limiter.forget(g.name, values)
+		}
+		g.generation.bump()
+	})
+}
@@ -1,8 +1,12 @@
 package publisher
 
 import (
+	"time"
+
 	"github.com/prometheus/client_golang/prometheus"
 
+	"github.com/the-anna-project/instrumentor/bucket"
+	"github.com/the-anna-project/instrumentor/internal/metric"
 	"github.com/the-anna-project/instrumentor/spec"
 )
 
@@ -11,11 +15,12 @@ import (
 type HistogramConfig struct {
 	// Settings.
 
-	// buckets represents a list of time ranges in seconds. Observed samples are
-	// put into their corresponding ranges.
+	// buckets represents a list of time ranges. Observed samples are put into
+	// their corresponding ranges.
 	//
-	// A bucket's unit MUST be second. The buckets list MUST be ordered
-	// incrementally.
+	// A bucket's unit MUST be the unit of the histogram, which is second in
+	// case the histogram has no unit. The buckets list MUST be ordered
+	// incrementally. The bucket package provides generators and presets.
 	//
 	// The buckets need to be properly configured to match the use case of the
 	// oberseved samples, otherwise the histogram becomes pretty useless. E.g.
@@ -30,6 +35,23 @@ type HistogramConfig struct {
 	// name represents the metric's key as it is supposed to be registered. In the
 	// scope of prometheus publisher this is expected to be an underscored string.
 	name string
+	// nativeBucketFactor represents the growth factor of the buckets of the
+	// native histogram. Native histograms are disabled in case it is 0.
+	nativeBucketFactor float64
+	// nativeMaxBucketNumber represents the maximum number of buckets of a series
+	// of the native histogram. There is no limit in case it is 0.
+	nativeMaxBucketNumber uint32
+	// nativeZeroThreshold represents the width of the zero bucket of the native
+	// histogram. The default of the prometheus client library is used in case
+	// it is 0.
+	nativeZeroThreshold float64
+	// ttl represents the duration after which a series of the metric is deleted
+	// in case it has not been touched in the meantime. Series never expire in
+	// case it is 0.
+	ttl time.Duration
+	// unit represents the unit of the observed durations, which is appended to
+	// the name of the histogram. The buckets are given in the same unit.
+	unit string
 }
 
 func (hc *HistogramConfig) Buckets() []float64 {
@@ -48,6 +70,18 @@ func (hc *HistogramConfig) Name() string {
 	return hc.name
 }
 
+func (hc *HistogramConfig) NativeBucketFactor() float64 {
+	return hc.nativeBucketFactor
+}
+
+func (hc *HistogramConfig) NativeMaxBucketNumber() uint32 {
+	return hc.nativeMaxBucketNumber
+}
+
+func (hc *HistogramConfig) NativeZeroThreshold() float64 {
+	return hc.nativeZeroThreshold
+}
+
 func (hc *HistogramConfig) SetBuckets(buckets []float64) {
 	hc.buckets = buckets
 }
@@ -64,20 +98,62 @@ func (hc *HistogramConfig) SetName(name string) {
 	hc.name = name
 }
 
+func (hc *HistogramConfig) SetNativeBucketFactor(factor float64) {
+	hc.nativeBucketFactor = factor
+}
+
+func (hc *HistogramConfig) SetNativeMaxBucketNumber(number uint32) {
+	hc.nativeMaxBucketNumber = number
+}
+
+func (hc *HistogramConfig) SetNativeZeroThreshold(threshold float64) {
+	hc.nativeZeroThreshold = threshold
+}
+
+func (hc *HistogramConfig) SetTTL(ttl time.Duration) {
+	hc.ttl = ttl
+}
+
+func (hc *HistogramConfig) SetUnit(unit string) {
+	hc.unit = unit
+}
+
+// TTL returns the duration after which series of the histogram not being
+// written expire. It is not part of spec.HistogramConfig, since only the
+// prometheus publisher supports it.
+func (hc *HistogramConfig) TTL() time.Duration {
+	return hc.ttl
+}
+
+func (hc *HistogramConfig) Unit() string {
+	return hc.unit
+}
+
 // DefaultHistogramConfig provides a default configuration to create a new
 // prometheus publisher histogram by best effort.
 func DefaultHistogramConfig() *HistogramConfig {
 	return &HistogramConfig{
 		// Settings.
-		buckets: []float64{.001, .002, .003, .004, .005, .01, .02, .03, .04, .05, .1, .2, .3, .4, .5, 1, 2, 3, 4, 5, 10},
-		help:    "",
-		labels:  nil,
-		name:    "",
+		buckets:               []float64{.001, .002, .003, .004, .005, .01, .02, .03, .04, .05, .1, .2, .3, .4, .5, 1, 2, 3, 4, 5, 10},
+		help:                  "",
+		labels:                nil,
+		name:                  "",
+		nativeBucketFactor:    0,
+		nativeMaxBucketNumber: 0,
+		nativeZeroThreshold:   0,
+		ttl:                   0,
+		unit:                  "",
 	}
 }
 
 // NewHistogram creates a new configured prometheus publisher histogram.
 func NewHistogram(config spec.HistogramConfig) (*Histogram, error) {
+	return newHistogramWithConstLabels(config, nil)
+}
+
+// newHistogramWithConstLabels creates a new configured prometheus publisher
+// histogram having the given constant labels.
+func newHistogramWithConstLabels(config spec.HistogramConfig, constLabels map[string]string) (*Histogram, error) {
 	// Settings.
 	if config.Buckets() == nil {
 		return nil, maskAnyf(invalidConfigError, "buckets must not be empty")
@@ -85,38 +161,81 @@ func NewHistogram(config spec.HistogramConfig) (*Histogram, error) {
 	if len(config.Buckets()) < 1 {
 		return nil, maskAnyf(invalidConfigError, "buckets must contain at least 1 value")
 	}
-	if config.Help() == "" {
-		return nil, maskAnyf(invalidConfigError, "help must not be empty")
+	err := bucket.Validate(config.Buckets())
+	if err != nil {
+		return nil, maskAny(err)
+	}
+	if config.NativeBucketFactor() != 0 && !(config.NativeBucketFactor() > 1) {
+		return nil, maskAnyf(invalidConfigError, "native bucket factor must be 0 or greater than 1")
+	}
+	if !(config.NativeZeroThreshold() >= 0) {
+		return nil, maskAnyf(invalidConfigError, "native zero threshold must not be negative")
 	}
 	if config.Name() == "" {
 		return nil, maskAnyf(invalidConfigError, "name must not be empty")
 	}
+	err = metric.ValidateName(config.Name())
+	if err != nil {
+		return nil, maskAny(err)
+	}
+	err = metric.ValidateLabels(config.Labels(), constLabels, metric.BucketLabel)
+	if err != nil {
+		return nil, maskAny(err)
+	}
+	if configTTL(config) < 0 {
+		return nil, maskAnyf(invalidConfigError, "TTL must not be negative")
+	}
+	if !metric.ValidUnit(config.Unit()) {
+		return nil, maskAnyf(invalidConfigError, "unit must be one of: %s, %s", spec.UnitMilliseconds, spec.UnitSeconds)
+	}
+
+	name := metric.UnitName(config.Name(), config.Unit())
 
 	var clientHistogram prometheus.Histogram
 	var clientHistogramVec *prometheus.HistogramVec
+	var expiry *seriesExpiry
 
 	if len(config.Labels()) == 0 {
 		clientHistogram = prometheus.NewHistogram(
 			prometheus.HistogramOpts{
-				Buckets: config.Buckets(),
-				Help:    config.Help(),
-				Name:    config.Name(),
+				Buckets:                        config.Buckets(),
+				ConstLabels:                    constLabels,
+				Help:                           config.Help(),
+				Name:                           name,
+				NativeHistogramBucketFactor:    config.NativeBucketFactor(),
+				NativeHistogramMaxBucketNumber: config.NativeMaxBucketNumber(),
+				NativeHistogramZeroThreshold:   config.NativeZeroThreshold(),
 			},
 		)
 	} else {
 		clientHistogramVec = prometheus.NewHistogramVec(
 			prometheus.HistogramOpts{
-				Buckets: config.Buckets(),
-				Help:    config.Help(),
-				Name:    config.Name(),
+				Buckets:                        config.Buckets(),
+				ConstLabels:                    constLabels,
+				Help:                           config.Help(),
+				Name:                           name,
+				NativeHistogramBucketFactor:    config.NativeBucketFactor(),
+				NativeHistogramMaxBucketNumber: config.NativeMaxBucketNumber(),
+				NativeHistogramZeroThreshold:   config.NativeZeroThreshold(),
 			},
 			config.Labels(),
 		)
+		expiry = newSeriesExpiry(configTTL(config))
 	}
 
 	newHistogram := &Histogram{
 		ClientHistogram:    clientHistogram,
 		ClientHistogramVec: clientHistogramVec,
+
+		// Internals.
+		expiry:     expiry,
+		generation: &seriesGeneration{},
+		rootVec:    clientHistogramVec,
+
+		// Settings.
+		labels: config.Labels(),
+		name:   name,
+		unit:   config.Unit(),
 	}
 
 	return newHistogram, nil
@@ -126,22 +245,83 @@ type Histogram struct {
 	// Public.
 	ClientHistogram    prometheus.Histogram
 	ClientHistogramVec *prometheus.HistogramVec
+
+	// Internals.
+	boundValues []string
+	cache       *childCache
+	expiry      *seriesExpiry
+	generation  *seriesGeneration
+	limiter     *cardinalityLimiter
+	rootVec     *prometheus.HistogramVec
+
+	// Settings.
+	labels []string
+	name   string
+	unit   string
+}
+
+// Collector returns the prometheus collector backing the histogram, which is
+// the ClientHistogramVec in case the histogram has been configured with labels.
+func (h *Histogram) Collector() prometheus.Collector {
+	if h.ClientHistogramVec != nil {
+		return h.ClientHistogramVec
+	}
+
+	return h.ClientHistogram
+}
+
+// DeleteLabelValues deletes the series of the given label values, if any.
+func (h *Histogram) DeleteLabelValues(values ...string) error {
+	if len(h.labels) == 0 {
+		// This error indicates that the histogram has not been configured with
+		// labels or all of its label values are already bound.
+		return maskAnyf(invalidConfigError, "histogram must be configured")
+	}
+	if len(values) != len(h.labels) {
+		return maskAnyf(invalidConfigError, "%d label values must be given", len(h.labels))
+	}
+
+	h.ClientHistogramVec.DeleteLabelValues(values...)
+	forgetSeries(h.limiter, h.expiry, h.name, metric.BindLabelValues(h.boundValues, values))
+	h.generation.bump()
+
+	return nil
 }
 
 func (h *Histogram) Observe(sample float64) error {
-	if h.ClientHistogram == nil {
+	if len(h.labels) != 0 {
 		// This error indicates that the histogram has been configured with labels.
 		// Therefore Histogram.ObserveWithLabels must be used.
 		return maskAnyf(invalidConfigError, "histogram must be configured")
 	}
 
-	h.ClientHistogram.Observe(sample)
+	child, err := h.child(nil)
+	if err != nil {
+		return maskAny(err)
+	}
+	if child != nil {
+		child.Observe(sample)
+	}
+
+	return nil
+}
+
+func (h *Histogram) ObserveWithLabelMap(sample float64, labels map[string]string) error {
+	values, err := metric.LabelValues(h.labels, labels)
+	if err != nil {
+		return maskAny(err)
+	}
+
+	err = h.ObserveWithLabels(sample, values...)
+	if err != nil {
+		return maskAny(err)
+	}
 
 	return nil
 }
 
 func (h *Histogram) ObserveWithLabels(sample float64, values ...string) error {
-	if h.ClientHistogramVec == nil {
+	if len(h.labels) == 0 {
 		// This error indicates that the histogram has not been configured with
 		// labels. Therefore Histogram.Observe must be used.
 		return maskAnyf(invalidConfigError, "histogram must be configured")
@@ -150,7 +330,159 @@ func (h *Histogram) ObserveWithLabels(sample float64, values ...string) error {
 		return maskAnyf(invalidConfigError, "labels must not be empty")
 	}
 
-	h.ClientHistogramVec.WithLabelValues(values...).Observe(sample)
+	child, err := h.child(values)
+	if err != nil {
+		return maskAny(err)
+	}
+	if child != nil {
+		child.Observe(sample)
+	}
+
+	return nil
+}
+
+// Reset deletes all series of the histogram.
+func (h *Histogram) Reset() error {
+	if h.rootVec == nil || len(h.boundValues) != 0 {
+		// This error indicates that the histogram has not been configured with
+		// labels or has label values bound.
+		return maskAnyf(invalidConfigError, "histogram must be configured")
+	}
+
+	h.rootVec.Reset()
+	if h.limiter != nil {
+		h.limiter.forgetAll(h.name)
+	}
+	if h.expiry != nil {
+		h.expiry.reset()
+	}
+	h.generation.bump()
 
 	return nil
 }
+
+func (h *Histogram) StartTimer() spec.Timer {
+	return metric.NewTimer(h.unit, h.Observe)
+}
+
+func (h *Histogram) StartTimerWithLabels(values ...string) spec.Timer {
+	return metric.NewTimer(h.unit, func(sample float64) error {
+		return h.ObserveWithLabels(sample, values...)
+	})
+}
+
+// With returns a histogram having the given label values bound to the first
+// labels of the histogram. The returned histogram holds the ClientHistogramVec
+// curried with the given label values. In case values for all labels are given,
+// the returned histogram caches the child of its series, which makes it cheap
+// to use in hot paths. The child is resolved again once series of the histogram
+// have been deleted, so the returned histogram keeps working afterwards.
+func (h *Histogram) With(values ...string) (spec.Histogram, error) {
+	if len(h.labels) == 0 {
+		// This error indicates that the histogram has not been configured with
+		// labels or all of its label values are already bound.
+		return nil, maskAnyf(invalidConfigError, "histogram must be configured")
+	}
+	if len(values) == 0 {
+		return nil, maskAnyf(invalidConfigError, "labels must not be empty")
+	}
+	if len(values) > len(h.labels) {
+		return nil, maskAnyf(invalidConfigError, "at most %d label values must be given", len(h.labels))
+	}
+
+	curried := prometheus.Labels{}
+	for i, v := range values {
+		curried[h.labels[i]] = v
+	}
+	clientHistogramVec, err := h.ClientHistogramVec.CurryWith(curried)
+	if err != nil {
+		return nil, maskAny(err)
+	}
+
+	newHistogram := &Histogram{
+		ClientHistogramVec: clientHistogramVec.(*prometheus.HistogramVec),
+
+		// Internals.
+		boundValues: metric.BindLabelValues(h.boundValues, values),
+		cache:       newChildCache(h.labels, values),
+		expiry:      h.expiry,
+		generation:  h.generation,
+		limiter:     h.limiter,
+		rootVec:     h.rootVec,
+
+		// Settings.
+		labels: h.labels[len(values):],
+		name:   h.name,
+		unit:   h.unit,
+	}
+
+	return newHistogram, nil
+}
+
+// child returns the child tracking the series of the given label values, which
+// is the ClientHistogram in case it is set. In case the cardinality limits are
+// exceeded, the child tracking the overflow series is returned, or nil in case
+// the series is dropped.
+func (h *Histogram) child(values []string) (prometheus.Observer, error) {
+	if h.ClientHistogram != nil {
+		return h.ClientHistogram, nil
+	}
+
+	// The generation is read before resolving the child, so that the child is
+	// resolved again in case its series is deleted concurrently. Series being
+	// cached have been admitted by the limiter, which only forgets them when
+	// they are deleted.
+	generation := h.generation.load()
+	if h.cache != nil {
+		child, ok := h.cache.get(generation)
+		if ok {
+			if h.expiry != nil {
+				h.expiry.touch(h.boundValues)
+			}
+
+			return child.(prometheus.Observer), nil
+		}
+	}
+
+	if h.limiter != nil && !h.limiter.admit(h.name, metric.BindLabelValues(h.boundValues, values)) {
+		if h.limiter.mode == CardinalityModeDrop {
+			return nil, nil
+		}
+
+		child, err := h.rootVec.GetMetricWithLabelValues(overflowLabelValues(len(h.boundValues) + len(values))...)
+		if err != nil {
+			return nil, maskAny(err)
+		}
+
+		return child, nil
+	}
+	if h.expiry != nil {
+		h.expiry.touch(metric.BindLabelValues(h.boundValues, values))
+	}
+
+	child, err := h.ClientHistogramVec.GetMetricWithLabelValues(values...)
+	if err != nil {
+		return nil, maskAny(err)
+	}
+	if h.cache != nil {
+		h.cache.set(generation, child)
+	}
+
+	return child, nil
+}
+
+// sweep deletes the series of the histogram not touched within its TTL before
+// the given time.
+func (h *Histogram) sweep(now time.Time) {
+	if h.expiry == nil {
+		return
+	}
+
+	h.expiry.sweep(now, func(values []string) {
+		h.rootVec.DeleteLabelValues(values...)
+		if h.limiter != nil {
+			h.limiter.forget(h.name, values)
+		}
+		h.generation.bump()
+	})
+}
@@ -0,0 +1,35 @@
+package publisher
+
+const (
+	// PushMethodPost is the push method merging the pushed metrics into the
+	// metrics already held by the Pushgateway for the same grouping key. Only
+	// metrics having the same names as the pushed ones are replaced.
+	PushMethodPost = "POST"
+	// PushMethodPut is the push method replacing all metrics held by the
+	// Pushgateway for the same grouping key with the pushed ones.
+	PushMethodPut = "PUT"
+)
+
+// Push pushes all metrics of the configured gatherer to the configured
+// Pushgateway once. It is called periodically after Boot and once more on
+// Shutdown, which pass push errors to the configured ErrorHandler. It can also
+// be called explicitly in order to handle push errors directly, e.g. before a
+// batch job exits. An error asserted by IsInvalidConfig is returned in case no
+// push URL is configured.
+func (s *Service) Push() error {
+	if s.pusher == nil {
+		return maskAnyf(invalidConfigError, "push URL must not be empty")
+	}
+
+	var err error
+	if s.pushMethod == PushMethodPost {
+		err = s.pusher.Add()
+	} else {
+		err = s.pusher.Push()
+	}
+	if err != nil {
+		return maskAny(err)
+	}
+
+	return nil
+}
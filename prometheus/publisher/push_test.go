@@ -0,0 +1,177 @@
+package publisher
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// pushRequest represents a request received by the fake Pushgateway.
+type pushRequest struct {
+	Body   string
+	Method string
+	Path   string
+}
+
+// newTestPushService creates a prometheus publisher service pushing to the
+// given URL using its own registry. It has a single counter incremented once.
+func newTestPushService(t *testing.T, url string, method string, timeout time.Duration) *Service {
+	registry := prometheus.NewRegistry()
+
+	config := DefaultServiceConfig()
+	config.Gatherer = registry
+	config.PushGrouping = map[string]string{"instance": "worker-1"}
+	config.PushJob = "batch"
+	config.PushMethod = method
+	config.PushTimeout = timeout
+	config.PushURL = url
+	config.Registerer = registry
+	s, err := NewService(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	counterConfig := s.CounterConfig()
+	counterConfig.SetHelp("Number of processed jobs.")
+	counterConfig.SetName("jobs_total")
+	c, err := s.Counter(counterConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = c.Increment(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return s
+}
+
+func TestService_Push(t *testing.T) {
+	received := make(chan pushRequest, 10)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		received <- pushRequest{Body: string(b), Method: r.Method, Path: r.URL.Path}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	for _, method := range []string{PushMethodPost, PushMethodPut} {
+		s := newTestPushService(t, srv.URL, method, time.Second)
+
+		err := s.Push()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// The final push on Shutdown happens even though the service has not been
+		// booted.
+		s.Shutdown()
+
+		if len(received) != 2 {
+			t.Fatalf("method %s: expected 2 pushes, got %d", method, len(received))
+		}
+		for i := 0; i < 2; i++ {
+			r := <-received
+			if r.Method != method {
+				t.Fatalf("expected method %s, got %s", method, r.Method)
+			}
+			if r.Path != "/metrics/job/batch/instance/worker-1" {
+				t.Fatalf("expected grouping key path, got %s", r.Path)
+			}
+			if !strings.Contains(r.Body, "jobs_total") {
+				t.Fatalf("method %s: expected jobs_total to be pushed", method)
+			}
+		}
+	}
+}
+
+func TestService_Push_Timeout(t *testing.T) {
+	// The fake Pushgateway does not respond until the test is over.
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer srv.Close()
+	defer close(release)
+
+	s := newTestPushService(t, srv.URL, PushMethodPut, 50*time.Millisecond)
+
+	start := time.Now()
+	err := s.Push()
+	if err == nil {
+		t.Fatal("expected error of push not being responded to")
+	}
+	if d := time.Since(start); d > 5*time.Second {
+		t.Fatalf("expected push to time out after 50ms, took %s", d)
+	}
+}
+
+func TestService_Push_Disabled(t *testing.T) {
+	registry := prometheus.NewRegistry()
+
+	config := DefaultServiceConfig()
+	config.Gatherer = registry
+	config.Registerer = registry
+	s, err := NewService(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Shutdown()
+
+	err = s.Push()
+	if !IsInvalidConfig(err) {
+		t.Fatalf("expected invalid config error without push URL, got %v", err)
+	}
+}
+
+func TestService_Push_ErrorHandler(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+
+	var mutex sync.Mutex
+	var errs []error
+	handled := func() int {
+		mutex.Lock()
+		defer mutex.Unlock()
+		return len(errs)
+	}
+
+	config := DefaultServiceConfig()
+	config.ErrorHandler = func(err error) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		errs = append(errs, err)
+	}
+	config.PushInterval = 10 * time.Millisecond
+	config.PushJob = "batch"
+	config.PushURL = srv.URL
+
+	// Errors of the periodic pushes are handled.
+	s, _ := newTestService(t, config)
+	s.Boot()
+	deadline := time.Now().Add(5 * time.Second)
+	for handled() == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("expected error of periodic push to be handled")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	s.Shutdown()
+
+	// The error of the final push is handled as well. The service is not
+	// booted, so the final push is the only one.
+	errs = nil
+	s, _ = newTestService(t, config)
+	s.Shutdown()
+	if handled() != 1 {
+		t.Fatalf("expected error of final push to be handled, got %d errors", handled())
+	}
+}
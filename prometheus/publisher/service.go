@@ -4,93 +4,386 @@
 package publisher
 
 import (
+	"context"
 	"net/http"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/prometheus/client_golang/prometheus/push"
 
+	"github.com/the-anna-project/instrumentor/internal/metric"
 	"github.com/the-anna-project/instrumentor/spec"
 )
 
 // ServiceConfig represents the configuration used to create a new prometheus
 // publisher service.
 type ServiceConfig struct {
+	// Dependencies.
+
+	// Gatherer is used to gather the metrics served by the service's HTTP
+	// handler and pushed to the Pushgateway. It must be the same registry as
+	// Registerer, so that the metrics created by the service are the ones being
+	// gathered.
+	Gatherer prometheus.Gatherer
+	// HTTPHandler is used to serve the metrics at HTTPEndpoint. In case it is
+	// nil, which is the default, a handler serving the metrics gathered using
+	// Gatherer is used.
+	HTTPHandler http.Handler
+	// Registerer is used to register all metrics created by the service.
+	Registerer prometheus.Registerer
+
 	// Settings.
+
+	// CardinalityMode describes how label combinations exceeding MaxSeries or
+	// MaxSeriesPerMetric are handled. It is one of CardinalityModeDrop or
+	// CardinalityModeOverflow. Either way the counter
+	// instrumentor_cardinality_limit_exceeded_total records it.
+	CardinalityMode string
+	// ConstLabels represents labels having the same value for all metrics
+	// created by the service, e.g. the name of the service or its version.
+	ConstLabels map[string]string
+	// ErrorHandler is called with errors the service cannot return, which are
+	// the errors of the periodic pushes and the final push on Shutdown. The
+	// default logs them.
+	ErrorHandler func(err error)
 	HTTPEndpoint string
-	HTTPHandler  http.Handler
-	Prefixes     []string
+	// MaxSeries represents the maximum number of series of all labelled metrics
+	// created by the service. There is no limit in case it is 0, which is the
+	// default. Other publishers do not limit the number of series.
+	MaxSeries int
+	// MaxSeriesPerMetric represents the maximum number of series of a single
+	// labelled metric created by the service. There is no limit in case it is
+	// 0, which is the default.
+	MaxSeriesPerMetric int
+	// PanicMode describes how panics of wrapped actions are handled. It is one
+	// of spec.PanicModeNone, spec.PanicModeRecover or spec.PanicModeRepanic.
+	PanicMode string
+	Prefixes  []string
+	// PushGrouping represents the grouping labels, next to the job name, of the
+	// grouping key the metrics are pushed with.
+	PushGrouping map[string]string
+	// PushInterval represents the interval in which the metrics are pushed to
+	// the Pushgateway after Boot.
+	PushInterval time.Duration
+	// PushJob represents the job name the metrics are pushed with.
+	PushJob string
+	// PushMethod represents the HTTP method the metrics are pushed with. It is
+	// one of PushMethodPost or PushMethodPut.
+	PushMethod string
+	// PushTimeout represents the maximum duration of a single push to the
+	// Pushgateway.
+	PushTimeout time.Duration
+	// PushURL represents the URL of the Pushgateway metrics are pushed to, e.g.
+	// http://127.0.0.1:9091. Pushing is disabled in case it is empty, which is
+	// the default. Pushing is meant for short-lived jobs that might exit before
+	// the metrics served by the HTTP handler are scraped.
+	PushURL string
+	// SanitizeKeys causes NewKey to replace all characters not being allowed in
+	// metric names by underscores, e.g. in case keys are built from dynamic
+	// input. Metric names and label names are validated either way. Invalid
+	// ones cause errors asserted by IsInvalidName.
+	SanitizeKeys bool
+	// SeriesTTL represents the default TTL of the metric configs provided by
+	// the service. A series of a labelled metric not touched within the TTL of
+	// the metric is deleted. Series never expire in case it is 0, which is the
+	// default. Other publishers do not expire series.
+	SeriesTTL time.Duration
+	// SweepInterval represents the interval in which series exceeding their TTL
+	// are deleted after Boot.
+	SweepInterval time.Duration
 }
 
 // DefaultServiceConfig provides a default configuration to create a new
 // prometheus publisher service by best effort.
 func DefaultServiceConfig() ServiceConfig {
 	return ServiceConfig{
+		// Dependencies.
+		Gatherer:    prometheus.DefaultGatherer,
+		HTTPHandler: nil,
+		Registerer:  prometheus.DefaultRegisterer,
+
 		// Settings.
-		HTTPEndpoint: "/metrics",
-		HTTPHandler:  prometheus.Handler(),
-		Prefixes:     []string{},
+		CardinalityMode:    CardinalityModeOverflow,
+		ConstLabels:        map[string]string{},
+		ErrorHandler:       metric.LogError("prometheus"),
+		HTTPEndpoint:       "/metrics",
+		MaxSeries:          0,
+		MaxSeriesPerMetric: 0,
+		PanicMode:          spec.PanicModeNone,
+		Prefixes:           []string{},
+		PushGrouping:       map[string]string{},
+		PushInterval:       10 * time.Second,
+		PushJob:            "",
+		PushMethod:         PushMethodPut,
+		PushTimeout:        5 * time.Second,
+		PushURL:            "",
+		SanitizeKeys:       false,
+		SeriesTTL:          0,
+		SweepInterval:      time.Minute,
 	}
 }
 
 // NewService creates a new prometheus publisher service.
 func NewService(config ServiceConfig) (*Service, error) {
+	// Dependencies.
+	if config.Gatherer == nil {
+		return nil, maskAnyf(invalidConfigError, "gatherer must not be empty")
+	}
+	if config.Registerer == nil {
+		return nil, maskAnyf(invalidConfigError, "registerer must not be empty")
+	}
+	if g, ok := config.Registerer.(prometheus.Gatherer); !ok || g != config.Gatherer {
+		return nil, maskAnyf(invalidConfigError, "gatherer must be the same registry as registerer")
+	}
+
 	// Settings.
+	if config.CardinalityMode != CardinalityModeDrop && config.CardinalityMode != CardinalityModeOverflow {
+		return nil, maskAnyf(invalidConfigError, "cardinality mode must be one of: %s, %s", CardinalityModeDrop, CardinalityModeOverflow)
+	}
+	if config.ConstLabels == nil {
+		return nil, maskAnyf(invalidConfigError, "const labels must not be empty")
+	}
+	err := metric.ValidateConstLabels(config.ConstLabels)
+	if err != nil {
+		return nil, maskAny(err)
+	}
+	if config.ErrorHandler == nil {
+		return nil, maskAnyf(invalidConfigError, "error handler must not be empty")
+	}
 	if config.HTTPEndpoint == "" {
 		return nil, maskAnyf(invalidConfigError, "HTTP endpoint must not be empty")
 	}
-	if config.HTTPHandler == nil {
-		return nil, maskAnyf(invalidConfigError, "HTTP handler must not be empty")
+	if config.MaxSeries < 0 {
+		return nil, maskAnyf(invalidConfigError, "max series must not be negative")
+	}
+	if config.MaxSeriesPerMetric < 0 {
+		return nil, maskAnyf(invalidConfigError, "max series per metric must not be negative")
+	}
+	if config.PanicMode != spec.PanicModeNone && config.PanicMode != spec.PanicModeRecover && config.PanicMode != spec.PanicModeRepanic {
+		return nil, maskAnyf(invalidConfigError, "panic mode must be one of: %s, %s, %s", spec.PanicModeNone, spec.PanicModeRecover, spec.PanicModeRepanic)
 	}
 	if config.Prefixes == nil {
 		return nil, maskAnyf(invalidConfigError, "prefixes must not be empty")
 	}
+	if config.SeriesTTL < 0 {
+		return nil, maskAnyf(invalidConfigError, "series TTL must not be negative")
+	}
+	if config.SweepInterval <= 0 {
+		return nil, maskAnyf(invalidConfigError, "sweep interval must be greater than 0")
+	}
+	if config.PushURL != "" {
+		if config.PushInterval <= 0 {
+			return nil, maskAnyf(invalidConfigError, "push interval must be greater than 0")
+		}
+		if config.PushJob == "" {
+			return nil, maskAnyf(invalidConfigError, "push job must not be empty")
+		}
+		if config.PushMethod != PushMethodPost && config.PushMethod != PushMethodPut {
+			return nil, maskAnyf(invalidConfigError, "push method must be one of: %s, %s", PushMethodPost, PushMethodPut)
+		}
+		if config.PushTimeout <= 0 {
+			return nil, maskAnyf(invalidConfigError, "push timeout must be greater than 0")
+		}
+	}
+
+	var newPusher *push.Pusher
+	{
+		if config.PushURL != "" {
+			// The default HTTP client has no timeout, so a Pushgateway not
+			// responding would block the push loop and Shutdown forever.
+			httpClient := &http.Client{Timeout: config.PushTimeout}
+			newPusher = push.New(config.PushURL, config.PushJob).Client(httpClient).Gatherer(config.Gatherer)
+			for k, v := range config.PushGrouping {
+				newPusher = newPusher.Grouping(k, v)
+			}
+		}
+	}
+
+	constLabels := map[string]string{}
+	for n, v := range config.ConstLabels {
+		constLabels[n] = v
+	}
+
+	var newLimiter *cardinalityLimiter
+	{
+		if config.MaxSeries > 0 || config.MaxSeriesPerMetric > 0 {
+			exceeded := prometheus.NewCounterVec(
+				prometheus.CounterOpts{
+					ConstLabels: constLabels,
+					Help:        "Number of label combinations exceeding the cardinality limits.",
+					Name:        cardinalityExceededName,
+				},
+				[]string{"metric"},
+			)
+			err := config.Registerer.Register(exceeded)
+			if are, ok := err.(prometheus.AlreadyRegisteredError); ok {
+				// Services sharing a registry share the counter as well.
+				existing, ok := are.ExistingCollector.(*prometheus.CounterVec)
+				if !ok {
+					return nil, maskAnyf(invalidConfigError, "%s is already registered as another kind of collector", cardinalityExceededName)
+				}
+				exceeded = existing
+			} else if err != nil {
+				return nil, maskAny(err)
+			}
+
+			newLimiter = &cardinalityLimiter{
+				exceeded:           exceeded,
+				mutex:              sync.Mutex{},
+				series:             map[string]map[string]struct{}{},
+				total:              0,
+				maxSeries:          config.MaxSeries,
+				maxSeriesPerMetric: config.MaxSeriesPerMetric,
+				mode:               config.CardinalityMode,
+			}
+		}
+	}
+
+	httpHandler := config.HTTPHandler
+	if httpHandler == nil {
+		httpHandler = promhttp.HandlerFor(config.Gatherer, promhttp.HandlerOpts{})
+	}
 
 	newService := &Service{
+		// Dependencies.
+		gatherer:   config.Gatherer,
+		registerer: config.Registerer,
+
 		// Internals.
 		closer:       make(chan struct{}, 1),
 		counters:     map[string]*Counter{},
 		bootOnce:     sync.Once{},
+		definitions:  map[string]metric.Definition{},
+		done:         make(chan struct{}, 1),
+		expiring:     make(chan struct{}),
+		expiringOnce: sync.Once{},
 		gauges:       map[string]*Gauge{},
 		histograms:   map[string]*Histogram{},
+		limiter:      newLimiter,
 		mutex:        sync.Mutex{},
+		pusher:       newPusher,
 		shutdownOnce: sync.Once{},
+		summaries:    map[string]*Summary{},
 
 		// Settings.
-		httpEndpoint: config.HTTPEndpoint,
-		httpHandler:  config.HTTPHandler,
-		prefixes:     config.Prefixes,
+		constLabels:   constLabels,
+		errorHandler:  config.ErrorHandler,
+		httpEndpoint:  config.HTTPEndpoint,
+		panicMode:     config.PanicMode,
+		httpHandler:   httpHandler,
+		prefixes:      config.Prefixes,
+		pushInterval:  config.PushInterval,
+		pushMethod:    config.PushMethod,
+		sanitizeKeys:  config.SanitizeKeys,
+		seriesTTL:     config.SeriesTTL,
+		sweepInterval: config.SweepInterval,
 	}
 
 	return newService, nil
 }
 
 type Service struct {
+	// Dependencies.
+	gatherer   prometheus.Gatherer
+	registerer prometheus.Registerer
+
 	// Internals.
 	closer       chan struct{}
 	counters     map[string]*Counter
 	bootOnce     sync.Once
+	definitions  map[string]metric.Definition
+	done         chan struct{}
+	expiring     chan struct{}
+	expiringOnce sync.Once
 	gauges       map[string]*Gauge
 	histograms   map[string]*Histogram
+	limiter      *cardinalityLimiter
 	mutex        sync.Mutex
+	pusher       *push.Pusher
 	shutdownOnce sync.Once
+	summaries    map[string]*Summary
 
 	// Settings.
 
+	// constLabels represents labels having the same value for all metrics
+	// created by the service.
+	constLabels map[string]string
+	// errorHandler is called with the errors of the periodic pushes and the
+	// final push on Shutdown.
+	errorHandler func(err error)
 	// httpEndpoint represents the HTTP endpoint used to register the httpHandler.
 	// In the context of Prometheus this is usually /metrics.
 	httpEndpoint string
-	// httpHandler represents the HTTP handler used to register the Prometheus
-	// registry in the HTTP server.
+	// httpHandler represents the HTTP handler used to serve the metrics of the
+	// configured gatherer in the HTTP server.
 	httpHandler http.Handler
+	// panicMode describes how panics of wrapped actions are handled.
+	panicMode string
 	// prefixes represents the Instrumentor's ordered prefixes.
 	prefixes []string
+	// pushInterval represents the interval in which the metrics are pushed to
+	// the Pushgateway.
+	pushInterval time.Duration
+	// pushMethod represents the HTTP method the metrics are pushed with.
+	pushMethod string
+	// sanitizeKeys describes whether NewKey sanitizes the keys it returns.
+	sanitizeKeys bool
+	// seriesTTL represents the default TTL of the metric configs provided by the
+	// service.
+	seriesTTL time.Duration
+	// sweepInterval represents the interval in which series exceeding their TTL
+	// are deleted.
+	sweepInterval time.Duration
 }
 
 func (s *Service) Boot() {
 	s.bootOnce.Do(func() {
-		// Service specific boot logic goes here.
+		go func() {
+			defer close(s.done)
+
+			// The sweep ticker is only started once the expiring channel is closed,
+			// which happens as soon as the first labelled metric having a TTL is
+			// registered. Until then the sweep ticker channel remains nil, so it
+			// never fires.
+			var sweepTicker *time.Ticker
+			var sweepTicks <-chan time.Time
+			expiring := s.expiring
+			defer func() {
+				if sweepTicker != nil {
+					sweepTicker.Stop()
+				}
+			}()
+
+			// The push ticker channel remains nil in case pushing is disabled, so
+			// it never fires.
+			var pushTicks <-chan time.Time
+			if s.pusher != nil {
+				pushTicker := time.NewTicker(s.pushInterval)
+				defer pushTicker.Stop()
+				pushTicks = pushTicker.C
+			}
+
+			for {
+				select {
+				case <-s.closer:
+					return
+				case <-pushTicks:
+					err := s.Push()
+					if err != nil {
+						s.errorHandler(err)
+					}
+				case <-expiring:
+					sweepTicker = time.NewTicker(s.sweepInterval)
+					sweepTicks = sweepTicker.C
+					expiring = nil
+				case now := <-sweepTicks:
+					s.sweep(now)
+				}
+			}
+		}()
 	})
 }
 
@@ -98,16 +391,29 @@ func (s *Service) Counter(config spec.CounterConfig) (spec.Counter, error) {
 	s.mutex.Lock()
 	defer s.mutex.Unlock()
 
-	if c, ok := s.counters[config.Name()]; ok {
-		return c, nil
+	newDefinition := metric.Definition{
+		Help:   config.Help(),
+		Kind:   metric.KindCounter,
+		Labels: config.Labels(),
+		Name:   config.Name(),
+		TTL:    configTTL(config),
+	}
+	d, ok := s.definitions[config.Name()]
+	err := d.Check(newDefinition)
+	if err != nil {
+		return nil, maskAny(err)
+	}
+	if ok {
+		return s.counters[config.Name()], nil
 	}
 
-	newCounter, err := NewCounter(config)
+	newCounter, err := newCounterWithConstLabels(config, s.constLabels)
 	if err != nil {
 		return nil, maskAny(err)
 	}
+	newCounter.limiter = s.limiter
 
-	err = prometheus.Register(newCounter.ClientCounter)
+	err = s.register(newDefinition, newCounter.Collector())
 	if err != nil {
 		return nil, maskAny(err)
 	}
@@ -117,23 +423,39 @@ func (s *Service) Counter(config spec.CounterConfig) (spec.Counter, error) {
 }
 
 func (s *Service) CounterConfig() spec.CounterConfig {
-	return DefaultCounterConfig()
+	newConfig := DefaultCounterConfig()
+	newConfig.SetTTL(s.seriesTTL)
+
+	return newConfig
 }
 
 func (s *Service) Gauge(config spec.GaugeConfig) (spec.Gauge, error) {
 	s.mutex.Lock()
 	defer s.mutex.Unlock()
 
-	if g, ok := s.gauges[config.Name()]; ok {
-		return g, nil
+	newDefinition := metric.Definition{
+		Help:   config.Help(),
+		Kind:   metric.KindGauge,
+		Labels: config.Labels(),
+		Name:   config.Name(),
+		TTL:    configTTL(config),
+	}
+	d, ok := s.definitions[config.Name()]
+	err := d.Check(newDefinition)
+	if err != nil {
+		return nil, maskAny(err)
+	}
+	if ok {
+		return s.gauges[config.Name()], nil
 	}
 
-	newGauge, err := NewGauge(config)
+	newGauge, err := newGaugeWithConstLabels(config, s.constLabels)
 	if err != nil {
 		return nil, maskAny(err)
 	}
+	newGauge.limiter = s.limiter
 
-	err = prometheus.Register(newGauge.ClientGauge)
+	err = s.register(newDefinition, newGauge.Collector())
 	if err != nil {
 		return nil, maskAny(err)
 	}
@@ -143,33 +465,60 @@ func (s *Service) Gauge(config spec.GaugeConfig) (spec.Gauge, error) {
 }
 
 func (s *Service) GaugeConfig() spec.GaugeConfig {
-	return DefaultGaugeConfig()
+	newConfig := DefaultGaugeConfig()
+	newConfig.SetTTL(s.seriesTTL)
+
+	return newConfig
 }
 
 func (s *Service) Histogram(config spec.HistogramConfig) (spec.Histogram, error) {
 	s.mutex.Lock()
 	defer s.mutex.Unlock()
 
-	if h, ok := s.histograms[config.Name()]; ok {
-		return h, nil
+	// The name of the histogram has its unit appended, so that histograms are
+	// looked up by the name they are actually reported with.
+	name := metric.UnitName(config.Name(), config.Unit())
+
+	newDefinition := metric.Definition{
+		Buckets:               config.Buckets(),
+		Help:                  config.Help(),
+		Kind:                  metric.KindHistogram,
+		Labels:                config.Labels(),
+		Name:                  name,
+		NativeBucketFactor:    config.NativeBucketFactor(),
+		NativeMaxBucketNumber: config.NativeMaxBucketNumber(),
+		NativeZeroThreshold:   config.NativeZeroThreshold(),
+		TTL:                   configTTL(config),
+	}
+	d, ok := s.definitions[name]
+	err := d.Check(newDefinition)
+	if err != nil {
+		return nil, maskAny(err)
+	}
+	if ok {
+		return s.histograms[name], nil
 	}
 
-	newHistogram, err := NewHistogram(config)
+	newHistogram, err := newHistogramWithConstLabels(config, s.constLabels)
 	if err != nil {
 		return nil, maskAny(err)
 	}
+	newHistogram.limiter = s.limiter
 
-	err = prometheus.Register(newHistogram.ClientHistogram)
+	err = s.register(newDefinition, newHistogram.Collector())
 	if err != nil {
 		return nil, maskAny(err)
 	}
-	s.histograms[config.Name()] = newHistogram
+	s.histograms[name] = newHistogram
 
 	return newHistogram, nil
 }
 
 func (s *Service) HistogramConfig() spec.HistogramConfig {
-	return DefaultHistogramConfig()
+	newConfig := DefaultHistogramConfig()
+	newConfig.SetTTL(s.seriesTTL)
+
+	return newConfig
 }
 
 func (s *Service) HTTPEndpoint() string {
@@ -180,47 +529,141 @@ func (s *Service) HTTPHandler() http.Handler {
 	return s.httpHandler
 }
 
+// Labels returns the ordered label names the metric registered under the given
+// name has been configured with. The returned boolean is false in case no
+// metric is registered under the given name.
+func (s *Service) Labels(name string) ([]string, bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	d, ok := s.definitions[name]
+
+	return d.Labels, ok
+}
+
 func (s *Service) Prefixes() []string {
 	return s.prefixes
 }
 
 func (s *Service) NewKey(str ...string) string {
-	return strings.Join(append(s.prefixes, str...), "_")
+	key := strings.Join(append(append([]string{}, s.prefixes...), str...), "_")
+	if s.sanitizeKeys {
+		key = metric.SanitizeName(key)
+	}
+
+	return key
+}
+
+// register registers the given collector using the configured registerer and
+// remembers the given definition of the collected metric. It must only be
+// called while holding the mutex.
+func (s *Service) register(d metric.Definition, collector prometheus.Collector) error {
+	err := s.registerer.Register(collector)
+	if _, ok := err.(prometheus.AlreadyRegisteredError); ok {
+		return maskAnyf(alreadyRegisteredError, "%s %s is already registered in the registry", d.Kind, d.Name)
+	} else if err != nil {
+		return maskAny(err)
+	}
+	s.definitions[d.Name] = d
+
+	if d.TTL > 0 && len(d.Labels) != 0 {
+		s.expiringOnce.Do(func() { close(s.expiring) })
+	}
+
+	return nil
 }
 
 func (s *Service) Shutdown() {
 	s.shutdownOnce.Do(func() {
 		close(s.closer)
-	})
-}
 
-func (s *Service) WrapFunc(key string, action func() error) func() error {
-	wrappedFunc := func() error {
-		histogramConfig := DefaultHistogramConfig()
-		histogramConfig.SetName(s.NewKey(key, "milliseconds"))
-		h, err := s.Histogram(histogramConfig)
-		if err != nil {
-			return maskAny(err)
+		// The background loop is only running in case the service has been
+		// booted. The final push must not race with a push of the loop, so the
+		// loop is awaited first.
+		booted := true
+		s.bootOnce.Do(func() { booted = false })
+		if booted {
+			<-s.done
 		}
-		counterConfig := DefaultCounterConfig()
-		counterConfig.SetName(s.NewKey(key, "error", "total"))
-		c, err := s.Counter(counterConfig)
-		if err != nil {
-			return maskAny(err)
+
+		if s.pusher != nil {
+			err := s.Push()
+			if err != nil {
+				s.errorHandler(err)
+			}
 		}
+	})
+}
 
-		defer func(t time.Time) {
-			h.Observe(float64(time.Since(t) / time.Millisecond))
-		}(time.Now())
+// sweep deletes the series of all metrics not touched within their TTL before
+// the given time.
+func (s *Service) sweep(now time.Time) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
 
-		err = action()
-		if err != nil {
-			c.Increment(1)
-			return maskAny(err)
-		}
+	for _, c := range s.counters {
+		c.sweep(now)
+	}
+	for _, g := range s.gauges {
+		g.sweep(now)
+	}
+	for _, h := range s.histograms {
+		h.sweep(now)
+	}
+	for _, m := range s.summaries {
+		m.sweep(now)
+	}
+}
 
-		return nil
+func (s *Service) Summary(config spec.SummaryConfig) (spec.Summary, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	newDefinition := metric.Definition{
+		AgeBuckets: config.AgeBuckets(),
+		Help:       config.Help(),
+		Kind:       metric.KindSummary,
+		Labels:     config.Labels(),
+		MaxAge:     config.MaxAge(),
+		Name:       config.Name(),
+		Objectives: config.Objectives(),
+		TTL:        configTTL(config),
+	}
+	d, ok := s.definitions[config.Name()]
+	err := d.Check(newDefinition)
+	if err != nil {
+		return nil, maskAny(err)
+	}
+	if ok {
+		return s.summaries[config.Name()], nil
+	}
+
+	newSummary, err := newSummaryWithConstLabels(config, s.constLabels)
+	if err != nil {
+		return nil, maskAny(err)
 	}
+	newSummary.limiter = s.limiter
 
-	return wrappedFunc
+	err = s.register(newDefinition, newSummary.Collector())
+	if err != nil {
+		return nil, maskAny(err)
+	}
+	s.summaries[config.Name()] = newSummary
+
+	return newSummary, nil
+}
+
+func (s *Service) SummaryConfig() spec.SummaryConfig {
+	newConfig := DefaultSummaryConfig()
+	newConfig.SetTTL(s.seriesTTL)
+
+	return newConfig
+}
+
+func (s *Service) WrapContextFunc(key string, labels map[string]string, action func(ctx context.Context) error) func(ctx context.Context) error {
+	return metric.WrapContextFunc(s, s.panicMode, key, labels, action)
+}
+
+func (s *Service) WrapFunc(key string, action func() error) func() error {
+	return metric.WrapFunc(s, s.panicMode, key, action)
 }
@@ -0,0 +1,164 @@
+package publisher
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/the-anna-project/instrumentor/spec"
+)
+
+// newTestService creates a prometheus publisher service using its own
+// registry, which is returned as well, so tests do not share the default
+// registry.
+func newTestService(t *testing.T, config ServiceConfig) (*Service, *prometheus.Registry) {
+	registry := prometheus.NewRegistry()
+	config.Gatherer = registry
+	config.Registerer = registry
+
+	s, err := NewService(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return s, registry
+}
+
+// gathered returns the values of the counters and gauges gathered from the
+// given registry, keyed by their names and label values joined by commas, e.g.
+// requests_total{GET,200}.
+func gathered(t *testing.T, registry *prometheus.Registry) map[string]float64 {
+	families, err := registry.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	values := map[string]float64{}
+	for _, f := range families {
+		for _, m := range f.GetMetric() {
+			var labelValues []string
+			for _, l := range m.GetLabel() {
+				labelValues = append(labelValues, l.GetValue())
+			}
+			key := f.GetName() + "{" + strings.Join(labelValues, ",") + "}"
+
+			switch {
+			case m.GetCounter() != nil:
+				values[key] = m.GetCounter().GetValue()
+			case m.GetGauge() != nil:
+				values[key] = m.GetGauge().GetValue()
+			}
+		}
+	}
+
+	return values
+}
+
+func TestNewService_ConstLabels(t *testing.T) {
+	// The prometheus client library panics as soon as a histogram or summary is
+	// created using constant labels named le or quantile. Constant labels named
+	// outcome collide with the labels of wrapped actions. All of them are
+	// rejected when creating the service.
+	for _, name := range []string{"le", "quantile", "outcome"} {
+		registry := prometheus.NewRegistry()
+
+		config := DefaultServiceConfig()
+		config.ConstLabels = map[string]string{name: "foo"}
+		config.Gatherer = registry
+		config.Registerer = registry
+		_, err := NewService(config)
+		if !IsInvalidName(err) {
+			t.Fatalf("const label %s: expected invalid name error, got %v", name, err)
+		}
+	}
+}
+
+func TestService_Register(t *testing.T) {
+	s, registry := newTestService(t, DefaultServiceConfig())
+
+	newConfig := func(name, help string, labels []string) spec.CounterConfig {
+		config := s.CounterConfig()
+		config.SetHelp(help)
+		config.SetLabels(labels)
+		config.SetName(name)
+		return config
+	}
+
+	c, err := s.Counter(newConfig("requests_total", "Number of requests.", []string{"method"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		Name     string
+		Register func() (interface{}, error)
+		Check    func(err error) bool
+	}{
+		{
+			Name: "same definition",
+			Register: func() (interface{}, error) {
+				return s.Counter(newConfig("requests_total", "Number of requests.", []string{"method"}))
+			},
+			Check: func(err error) bool { return err == nil },
+		},
+		{
+			Name: "other kind",
+			Register: func() (interface{}, error) {
+				config := s.GaugeConfig()
+				config.SetHelp("Number of requests.")
+				config.SetLabels([]string{"method"})
+				config.SetName("requests_total")
+				return s.Gauge(config)
+			},
+			Check: IsAlreadyRegistered,
+		},
+		{
+			Name: "other help",
+			Register: func() (interface{}, error) {
+				return s.Counter(newConfig("requests_total", "Number of handled requests.", []string{"method"}))
+			},
+			Check: IsConflictingDefinition,
+		},
+		{
+			Name: "other labels",
+			Register: func() (interface{}, error) {
+				return s.Counter(newConfig("requests_total", "Number of requests.", []string{"method", "code"}))
+			},
+			Check: IsConflictingDefinition,
+		},
+		{
+			Name: "empty help",
+			Register: func() (interface{}, error) {
+				return s.Counter(newConfig("errors_total", "", nil))
+			},
+			Check: IsInvalidConfig,
+		},
+		{
+			Name: "registered by other service",
+			Register: func() (interface{}, error) {
+				// Services sharing a registry do not share their definitions, so the
+				// conflict is detected by the registry.
+				config := DefaultServiceConfig()
+				config.Gatherer = registry
+				config.Registerer = registry
+				other, err := NewService(config)
+				if err != nil {
+					return nil, err
+				}
+				return other.Counter(newConfig("requests_total", "Number of requests.", []string{"method"}))
+			},
+			Check: IsAlreadyRegistered,
+		},
+	}
+
+	for _, tc := range testCases {
+		registered, err := tc.Register()
+		if !tc.Check(err) {
+			t.Fatalf("%s: unexpected error %v", tc.Name, err)
+		}
+		if err == nil && registered != c {
+			t.Fatalf("%s: expected the registered counter to be returned", tc.Name)
+		}
+	}
+}
@@ -0,0 +1,434 @@
+package publisher
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/the-anna-project/instrumentor/internal/metric"
+	"github.com/the-anna-project/instrumentor/spec"
+)
+
+// SummaryConfig represents the configuration used to create a new prometheus
+// publisher summary.
+type SummaryConfig struct {
+	// Settings.
+
+	// ageBuckets represents the number of buckets the sliding time window of
+	// maxAge is divided into. Each time a bucket's duration passed, the oldest
+	// observations are dropped.
+	ageBuckets uint32
+	// help represents some sort of informative description of the registered
+	// metric.
+	help string
+	// labels represents labels as being used by prometheus. They partition
+	// metrics.
+	labels []string
+	// maxAge represents the duration of the sliding time window observations
+	// are considered for when estimating quantiles.
+	maxAge time.Duration
+	// name represents the metric's key as it is supposed to be registered. In the
+	// scope of prometheus publisher this is expected to be an underscored string.
+	name string
+	// objectives represents the quantiles to be estimated, mapped to their
+	// absolute error. E.g. 0.99: 0.001 estimates the 99th percentile with a rank
+	// error of 0.1 percent.
+	objectives map[float64]float64
+	// ttl represents the duration after which a series of the metric is deleted
+	// in case it has not been touched in the meantime. Series never expire in
+	// case it is 0.
+	ttl time.Duration
+}
+
+func (sc *SummaryConfig) AgeBuckets() uint32 {
+	return sc.ageBuckets
+}
+
+func (sc *SummaryConfig) Help() string {
+	return sc.help
+}
+
+func (sc *SummaryConfig) Labels() []string {
+	return sc.labels
+}
+
+func (sc *SummaryConfig) MaxAge() time.Duration {
+	return sc.maxAge
+}
+
+func (sc *SummaryConfig) Name() string {
+	return sc.name
+}
+
+func (sc *SummaryConfig) Objectives() map[float64]float64 {
+	return sc.objectives
+}
+
+func (sc *SummaryConfig) SetAgeBuckets(ageBuckets uint32) {
+	sc.ageBuckets = ageBuckets
+}
+
+func (sc *SummaryConfig) SetHelp(help string) {
+	sc.help = help
+}
+
+func (sc *SummaryConfig) SetLabels(labels []string) {
+	sc.labels = labels
+}
+
+func (sc *SummaryConfig) SetMaxAge(maxAge time.Duration) {
+	sc.maxAge = maxAge
+}
+
+func (sc *SummaryConfig) SetName(name string) {
+	sc.name = name
+}
+
+func (sc *SummaryConfig) SetObjectives(objectives map[float64]float64) {
+	sc.objectives = objectives
+}
+
+func (sc *SummaryConfig) SetTTL(ttl time.Duration) {
+	sc.ttl = ttl
+}
+
+// TTL returns the duration after which series of the summary not being written
+// expire. It is not part of spec.SummaryConfig, since only the prometheus
+// publisher supports it.
+func (sc *SummaryConfig) TTL() time.Duration {
+	return sc.ttl
+}
+
+// DefaultSummaryConfig provides a default configuration to create a new
+// prometheus publisher summary by best effort.
+func DefaultSummaryConfig() *SummaryConfig {
+	return &SummaryConfig{
+		// Settings.
+		ageBuckets: prometheus.DefAgeBuckets,
+		help:       "",
+		labels:     nil,
+		maxAge:     prometheus.DefMaxAge,
+		name:       "",
+		objectives: map[float64]float64{0.5: 0.05, 0.9: 0.01, 0.99: 0.001},
+		ttl:        0,
+	}
+}
+
+// NewSummary creates a new configured prometheus publisher summary.
+func NewSummary(config spec.SummaryConfig) (*Summary, error) {
+	return newSummaryWithConstLabels(config, nil)
+}
+
+// newSummaryWithConstLabels creates a new configured prometheus publisher
+// summary having the given constant labels.
+func newSummaryWithConstLabels(config spec.SummaryConfig, constLabels map[string]string) (*Summary, error) {
+	// Settings.
+	if config.AgeBuckets() == 0 {
+		return nil, maskAnyf(invalidConfigError, "age buckets must be greater than 0")
+	}
+	if config.MaxAge() < time.Duration(config.AgeBuckets()) {
+		return nil, maskAnyf(invalidConfigError, "max age must be at least 1 nanosecond per age bucket")
+	}
+	if config.Name() == "" {
+		return nil, maskAnyf(invalidConfigError, "name must not be empty")
+	}
+	err := metric.ValidateName(config.Name())
+	if err != nil {
+		return nil, maskAny(err)
+	}
+	err = metric.ValidateLabels(config.Labels(), constLabels, metric.QuantileLabel)
+	if err != nil {
+		return nil, maskAny(err)
+	}
+	if configTTL(config) < 0 {
+		return nil, maskAnyf(invalidConfigError, "TTL must not be negative")
+	}
+	for q, e := range config.Objectives() {
+		if q < 0 || q > 1 {
+			return nil, maskAnyf(invalidConfigError, "objective quantile %v must be between 0 and 1", q)
+		}
+		if e < 0 {
+			return nil, maskAnyf(invalidConfigError, "objective error %v must not be negative", e)
+		}
+	}
+
+	var clientSummary prometheus.Summary
+	var clientSummaryVec *prometheus.SummaryVec
+	var expiry *seriesExpiry
+
+	if len(config.Labels()) == 0 {
+		clientSummary = prometheus.NewSummary(
+			prometheus.SummaryOpts{
+				AgeBuckets:  config.AgeBuckets(),
+				ConstLabels: constLabels,
+				Help:        config.Help(),
+				MaxAge:      config.MaxAge(),
+				Name:        config.Name(),
+				Objectives:  config.Objectives(),
+			},
+		)
+	} else {
+		clientSummaryVec = prometheus.NewSummaryVec(
+			prometheus.SummaryOpts{
+				AgeBuckets:  config.AgeBuckets(),
+				ConstLabels: constLabels,
+				Help:        config.Help(),
+				MaxAge:      config.MaxAge(),
+				Name:        config.Name(),
+				Objectives:  config.Objectives(),
+			},
+			config.Labels(),
+		)
+		expiry = newSeriesExpiry(configTTL(config))
+	}
+
+	newSummary := &Summary{
+		ClientSummary:    clientSummary,
+		ClientSummaryVec: clientSummaryVec,
+
+		// Internals.
+		expiry:     expiry,
+		generation: &seriesGeneration{},
+		rootVec:    clientSummaryVec,
+
+		// Settings.
+		labels: config.Labels(),
+		name:   config.Name(),
+	}
+
+	return newSummary, nil
+}
+
+type Summary struct {
+	// Public.
+	ClientSummary    prometheus.Summary
+	ClientSummaryVec *prometheus.SummaryVec
+
+	// Internals.
+	boundValues []string
+	cache       *childCache
+	expiry      *seriesExpiry
+	generation  *seriesGeneration
+	limiter     *cardinalityLimiter
+	rootVec     *prometheus.SummaryVec
+
+	// Settings.
+	labels []string
+	name   string
+}
+
+// Collector returns the prometheus collector backing the summary, which is the
+// ClientSummaryVec in case the summary has been configured with labels.
+func (s *Summary) Collector() prometheus.Collector {
+	if s.ClientSummaryVec != nil {
+		return s.ClientSummaryVec
+	}
+
+	return s.ClientSummary
+}
+
+// DeleteLabelValues deletes the series of the given label values, if any.
+func (s *Summary) DeleteLabelValues(values ...string) error {
+	if len(s.labels) == 0 {
+		// This error indicates that the summary has not been configured with
+		// labels or all of its label values are already bound.
+		return maskAnyf(invalidConfigError, "summary must be configured")
+	}
+	if len(values) != len(s.labels) {
+		return maskAnyf(invalidConfigError, "%d label values must be given", len(s.labels))
+	}
+
+	s.ClientSummaryVec.DeleteLabelValues(values...)
+	forgetSeries(s.limiter, s.expiry, s.name, metric.BindLabelValues(s.boundValues, values))
+	s.generation.bump()
+
+	return nil
+}
+
+func (s *Summary) Observe(sample float64) error {
+	if len(s.labels) != 0 {
+		// This error indicates that the summary has been configured with labels.
+		// Therefore Summary.ObserveWithLabels must be used.
+		return maskAnyf(invalidConfigError, "summary must be configured")
+	}
+
+	child, err := s.child(nil)
+	if err != nil {
+		return maskAny(err)
+	}
+	if child != nil {
+		child.Observe(sample)
+	}
+
+	return nil
+}
+
+func (s *Summary) ObserveWithLabelMap(sample float64, labels map[string]string) error {
+	values, err := metric.LabelValues(s.labels, labels)
+	if err != nil {
+		return maskAny(err)
+	}
+
+	err = s.ObserveWithLabels(sample, values...)
+	if err != nil {
+		return maskAny(err)
+	}
+
+	return nil
+}
+
+func (s *Summary) ObserveWithLabels(sample float64, values ...string) error {
+	if len(s.labels) == 0 {
+		// This error indicates that the summary has not been configured with
+		// labels. Therefore Summary.Observe must be used.
+		return maskAnyf(invalidConfigError, "summary must be configured")
+	}
+	if len(values) == 0 {
+		return maskAnyf(invalidConfigError, "labels must not be empty")
+	}
+
+	child, err := s.child(values)
+	if err != nil {
+		return maskAny(err)
+	}
+	if child != nil {
+		child.Observe(sample)
+	}
+
+	return nil
+}
+
+// Reset deletes all series of the summary.
+func (s *Summary) Reset() error {
+	if s.rootVec == nil || len(s.boundValues) != 0 {
+		// This error indicates that the summary has not been configured with
+		// labels or has label values bound.
+		return maskAnyf(invalidConfigError, "summary must be configured")
+	}
+
+	s.rootVec.Reset()
+	if s.limiter != nil {
+		s.limiter.forgetAll(s.name)
+	}
+	if s.expiry != nil {
+		s.expiry.reset()
+	}
+	s.generation.bump()
+
+	return nil
+}
+
+// With returns a summary having the given label values bound to the first
+// labels of the summary. The returned summary holds the ClientSummaryVec
+// curried with the given label values. In case values for all labels are given,
+// the returned summary caches the child of its series, which makes it cheap to
+// use in hot paths. The child is resolved again once series of the summary have
+// been deleted, so the returned summary keeps working afterwards.
+func (s *Summary) With(values ...string) (spec.Summary, error) {
+	if len(s.labels) == 0 {
+		// This error indicates that the summary has not been configured with
+		// labels or all of its label values are already bound.
+		return nil, maskAnyf(invalidConfigError, "summary must be configured")
+	}
+	if len(values) == 0 {
+		return nil, maskAnyf(invalidConfigError, "labels must not be empty")
+	}
+	if len(values) > len(s.labels) {
+		return nil, maskAnyf(invalidConfigError, "at most %d label values must be given", len(s.labels))
+	}
+
+	curried := prometheus.Labels{}
+	for i, v := range values {
+		curried[s.labels[i]] = v
+	}
+	clientSummaryVec, err := s.ClientSummaryVec.CurryWith(curried)
+	if err != nil {
+		return nil, maskAny(err)
+	}
+
+	newSummary := &Summary{
+		ClientSummaryVec: clientSummaryVec.(*prometheus.SummaryVec),
+
+		// Internals.
+		boundValues: metric.BindLabelValues(s.boundValues, values),
+		cache:       newChildCache(s.labels, values),
+		expiry:      s.expiry,
+		generation:  s.generation,
+		limiter:     s.limiter,
+		rootVec:     s.rootVec,
+
+		// Settings.
+		labels: s.labels[len(values):],
+		name:   s.name,
+	}
+
+	return newSummary, nil
+}
+
+// child returns the child tracking the series of the given label values, which
+// is the ClientSummary in case it is set. In case the cardinality limits are
+// exceeded, the child tracking the overflow series is returned, or nil in case
+// the series is dropped.
+func (s *Summary) child(values []string) (prometheus.Observer, error) {
+	if s.ClientSummary != nil {
+		return s.ClientSummary, nil
+	}
+
+	// The generation is read before resolving the child, so that the child is
+	// resolved again in case its series is deleted concurrently. Series being
+	// cached have been admitted by the limiter, which only forgets them when
+	// they are deleted.
+	generation := s.generation.load()
+	if s.cache != nil {
+		child, ok := s.cache.get(generation)
+		if ok {
+			if s.expiry != nil {
+				s.expiry.touch(s.boundValues)
+			}
+
+			return child.(prometheus.Observer), nil
+		}
+	}
+
+	if s.limiter != nil && !s.limiter.admit(s.name, metric.BindLabelValues(s.boundValues, values)) {
+		if s.limiter.mode == CardinalityModeDrop {
+			return nil, nil
+		}
+
+		child, err := s.rootVec.GetMetricWithLabelValues(overflowLabelValues(len(s.boundValues) + len(values))...)
+		if err != nil {
+			return nil, maskAny(err)
+		}
+
+		return child, nil
+	}
+	if s.expiry != nil {
+		s.expiry.touch(metric.BindLabelValues(s.boundValues, values))
+	}
+
+	child, err := s.ClientSummaryVec.GetMetricWithLabelValues(values...)
+	if err != nil {
+		return nil, maskAny(err)
+	}
+	if s.cache != nil {
+		s.cache.set(generation, child)
+	}
+
+	return child, nil
+}
+
+// sweep deletes the series of the summary not touched within its TTL before the
+// given time.
+func (s *Summary) sweep(now time.Time) {
+	if s.expiry == nil {
+		return
+	}
+
+	s.expiry.sweep(now, func(values []string) {
+		s.rootVec.DeleteLabelValues(values...)
+		if s.limiter != nil {
+			s.limiter.forget(s.name, values)
+		}
+		s.generation.bump()
+	})
+}
@@ -2,13 +2,33 @@ package spec
 
 // Consumer represents a service to abstract instrumentation libraries to fetch
 // application metrics.
-//
-// TODO extend with useful functionality.
 type Consumer interface {
 	// Boot initializes and starts the whole service like booting a machine. The
 	// call to Boot blocks until the service is completely initialized, so you
 	// might want to call it in a separate goroutine.
 	Boot()
+	// Counter returns the current value of the counter registered under the
+	// given name. The given label values identify the counter's partition in
+	// case it has been configured with labels. They have to be given in the
+	// order of the configured labels.
+	Counter(name string, values ...string) (float64, error)
+	// Gauge returns the current value of the gauge registered under the given
+	// name. The given label values identify the gauge's partition in case it has
+	// been configured with labels. They have to be given in the order of the
+	// configured labels.
+	Gauge(name string, values ...string) (float64, error)
+	// Histogram returns the current state of the histogram registered under the
+	// given name. The given label values identify the histogram's partition in
+	// case it has been configured with labels. They have to be given in the
+	// order of the configured labels.
+	Histogram(name string, values ...string) (HistogramSample, error)
+	// Names returns the ordered names of all metrics known to the consumer.
+	Names() ([]string, error)
+	// Summary returns the current state of the summary registered under the
+	// given name. The given label values identify the summary's partition in
+	// case it has been configured with labels. They have to be given in the
+	// order of the configured labels.
+	Summary(name string, values ...string) (SummarySample, error)
 	// Shutdown ends all processes of the service like shutting down a machine.
 	// The call to Shutdown blocks until the service is completely shut down, so
 	// you might want to call it in a separate goroutine.
@@ -2,11 +2,35 @@ package spec
 
 // Counter is a metric that can be arbitrarily incremented.
 type Counter interface {
+	// DeleteLabelValues deletes the series of the given label values, so that
+	// it is not reported anymore until it is used again. The label values must
+	// be given for all labels the counter has been configured with, except the
+	// ones bound using With.
+	DeleteLabelValues(values ...string) error
 	// Increment increments the current counter by the given delta.
 	Increment(delta float64) error
 	IncrementWithLabels(delta float64, values ...string) error
+	// IncrementWithLabelMap is like IncrementWithLabels, but takes the label
+	// values keyed by their label names. The keys must match the configured
+	// labels exactly. Otherwise an error asserted by IsMissingLabel or
+	// IsUnknownLabel of the publisher's package is returned.
+	IncrementWithLabelMap(delta float64, labels map[string]string) error
+	// Reset deletes all series of the counter. It must not be used on counters
+	// returned by With.
+	Reset() error
+	// With returns a counter having the given label values bound to the first
+	// labels the counter has been configured with. In case values for all labels
+	// are given, Increment must be used on the returned counter. Otherwise the
+	// label values of the remaining labels must be given to the returned
+	// counter. Binding label values up front avoids resolving the same series on
+	// every call in hot paths.
+	With(values ...string) (Counter, error)
 }
 
+// CounterConfig represents the settings of a counter all publishers support.
+// Publishers may provide additional settings on their concrete config types,
+// e.g. the TTL of series of the prometheus publisher. These are not part of
+// the spec, since the other publishers do not support them.
 type CounterConfig interface {
 	Help() string
 	Labels() []string
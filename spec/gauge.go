@@ -6,13 +6,41 @@ type Gauge interface {
 	// Decrement decrements the current gauge by the given delta.
 	Decrement(delta float64) error
 	DecrementWithLabels(delta float64, values ...string) error
+	// DecrementWithLabelMap is like DecrementWithLabels, but takes the label
+	// values keyed by their label names. The keys must match the configured
+	// labels exactly. Otherwise an error asserted by IsMissingLabel or
+	// IsUnknownLabel of the publisher's package is returned.
+	DecrementWithLabelMap(delta float64, labels map[string]string) error
+	// DeleteLabelValues deletes the series of the given label values, so that
+	// it is not reported anymore until it is used again. The label values must
+	// be given for all labels the gauge has been configured with, except the
+	// ones bound using With.
+	DeleteLabelValues(values ...string) error
 	// Increment increments the current gauge by the given delta.
 	Increment(delta float64) error
 	IncrementWithLabels(delta float64, values ...string) error
+	// IncrementWithLabelMap is like IncrementWithLabels, but takes the label
+	// values keyed by their label names.
+	IncrementWithLabelMap(delta float64, labels map[string]string) error
 	Set(value float64) error
 	SetWithLabels(value float64, values ...string) error
+	// SetWithLabelMap is like SetWithLabels, but takes the label values keyed
+	// by their label names.
+	SetWithLabelMap(value float64, labels map[string]string) error
+	// Reset deletes all series of the gauge. It must not be used on gauges
+	// returned by With.
+	Reset() error
+	// With returns a gauge having the given label values bound to the first
+	// labels the gauge has been configured with. In case values for all labels
+	// are given, Decrement, Increment and Set must be used on the returned
+	// gauge. Otherwise the label values of the remaining labels must be given
+	// to the returned gauge. Binding label values up front avoids resolving the
+	// same series on every call in hot paths.
+	With(values ...string) (Gauge, error)
 }
 
+// GaugeConfig represents the settings of a gauge all publishers support. See
+// CounterConfig for settings provided by single publishers only.
 type GaugeConfig interface {
 	Help() string
 	Labels() []string
@@ -2,19 +2,107 @@ package spec
 
 // Histogram is a metric to observe samples over time.
 type Histogram interface {
+	// DeleteLabelValues deletes the series of the given label values, so that
+	// it is not reported anymore until it is used again. The label values must
+	// be given for all labels the histogram has been configured with, except the
+	// ones bound using With.
+	DeleteLabelValues(values ...string) error
 	// Observe tracks the given sample used for aggregation of the current
 	// histogramm.
 	Observe(sample float64) error
 	ObserveWithLabels(sample float64, values ...string) error
+	// ObserveWithLabelMap is like ObserveWithLabels, but takes the label
+	// values keyed by their label names. The keys must match the configured
+	// labels exactly. Otherwise an error asserted by IsMissingLabel or
+	// IsUnknownLabel of the publisher's package is returned.
+	ObserveWithLabelMap(sample float64, labels map[string]string) error
+	// Reset deletes all series of the histogram. It must not be used on histograms
+	// returned by With.
+	Reset() error
+	// StartTimer starts a Timer observing the measured duration using Observe.
+	StartTimer() Timer
+	// StartTimerWithLabels starts a Timer observing the measured duration using
+	// ObserveWithLabels and the given label values.
+	StartTimerWithLabels(values ...string) Timer
+	// With returns a histogram having the given label values bound to the first
+	// labels the histogram has been configured with. In case values for all labels
+	// are given, Observe must be used on the returned histogram. Otherwise the
+	// label values of the remaining labels must be given to the returned
+	// histogram. Binding label values up front avoids resolving the same series on
+	// every call in hot paths.
+	With(values ...string) (Histogram, error)
 }
 
+// HistogramConfig represents the settings of a histogram all publishers
+// support. See CounterConfig for settings provided by single publishers only.
 type HistogramConfig interface {
 	Buckets() []float64
 	Help() string
 	Labels() []string
 	Name() string
+	// NativeBucketFactor returns the growth factor of the buckets of the native
+	// histogram, also known as sparse or exponential histogram, which is
+	// maintained next to the buckets returned by Buckets. Native histograms are
+	// disabled in case it is 0, which is the default. Otherwise it must be
+	// greater than 1. The schema of the native histogram is chosen so that the
+	// upper bound of each bucket is at most the given factor times its lower
+	// bound, e.g. 1.1 results in schema 3.
+	NativeBucketFactor() float64
+	// NativeMaxBucketNumber returns the maximum number of buckets of a series of
+	// the native histogram. In case it is exceeded, the width of all buckets of
+	// the series is doubled. There is no limit in case it is 0.
+	NativeMaxBucketNumber() uint32
+	// NativeZeroThreshold returns the width of the zero bucket of the native
+	// histogram. Samples whose absolute value is at most the threshold are
+	// counted in the zero bucket. In case it is 0, the default of 2^-128 is
+	// used.
+	NativeZeroThreshold() float64
 	SetBuckets([]float64)
 	SetHelp(string)
 	SetLabels([]string)
 	SetName(string)
+	SetNativeBucketFactor(float64)
+	SetNativeMaxBucketNumber(uint32)
+	SetNativeZeroThreshold(float64)
+	SetUnit(string)
+	// Unit returns the unit of the observed durations, which is one of
+	// UnitMilliseconds and UnitSeconds. The unit is appended to the name of the
+	// histogram, unless the name already ends with it. Buckets are given in the
+	// same unit. Histograms not tracking durations have no unit, which is the
+	// empty string.
+	Unit() string
+}
+
+// HistogramSample represents the state of a histogram at the time it has been
+// fetched by a Consumer.
+type HistogramSample interface {
+	// Buckets returns the upper bounds of the histogram's buckets mapped to the
+	// cumulative number of samples observed in them.
+	Buckets() map[float64]uint64
+	// Count returns the number of all observed samples.
+	Count() uint64
+	// NativeNegativeBuckets returns the number of samples counted in the negative
+	// buckets of the native histogram, keyed by the index of the bucket. The
+	// bucket having index i counts the samples whose absolute value is within
+	// (base^(i-1), base^i], where base is 2^(2^-NativeSchema). Empty buckets
+	// may be omitted, so the spans of populated buckets are given by the
+	// indexes. It is empty in case native histograms are disabled.
+	NativeNegativeBuckets() map[int]uint64
+	// NativePositiveBuckets returns the number of samples counted in the positive
+	// buckets of the native histogram, keyed by the index of the bucket like
+	// NativeNegativeBuckets.
+	NativePositiveBuckets() map[int]uint64
+	// NativeSchema returns the schema of the native histogram, which defines
+	// the width of its buckets. It may be lower than the schema chosen by the
+	// configured bucket factor in case the maximum number of buckets has been
+	// exceeded.
+	NativeSchema() int32
+	// NativeZeroCount returns the number of samples counted in the zero bucket
+	// of the native histogram.
+	NativeZeroCount() uint64
+	// NativeZeroThreshold returns the width of the zero bucket of the native
+	// histogram.
+	NativeZeroThreshold() float64
+	// Sum returns the sum of all observed samples.
+	Sum() float64
 }
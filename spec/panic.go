@@ -0,0 +1,14 @@
+package spec
+
+const (
+	// PanicModeNone causes panics of actions wrapped by a Publisher to tear
+	// through without being recovered or recorded.
+	PanicModeNone = "none"
+	// PanicModeRecover causes panics of actions wrapped by a Publisher to be
+	// recovered and recorded. The wrapped function returns an error describing
+	// the panic and its stack instead.
+	PanicModeRecover = "recover"
+	// PanicModeRepanic causes panics of actions wrapped by a Publisher to be
+	// recovered and recorded. Afterwards the panic is continued.
+	PanicModeRepanic = "repanic"
+)
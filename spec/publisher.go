@@ -1,6 +1,7 @@
 package spec
 
 import (
+	"context"
 	"net/http"
 )
 
@@ -19,8 +20,8 @@ type Publisher interface {
 	// exist for the given key, one is created.
 	Gauge(config GaugeConfig) (Gauge, error)
 	GaugeConfig() GaugeConfig
-	// Gauge provides a Gauge for the given key. In case there does no counter
-	// exist for the given key, one is created.
+	// Histogram provides a Histogram for the given key. In case there does no
+	// histogram exist for the given key, one is created.
 	Histogram(config HistogramConfig) (Histogram, error)
 	HistogramConfig() HistogramConfig
 	// HTTPEndpoint returns the instrumentor's metric endpoint supposed to be
@@ -54,7 +55,52 @@ type Publisher interface {
 	//         Holds the action's error count. This metric is emitted for each
 	//         error returned by the given action.
 	//
+	//     <prefix>_<key>_panic_total
+	//
+	//         Holds the action's panic count. This metric is emitted for each
+	//         panic of the given action in case the configured panic mode is
+	//         PanicModeRecover or PanicModeRepanic.
+	//
 	WrapFunc(key string, action func() error) func() error
+	// WrapContextFunc wraps basic instrumentation around the given context
+	// aware action. In contrast to WrapFunc, the emitted metrics are partitioned
+	// by the given static labels and by the outcome of the action. That way a
+	// single key can cover many operations distinguished by their labels.
+	//
+	// The wrapped action causes the following metric's to be emitted. <prefix>
+	// is described by the configured prefix of the current instrumentor. The
+	// metrics are labelled with the names of the given static labels in
+	// alphabetical order, followed by the outcome label.
+	//
+	//     <prefix>_<key>_milliseconds
+	//
+	//         Holds the action's duration in milliseconds. This metric is
+	//         emitted for each executed action.
+	//
+	//     <prefix>_<key>_error_total
+	//
+	//         Holds the action's error count. This metric is emitted for each
+	//         error returned by the given action.
+	//
+	//     <prefix>_<key>_panic_total
+	//
+	//         Holds the action's panic count. This metric is emitted for each
+	//         panic of the given action in case the configured panic mode is
+	//         PanicModeRecover or PanicModeRepanic.
+	//
+	// The outcome label holds one of the following values.
+	//
+	//     success             the action returned no error
+	//     canceled            the action's context has been canceled
+	//     deadline_exceeded   the action's context deadline has been exceeded
+	//     error               the action returned any other error
+	//     panic               the action panicked
+	//
+	WrapContextFunc(key string, labels map[string]string, action func(ctx context.Context) error) func(ctx context.Context) error
+	// Summary provides a Summary for the given key. In case there does no
+	// summary exist for the given key, one is created.
+	Summary(config SummaryConfig) (Summary, error)
+	SummaryConfig() SummaryConfig
 	// Shutdown ends all processes of the service like shutting down a machine.
 	// The call to Shutdown blocks until the service is completely shut down, so
 	// you might want to call it in a separate goroutine.
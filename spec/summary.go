@@ -0,0 +1,63 @@
+package spec
+
+import (
+	"time"
+)
+
+// Summary is a metric to observe samples over time and to estimate configured
+// quantiles of the samples observed within a sliding time window.
+type Summary interface {
+	// DeleteLabelValues deletes the series of the given label values, so that
+	// it is not reported anymore until it is used again. The label values must
+	// be given for all labels the summary has been configured with, except the
+	// ones bound using With.
+	DeleteLabelValues(values ...string) error
+	// Observe tracks the given sample used for aggregation of the current
+	// summary.
+	Observe(sample float64) error
+	ObserveWithLabels(sample float64, values ...string) error
+	// ObserveWithLabelMap is like ObserveWithLabels, but takes the label
+	// values keyed by their label names. The keys must match the configured
+	// labels exactly. Otherwise an error asserted by IsMissingLabel or
+	// IsUnknownLabel of the publisher's package is returned.
+	ObserveWithLabelMap(sample float64, labels map[string]string) error
+	// Reset deletes all series of the summary. It must not be used on summarys
+	// returned by With.
+	Reset() error
+	// With returns a summary having the given label values bound to the first
+	// labels the summary has been configured with. In case values for all labels
+	// are given, Observe must be used on the returned summary. Otherwise the
+	// label values of the remaining labels must be given to the returned
+	// summary. Binding label values up front avoids resolving the same series on
+	// every call in hot paths.
+	With(values ...string) (Summary, error)
+}
+
+// SummaryConfig represents the settings of a summary all publishers support.
+// See CounterConfig for settings provided by single publishers only.
+type SummaryConfig interface {
+	AgeBuckets() uint32
+	Help() string
+	Labels() []string
+	MaxAge() time.Duration
+	Name() string
+	Objectives() map[float64]float64
+	SetAgeBuckets(uint32)
+	SetHelp(string)
+	SetLabels([]string)
+	SetMaxAge(time.Duration)
+	SetName(string)
+	SetObjectives(map[float64]float64)
+}
+
+// SummarySample represents the state of a summary at the time it has been
+// fetched by a Consumer.
+type SummarySample interface {
+	// Count returns the number of all observed samples.
+	Count() uint64
+	// Quantiles returns the configured quantiles mapped to their estimated
+	// values.
+	Quantiles() map[float64]float64
+	// Sum returns the sum of all observed samples.
+	Sum() float64
+}
@@ -0,0 +1,21 @@
+package spec
+
+import (
+	"time"
+)
+
+// Timer measures the duration of an operation and observes it using the
+// Histogram it has been started with.
+type Timer interface {
+	// ObserveDuration observes the duration since the timer has been started and
+	// returns it. The duration is converted to the unit of the histogram without
+	// losing precision. Durations of histograms without unit are observed in
+	// seconds.
+	ObserveDuration() (time.Duration, error)
+	// Stop is like ObserveDuration, but ignores the duration and any error. It
+	// is meant to be deferred.
+	//
+	//     defer histogram.StartTimer().Stop()
+	//
+	Stop()
+}
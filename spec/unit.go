@@ -0,0 +1,10 @@
+package spec
+
+const (
+	// UnitMilliseconds causes a histogram to observe durations in milliseconds.
+	// The name of the histogram gets the _milliseconds suffix.
+	UnitMilliseconds = "milliseconds"
+	// UnitSeconds causes a histogram to observe durations in seconds. The name
+	// of the histogram gets the _seconds suffix.
+	UnitSeconds = "seconds"
+)
@@ -0,0 +1,72 @@
+// Package consumer implements github.com/the-anna-project/instrumentor.Consumer
+// for the StatsD kind. Metrics sent to a StatsD agent cannot be read back, so
+// all queries return an error asserted by IsNotSupported.
+package consumer
+
+import (
+	"sync"
+
+	"github.com/the-anna-project/instrumentor/spec"
+)
+
+// ServiceConfig represents the configuration used to create a new StatsD
+// consumer service.
+type ServiceConfig struct {
+}
+
+// DefaultServiceConfig provides a default configuration to create a new StatsD
+// consumer service by best effort.
+func DefaultServiceConfig() ServiceConfig {
+	return ServiceConfig{}
+}
+
+// NewService creates a new StatsD consumer service.
+func NewService(config ServiceConfig) (*Service, error) {
+	newService := &Service{
+		// Internals.
+		bootOnce:     sync.Once{},
+		closer:       make(chan struct{}, 1),
+		shutdownOnce: sync.Once{},
+	}
+
+	return newService, nil
+}
+
+type Service struct {
+	// Internals.
+	bootOnce     sync.Once
+	closer       chan struct{}
+	shutdownOnce sync.Once
+}
+
+func (s *Service) Boot() {
+	s.bootOnce.Do(func() {
+		// Service specific boot logic goes here.
+	})
+}
+
+func (s *Service) Counter(name string, values ...string) (float64, error) {
+	return 0, maskAnyf(notSupportedError, "reading StatsD counters")
+}
+
+func (s *Service) Gauge(name string, values ...string) (float64, error) {
+	return 0, maskAnyf(notSupportedError, "reading StatsD gauges")
+}
+
+func (s *Service) Histogram(name string, values ...string) (spec.HistogramSample, error) {
+	return nil, maskAnyf(notSupportedError, "reading StatsD histograms")
+}
+
+func (s *Service) Names() ([]string, error) {
+	return nil, maskAnyf(notSupportedError, "reading StatsD metric names")
+}
+
+func (s *Service) Shutdown() {
+	s.shutdownOnce.Do(func() {
+		close(s.closer)
+	})
+}
+
+func (s *Service) Summary(name string, values ...string) (spec.SummarySample, error) {
+	return nil, maskAnyf(notSupportedError, "reading StatsD summaries")
+}
@@ -0,0 +1,238 @@
+package publisher
+
+import (
+	"bytes"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const (
+	// TypeCounter is the StatsD type of counters.
+	TypeCounter = "c"
+	// TypeGauge is the StatsD type of gauges.
+	TypeGauge = "g"
+	// TypeHistogram is the StatsD type of histograms.
+	TypeHistogram = "h"
+	// TypeTiming is the StatsD type of timers. Samples are expected to be
+	// milliseconds.
+	TypeTiming = "ms"
+)
+
+// ClientConfig represents the configuration used to create a new StatsD
+// client.
+type ClientConfig struct {
+	// Settings.
+
+	// Address represents the UDP address of the StatsD agent metrics are sent
+	// to.
+	Address string
+	// ConstLabels represents labels added as DogStatsD tags to every line sent
+	// by the client.
+	ConstLabels map[string]string
+	// MaxPacketSize represents the maximum number of bytes sent within a single
+	// UDP packet. Lines are buffered until the next line would exceed the packet
+	// size. A single line exceeding the packet size is sent on its own.
+	MaxPacketSize int
+}
+
+// DefaultClientConfig provides a default configuration to create a new StatsD
+// client by best effort.
+func DefaultClientConfig() ClientConfig {
+	return ClientConfig{
+		// Settings.
+		Address:     "127.0.0.1:8125",
+		ConstLabels: map[string]string{},
+		// 1432 bytes fit into a single ethernet frame together with the IP and UDP
+		// headers.
+		MaxPacketSize: 1432,
+	}
+}
+
+// NewClient creates a new configured StatsD client.
+func NewClient(config ClientConfig) (*Client, error) {
+	// Settings.
+	if config.Address == "" {
+		return nil, maskAnyf(invalidConfigError, "address must not be empty")
+	}
+	if config.MaxPacketSize < 1 {
+		return nil, maskAnyf(invalidConfigError, "max packet size must be greater than 0")
+	}
+
+	conn, err := net.Dial("udp", config.Address)
+	if err != nil {
+		return nil, maskAny(err)
+	}
+
+	var names []string
+	for n := range config.ConstLabels {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	var values []string
+	for _, n := range names {
+		values = append(values, config.ConstLabels[n])
+	}
+
+	newClient := &Client{
+		// Internals.
+		buffer: bytes.Buffer{},
+		conn:   conn,
+		mutex:  sync.Mutex{},
+
+		// Settings.
+		constTags:     formatTags(names, values),
+		maxPacketSize: config.MaxPacketSize,
+	}
+
+	return newClient, nil
+}
+
+// Client buffers StatsD lines and sends them in packets to the configured
+// StatsD agent. All methods are safe for concurrent use.
+type Client struct {
+	// Internals.
+	buffer bytes.Buffer
+	conn   net.Conn
+	mutex  sync.Mutex
+
+	// Settings.
+
+	// constTags represents the formatted constant labels, ordered by their
+	// names.
+	constTags     string
+	maxPacketSize int
+}
+
+// Close flushes all buffered lines and closes the client's connection.
+func (c *Client) Close() error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	err := c.flush()
+	if err != nil {
+		c.conn.Close()
+		return maskAny(err)
+	}
+
+	err = c.conn.Close()
+	if err != nil {
+		return maskAny(err)
+	}
+
+	return nil
+}
+
+// Flush sends all buffered lines.
+func (c *Client) Flush() error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	err := c.flush()
+	if err != nil {
+		return maskAny(err)
+	}
+
+	return nil
+}
+
+// Send buffers a line for the metric with the given name, value and StatsD
+// type. The given label names and values are added as DogStatsD tags, followed
+// by the client's constant labels. In case
+// the buffered lines would exceed the maximum packet size, they are sent
+// first.
+func (c *Client) Send(name string, value string, statsdType string, labels []string, values []string) error {
+	line := formatLine(name, value, statsdType, formatTags(labels, values), c.constTags)
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if c.buffer.Len() > 0 && c.buffer.Len()+1+len(line) > c.maxPacketSize {
+		err := c.flush()
+		if err != nil {
+			return maskAny(err)
+		}
+	}
+
+	if c.buffer.Len() > 0 {
+		c.buffer.WriteByte('\n')
+	}
+	c.buffer.WriteString(line)
+
+	return nil
+}
+
+// flush must only be called while holding the mutex.
+func (c *Client) flush() error {
+	if c.buffer.Len() == 0 {
+		return nil
+	}
+
+	_, err := c.conn.Write(c.buffer.Bytes())
+	c.buffer.Reset()
+	if err != nil {
+		return maskAny(err)
+	}
+
+	return nil
+}
+
+// formatFloat formats the given value using as few digits as necessary.
+func formatFloat(value float64) string {
+	return strconv.FormatFloat(value, 'f', -1, 64)
+}
+
+// formatLine formats a single StatsD line as follows, given the formatted tags
+// of the metric's labels and the formatted constant tags.
+//
+//	<name>:<value>|<type>|#<label>:<value>,<label>:<value>
+func formatLine(name string, value string, statsdType string, tags string, constTags string) string {
+	var b bytes.Buffer
+
+	b.WriteString(nameReplacer.Replace(name))
+	b.WriteByte(':')
+	b.WriteString(value)
+	b.WriteByte('|')
+	b.WriteString(statsdType)
+
+	if tags != "" || constTags != "" {
+		b.WriteString("|#")
+		b.WriteString(tags)
+		if tags != "" && constTags != "" {
+			b.WriteByte(',')
+		}
+		b.WriteString(constTags)
+	}
+
+	return b.String()
+}
+
+// formatTags formats the given label names and values as comma separated
+// DogStatsD tags.
+func formatTags(labels []string, values []string) string {
+	var b bytes.Buffer
+
+	for i, l := range labels {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(tagReplacer.Replace(l))
+		b.WriteByte(':')
+		if i < len(values) {
+			b.WriteString(tagReplacer.Replace(values[i]))
+		}
+	}
+
+	return b.String()
+}
+
+var (
+	// nameReplacer replaces characters having a special meaning in the StatsD
+	// line protocol.
+	nameReplacer = strings.NewReplacer(":", "_", "|", "_", "@", "_", "\n", "_")
+	// tagReplacer replaces characters having a special meaning in DogStatsD
+	// tags.
+	tagReplacer = strings.NewReplacer(",", "_", "|", "_", "#", "_", "\n", "_")
+)
@@ -0,0 +1,210 @@
+package publisher
+
+import (
+	"github.com/the-anna-project/instrumentor/internal/metric"
+	"github.com/the-anna-project/instrumentor/spec"
+)
+
+// CounterConfig represents the configuration used to create a new StatsD
+// publisher counter object.
+type CounterConfig struct {
+	// Settings.
+	help   string
+	labels []string
+	name   string
+}
+
+func (cc *CounterConfig) Help() string {
+	return cc.help
+}
+
+func (cc *CounterConfig) Labels() []string {
+	return cc.labels
+}
+
+func (cc *CounterConfig) Name() string {
+	return cc.name
+}
+
+func (cc *CounterConfig) SetHelp(help string) {
+	cc.help = help
+}
+
+func (cc *CounterConfig) SetLabels(labels []string) {
+	cc.labels = labels
+}
+
+func (cc *CounterConfig) SetName(name string) {
+	cc.name = name
+}
+
+// DefaultCounterConfig provides a default configuration to create a new StatsD
+// publisher counter object by best effort.
+func DefaultCounterConfig() *CounterConfig {
+	return &CounterConfig{
+		// Settings.
+		help:   "",
+		labels: nil,
+		name:   "",
+	}
+}
+
+// NewCounter creates a new configured StatsD publisher counter object.
+func NewCounter(config spec.CounterConfig) (*Counter, error) {
+	return newCounterWithConstLabels(config, nil)
+}
+
+// newCounterWithConstLabels creates a new configured StatsD publisher counter
+// object having the given constant labels. They are only used to validate the
+// labels of the counter, since the client adds them to every line it sends.
+func newCounterWithConstLabels(config spec.CounterConfig, constLabels map[string]string) (*Counter, error) {
+	// Settings.
+	if config.Name() == "" {
+		return nil, maskAnyf(invalidConfigError, "name must not be empty")
+	}
+	err := metric.ValidateName(config.Name())
+	if err != nil {
+		return nil, maskAny(err)
+	}
+	err = metric.ValidateLabels(config.Labels(), constLabels)
+	if err != nil {
+		return nil, maskAny(err)
+	}
+
+	newCounter := &Counter{
+		// Settings.
+		labels: config.Labels(),
+		name:   config.Name(),
+	}
+
+	return newCounter, nil
+}
+
+type Counter struct {
+	// Public.
+
+	// Client is used to send the counter's increments. It is set by the
+	// service creating the counter.
+	Client *Client
+
+	// Settings.
+	boundValues []string
+	labels      []string
+	name        string
+}
+
+// DeleteLabelValues only validates the given label values, since StatsD
+// agents aggregate and expire series on their own.
+func (c *Counter) DeleteLabelValues(values ...string) error {
+	labels := c.unboundLabels()
+	if len(labels) == 0 {
+		// This error indicates that the counter has not been configured with
+		// labels or all of its label values are already bound.
+		return maskAnyf(invalidConfigError, "counter must be configured")
+	}
+	if len(values) != len(labels) {
+		return maskAnyf(invalidConfigError, "%d label values must be given", len(labels))
+	}
+
+	return nil
+}
+
+func (c *Counter) Increment(delta float64) error {
+	if len(c.unboundLabels()) != 0 {
+		// This error indicates that the counter has been configured with labels.
+		// Therefore Counter.IncrementWithLabels must be used.
+		return maskAnyf(invalidConfigError, "counter must be configured")
+	}
+	if delta < 0 {
+		return maskAnyf(invalidConfigError, "counter %s cannot decrease in value", c.name)
+	}
+
+	err := c.Client.Send(c.name, formatFloat(delta), TypeCounter, c.labels, c.boundValues)
+	if err != nil {
+		return maskAny(err)
+	}
+
+	return nil
+}
+
+func (c *Counter) IncrementWithLabelMap(delta float64, labels map[string]string) error {
+	values, err := metric.LabelValues(c.unboundLabels(), labels)
+	if err != nil {
+		return maskAny(err)
+	}
+
+	err = c.IncrementWithLabels(delta, values...)
+	if err != nil {
+		return maskAny(err)
+	}
+
+	return nil
+}
+
+func (c *Counter) IncrementWithLabels(delta float64, values ...string) error {
+	if len(c.unboundLabels()) == 0 {
+		// This error indicates that the counter has not been configured with
+		// labels. Therefore Counter.Increment must be used.
+		return maskAnyf(invalidConfigError, "counter must be configured")
+	}
+	if len(values) != len(c.unboundLabels()) {
+		return maskAnyf(invalidConfigError, "%d label values must be given", len(c.unboundLabels()))
+	}
+	if delta < 0 {
+		return maskAnyf(invalidConfigError, "counter %s cannot decrease in value", c.name)
+	}
+
+	err := c.Client.Send(c.name, formatFloat(delta), TypeCounter, c.labels, metric.BindLabelValues(c.boundValues, values))
+	if err != nil {
+		return maskAny(err)
+	}
+
+	return nil
+}
+
+// Reset does nothing, since StatsD agents aggregate and expire series on their
+// own.
+func (c *Counter) Reset() error {
+	if len(c.labels) == 0 || len(c.boundValues) != 0 {
+		// This error indicates that the counter has not been configured with
+		// labels or has label values bound.
+		return maskAnyf(invalidConfigError, "counter must be configured")
+	}
+
+	return nil
+}
+
+// With returns a counter having the given label values bound to the first
+// labels of the counter.
+func (c *Counter) With(values ...string) (spec.Counter, error) {
+	labels := c.unboundLabels()
+	if len(labels) == 0 {
+		// This error indicates that the counter has not been configured with
+		// labels or all of its label values are already bound.
+		return nil, maskAnyf(invalidConfigError, "counter must be configured")
+	}
+	if len(values) == 0 {
+		return nil, maskAnyf(invalidConfigError, "labels must not be empty")
+	}
+	if len(values) > len(labels) {
+		return nil, maskAnyf(invalidConfigError, "at most %d label values must be given", len(labels))
+	}
+
+	newCounter := &Counter{
+		// Public.
+		Client: c.Client,
+
+		// Settings.
+		boundValues: metric.BindLabelValues(c.boundValues, values),
+		labels:      c.labels,
+		name:        c.name,
+	}
+
+	return newCounter, nil
+}
+
+// unboundLabels returns the labels of the counter whose values are not yet
+// bound.
+func (c *Counter) unboundLabels() []string {
+	return c.labels[len(c.boundValues):]
+}
@@ -0,0 +1,342 @@
+package publisher
+
+import (
+	"github.com/the-anna-project/instrumentor/internal/metric"
+	"github.com/the-anna-project/instrumentor/spec"
+)
+
+// GaugeConfig represents the configuration used to create a new StatsD
+// publisher gauge.
+type GaugeConfig struct {
+	// Settings.
+	help   string
+	labels []string
+	name   string
+}
+
+func (gc *GaugeConfig) Help() string {
+	return gc.help
+}
+
+func (gc *GaugeConfig) Labels() []string {
+	return gc.labels
+}
+
+func (gc *GaugeConfig) Name() string {
+	return gc.name
+}
+
+func (gc *GaugeConfig) SetHelp(help string) {
+	gc.help = help
+}
+
+func (gc *GaugeConfig) SetLabels(labels []string) {
+	gc.labels = labels
+}
+
+func (gc *GaugeConfig) SetName(name string) {
+	gc.name = name
+}
+
+// DefaultGaugeConfig provides a default configuration to create a new StatsD
+// publisher gauge by best effort.
+func DefaultGaugeConfig() *GaugeConfig {
+	return &GaugeConfig{
+		// Settings.
+		help:   "",
+		labels: nil,
+		name:   "",
+	}
+}
+
+// NewGauge creates a new configured StatsD publisher gauge.
+func NewGauge(config spec.GaugeConfig) (*Gauge, error) {
+	return newGaugeWithConstLabels(config, nil)
+}
+
+// newGaugeWithConstLabels creates a new configured StatsD publisher gauge
+// having the given constant labels. They are only used to validate the labels
+// of the gauge, since the client adds them to every line it sends.
+func newGaugeWithConstLabels(config spec.GaugeConfig, constLabels map[string]string) (*Gauge, error) {
+	// Settings.
+	if config.Name() == "" {
+		return nil, maskAnyf(invalidConfigError, "name must not be empty")
+	}
+	err := metric.ValidateName(config.Name())
+	if err != nil {
+		return nil, maskAny(err)
+	}
+	err = metric.ValidateLabels(config.Labels(), constLabels)
+	if err != nil {
+		return nil, maskAny(err)
+	}
+
+	newGauge := &Gauge{
+		// Settings.
+		labels: config.Labels(),
+		name:   config.Name(),
+	}
+
+	return newGauge, nil
+}
+
+type Gauge struct {
+	// Public.
+
+	// Client is used to send the gauge's values. It is set by the service
+	// creating the gauge.
+	Client *Client
+
+	// Settings.
+	boundValues []string
+	labels      []string
+	name        string
+}
+
+func (g *Gauge) Decrement(delta float64) error {
+	if len(g.unboundLabels()) != 0 {
+		// This error indicates that the gauge has been configured with labels.
+		// Therefore Gauge.DecrementWithLabels must be used.
+		return maskAnyf(invalidConfigError, "gauge must be configured")
+	}
+
+	err := g.add(-delta, g.boundValues)
+	if err != nil {
+		return maskAny(err)
+	}
+
+	return nil
+}
+
+func (g *Gauge) DecrementWithLabelMap(delta float64, labels map[string]string) error {
+	values, err := metric.LabelValues(g.unboundLabels(), labels)
+	if err != nil {
+		return maskAny(err)
+	}
+
+	err = g.DecrementWithLabels(delta, values...)
+	if err != nil {
+		return maskAny(err)
+	}
+
+	return nil
+}
+
+func (g *Gauge) DecrementWithLabels(delta float64, values ...string) error {
+	if len(g.unboundLabels()) == 0 {
+		// This error indicates that the gauge has not been configured with labels.
+		// Therefore Gauge.Decrement must be used.
+		return maskAnyf(invalidConfigError, "gauge must be configured")
+	}
+	if len(values) != len(g.unboundLabels()) {
+		return maskAnyf(invalidConfigError, "%d label values must be given", len(g.unboundLabels()))
+	}
+
+	err := g.add(-delta, metric.BindLabelValues(g.boundValues, values))
+	if err != nil {
+		return maskAny(err)
+	}
+
+	return nil
+}
+
+// DeleteLabelValues only validates the given label values, since StatsD
+// agents aggregate and expire series on their own.
+func (g *Gauge) DeleteLabelValues(values ...string) error {
+	labels := g.unboundLabels()
+	if len(labels) == 0 {
+		// This error indicates that the gauge has not been configured with
+		// labels or all of its label values are already bound.
+		return maskAnyf(invalidConfigError, "gauge must be configured")
+	}
+	if len(values) != len(labels) {
+		return maskAnyf(invalidConfigError, "%d label values must be given", len(labels))
+	}
+
+	return nil
+}
+
+func (g *Gauge) Increment(delta float64) error {
+	if len(g.unboundLabels()) != 0 {
+		// This error indicates that the gauge has been configured with labels.
+		// Therefore Gauge.IncrementWithLabels must be used.
+		return maskAnyf(invalidConfigError, "gauge must be configured")
+	}
+
+	err := g.add(delta, g.boundValues)
+	if err != nil {
+		return maskAny(err)
+	}
+
+	return nil
+}
+
+func (g *Gauge) IncrementWithLabelMap(delta float64, labels map[string]string) error {
+	values, err := metric.LabelValues(g.unboundLabels(), labels)
+	if err != nil {
+		return maskAny(err)
+	}
+
+	err = g.IncrementWithLabels(delta, values...)
+	if err != nil {
+		return maskAny(err)
+	}
+
+	return nil
+}
+
+func (g *Gauge) IncrementWithLabels(delta float64, values ...string) error {
+	if len(g.unboundLabels()) == 0 {
+		// This error indicates that the gauge has not been configured with labels.
+		// Therefore Gauge.Increment must be used.
+		return maskAnyf(invalidConfigError, "gauge must be configured")
+	}
+	if len(values) != len(g.unboundLabels()) {
+		return maskAnyf(invalidConfigError, "%d label values must be given", len(g.unboundLabels()))
+	}
+
+	err := g.add(delta, metric.BindLabelValues(g.boundValues, values))
+	if err != nil {
+		return maskAny(err)
+	}
+
+	return nil
+}
+
+func (g *Gauge) Set(value float64) error {
+	if len(g.unboundLabels()) != 0 {
+		// This error indicates that the gauge has been configured with labels.
+		// Therefore Gauge.SetWithLabels must be used.
+		return maskAnyf(invalidConfigError, "gauge must be configured")
+	}
+
+	err := g.set(value, g.boundValues)
+	if err != nil {
+		return maskAny(err)
+	}
+
+	return nil
+}
+
+func (g *Gauge) SetWithLabelMap(value float64, labels map[string]string) error {
+	values, err := metric.LabelValues(g.unboundLabels(), labels)
+	if err != nil {
+		return maskAny(err)
+	}
+
+	err = g.SetWithLabels(value, values...)
+	if err != nil {
+		return maskAny(err)
+	}
+
+	return nil
+}
+
+func (g *Gauge) SetWithLabels(value float64, values ...string) error {
+	if len(g.unboundLabels()) == 0 {
+		// This error indicates that the gauge has not been configured with labels.
+		// Therefore Gauge.Set must be used.
+		return maskAnyf(invalidConfigError, "gauge must be configured")
+	}
+	if len(values) != len(g.unboundLabels()) {
+		return maskAnyf(invalidConfigError, "%d label values must be given", len(g.unboundLabels()))
+	}
+
+	err := g.set(value, metric.BindLabelValues(g.boundValues, values))
+	if err != nil {
+		return maskAny(err)
+	}
+
+	return nil
+}
+
+// add sends the given delta as relative gauge value. StatsD treats gauge values
+// having an explicit sign as delta.
+func (g *Gauge) add(delta float64, values []string) error {
+	value := formatFloat(delta)
+	if delta >= 0 {
+		value = "+" + value
+	}
+
+	err := g.Client.Send(g.name, value, TypeGauge, g.labelsFor(values), values)
+	if err != nil {
+		return maskAny(err)
+	}
+
+	return nil
+}
+
+// labelsFor returns the configured labels in case label values are given.
+func (g *Gauge) labelsFor(values []string) []string {
+	if values == nil {
+		return nil
+	}
+
+	return g.labels
+}
+
+// set sends the given value as absolute gauge value. Since StatsD treats
+// negative values as delta, the gauge is reset to 0 before a negative value is
+// subtracted.
+func (g *Gauge) set(value float64, values []string) error {
+	if value < 0 {
+		err := g.Client.Send(g.name, "0", TypeGauge, g.labelsFor(values), values)
+		if err != nil {
+			return maskAny(err)
+		}
+	}
+
+	err := g.Client.Send(g.name, formatFloat(value), TypeGauge, g.labelsFor(values), values)
+	if err != nil {
+		return maskAny(err)
+	}
+
+	return nil
+}
+
+// Reset does nothing, since StatsD agents aggregate and expire series on their
+// own.
+func (g *Gauge) Reset() error {
+	if len(g.labels) == 0 || len(g.boundValues) != 0 {
+		// This error indicates that the gauge has not been configured with
+		// labels or has label values bound.
+		return maskAnyf(invalidConfigError, "gauge must be configured")
+	}
+
+	return nil
+}
+
+// With returns a gauge having the given label values bound to the first
+// labels of the gauge.
+func (g *Gauge) With(values ...string) (spec.Gauge, error) {
+	labels := g.unboundLabels()
+	if len(labels) == 0 {
+		// This error indicates that the gauge has not been configured with
+		// labels or all of its label values are already bound.
+		return nil, maskAnyf(invalidConfigError, "gauge must be configured")
+	}
+	if len(values) == 0 {
+		return nil, maskAnyf(invalidConfigError, "labels must not be empty")
+	}
+	if len(values) > len(labels) {
+		return nil, maskAnyf(invalidConfigError, "at most %d label values must be given", len(labels))
+	}
+
+	newGauge := &Gauge{
+		// Public.
+		Client: g.Client,
+
+		// Settings.
+		boundValues: metric.BindLabelValues(g.boundValues, values),
+		labels:      g.labels,
+		name:        g.name,
+	}
+
+	return newGauge, nil
+}
+
+// unboundLabels returns the labels of the gauge whose values are not yet
+// bound.
+func (g *Gauge) unboundLabels() []string {
+	return g.labels[len(g.boundValues):]
+}
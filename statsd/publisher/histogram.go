@@ -0,0 +1,290 @@
+package publisher
+
+import (
+	"github.com/the-anna-project/instrumentor/internal/metric"
+	"github.com/the-anna-project/instrumentor/spec"
+)
+
+// HistogramConfig represents the configuration used to create a new StatsD
+// publisher histogram.
+//
+// The buckets and the settings of native histograms are not used by the StatsD
+// publisher, because samples are aggregated by the StatsD agent.
+type HistogramConfig struct {
+	// Settings.
+	buckets               []float64
+	help                  string
+	labels                []string
+	name                  string
+	nativeBucketFactor    float64
+	nativeMaxBucketNumber uint32
+	nativeZeroThreshold   float64
+	unit                  string
+}
+
+func (hc *HistogramConfig) Buckets() []float64 {
+	return hc.buckets
+}
+
+func (hc *HistogramConfig) Help() string {
+	return hc.help
+}
+
+func (hc *HistogramConfig) Labels() []string {
+	return hc.labels
+}
+
+func (hc *HistogramConfig) Name() string {
+	return hc.name
+}
+
+func (hc *HistogramConfig) NativeBucketFactor() float64 {
+	return hc.nativeBucketFactor
+}
+
+func (hc *HistogramConfig) NativeMaxBucketNumber() uint32 {
+	return hc.nativeMaxBucketNumber
+}
+
+func (hc *HistogramConfig) NativeZeroThreshold() float64 {
+	return hc.nativeZeroThreshold
+}
+
+func (hc *HistogramConfig) SetBuckets(buckets []float64) {
+	hc.buckets = buckets
+}
+
+func (hc *HistogramConfig) SetHelp(help string) {
+	hc.help = help
+}
+
+func (hc *HistogramConfig) SetLabels(labels []string) {
+	hc.labels = labels
+}
+
+func (hc *HistogramConfig) SetName(name string) {
+	hc.name = name
+}
+
+func (hc *HistogramConfig) SetNativeBucketFactor(factor float64) {
+	hc.nativeBucketFactor = factor
+}
+
+func (hc *HistogramConfig) SetNativeMaxBucketNumber(number uint32) {
+	hc.nativeMaxBucketNumber = number
+}
+
+func (hc *HistogramConfig) SetNativeZeroThreshold(threshold float64) {
+	hc.nativeZeroThreshold = threshold
+}
+
+func (hc *HistogramConfig) SetUnit(unit string) {
+	hc.unit = unit
+}
+
+func (hc *HistogramConfig) Unit() string {
+	return hc.unit
+}
+
+// DefaultHistogramConfig provides a default configuration to create a new
+// StatsD publisher histogram by best effort.
+func DefaultHistogramConfig() *HistogramConfig {
+	return &HistogramConfig{
+		// Settings.
+		buckets:               nil,
+		help:                  "",
+		labels:                nil,
+		name:                  "",
+		nativeBucketFactor:    0,
+		nativeMaxBucketNumber: 0,
+		nativeZeroThreshold:   0,
+		unit:                  "",
+	}
+}
+
+// NewHistogram creates a new configured StatsD publisher histogram.
+func NewHistogram(config spec.HistogramConfig) (*Histogram, error) {
+	return newHistogramWithConstLabels(config, nil)
+}
+
+// newHistogramWithConstLabels creates a new configured StatsD publisher
+// histogram having the given constant labels. They are only used to validate
+// the labels of the histogram, since the client adds them to every line it
+// sends.
+func newHistogramWithConstLabels(config spec.HistogramConfig, constLabels map[string]string) (*Histogram, error) {
+	// Settings.
+	if config.Name() == "" {
+		return nil, maskAnyf(invalidConfigError, "name must not be empty")
+	}
+	err := metric.ValidateName(config.Name())
+	if err != nil {
+		return nil, maskAny(err)
+	}
+	err = metric.ValidateLabels(config.Labels(), constLabels, metric.BucketLabel)
+	if err != nil {
+		return nil, maskAny(err)
+	}
+	if !metric.ValidUnit(config.Unit()) {
+		return nil, maskAnyf(invalidConfigError, "unit must be one of: %s, %s", spec.UnitMilliseconds, spec.UnitSeconds)
+	}
+
+	// Samples of histograms measuring milliseconds are sent as timings, which
+	// StatsD expects to be milliseconds. Samples of histograms measuring seconds
+	// are sent as they are, so they match the _seconds suffix of their name.
+	statsdType := TypeHistogram
+	if config.Unit() == spec.UnitMilliseconds {
+		statsdType = TypeTiming
+	}
+
+	newHistogram := &Histogram{
+		// Public.
+		Type: statsdType,
+
+		// Settings.
+		labels: config.Labels(),
+		name:   metric.UnitName(config.Name(), config.Unit()),
+		unit:   config.Unit(),
+	}
+
+	return newHistogram, nil
+}
+
+type Histogram struct {
+	// Public.
+
+	// Client is used to send the histogram's samples. It is set by the service
+	// creating the histogram.
+	Client *Client
+	// Type represents the StatsD type samples are sent with. It is TypeTiming in
+	// case the histogram has been configured with spec.UnitMilliseconds,
+	// otherwise TypeHistogram.
+	Type string
+
+	// Settings.
+	boundValues []string
+	labels      []string
+	name        string
+	unit        string
+}
+
+// DeleteLabelValues only validates the given label values, since StatsD
+// agents aggregate and expire series on their own.
+func (h *Histogram) DeleteLabelValues(values ...string) error {
+	labels := h.unboundLabels()
+	if len(labels) == 0 {
+		// This error indicates that the histogram has not been configured with
+		// labels or all of its label values are already bound.
+		return maskAnyf(invalidConfigError, "histogram must be configured")
+	}
+	if len(values) != len(labels) {
+		return maskAnyf(invalidConfigError, "%d label values must be given", len(labels))
+	}
+
+	return nil
+}
+
+func (h *Histogram) Observe(sample float64) error {
+	if len(h.unboundLabels()) != 0 {
+		// This error indicates that the histogram has been configured with labels.
+		// Therefore Histogram.ObserveWithLabels must be used.
+		return maskAnyf(invalidConfigError, "histogram must be configured")
+	}
+
+	err := h.Client.Send(h.name, formatFloat(sample), h.Type, h.labels, h.boundValues)
+	if err != nil {
+		return maskAny(err)
+	}
+
+	return nil
+}
+
+func (h *Histogram) ObserveWithLabelMap(sample float64, labels map[string]string) error {
+	values, err := metric.LabelValues(h.unboundLabels(), labels)
+	if err != nil {
+		return maskAny(err)
+	}
+
+	err = h.ObserveWithLabels(sample, values...)
+	if err != nil {
+		return maskAny(err)
+	}
+
+	return nil
+}
+
+func (h *Histogram) ObserveWithLabels(sample float64, values ...string) error {
+	if len(h.unboundLabels()) == 0 {
+		// This error indicates that the histogram has not been configured with
+		// labels. Therefore Histogram.Observe must be used.
+		return maskAnyf(invalidConfigError, "histogram must be configured")
+	}
+	if len(values) != len(h.unboundLabels()) {
+		return maskAnyf(invalidConfigError, "%d label values must be given", len(h.unboundLabels()))
+	}
+
+	err := h.Client.Send(h.name, formatFloat(sample), h.Type, h.labels, metric.BindLabelValues(h.boundValues, values))
+	if err != nil {
+		return maskAny(err)
+	}
+
+	return nil
+}
+
+// Reset does nothing, since StatsD agents aggregate and expire series on their
+// own.
+func (h *Histogram) Reset() error {
+	if len(h.labels) == 0 || len(h.boundValues) != 0 {
+		// This error indicates that the histogram has not been configured with
+		// labels or has label values bound.
+		return maskAnyf(invalidConfigError, "histogram must be configured")
+	}
+
+	return nil
+}
+
+func (h *Histogram) StartTimer() spec.Timer {
+	return metric.NewTimer(h.unit, h.Observe)
+}
+
+func (h *Histogram) StartTimerWithLabels(values ...string) spec.Timer {
+	return metric.NewTimer(h.unit, func(sample float64) error {
+		return h.ObserveWithLabels(sample, values...)
+	})
+}
+
+// With returns a histogram having the given label values bound to the first
+// labels of the histogram.
+func (h *Histogram) With(values ...string) (spec.Histogram, error) {
+	labels := h.unboundLabels()
+	if len(labels) == 0 {
+		// This error indicates that the histogram has not been configured with
+		// labels or all of its label values are already bound.
+		return nil, maskAnyf(invalidConfigError, "histogram must be configured")
+	}
+	if len(values) == 0 {
+		return nil, maskAnyf(invalidConfigError, "labels must not be empty")
+	}
+	if len(values) > len(labels) {
+		return nil, maskAnyf(invalidConfigError, "at most %d label values must be given", len(labels))
+	}
+
+	newHistogram := &Histogram{
+		// Public.
+		Client: h.Client,
+		Type:   h.Type,
+
+		// Settings.
+		boundValues: metric.BindLabelValues(h.boundValues, values),
+		labels:      h.labels,
+		name:        h.name,
+		unit:        h.unit,
+	}
+
+	return newHistogram, nil
+}
+
+// unboundLabels returns the labels of the histogram whose values are not yet
+// bound.
+func (h *Histogram) unboundLabels() []string {
+	return h.labels[len(h.boundValues):]
+}
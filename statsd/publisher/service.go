@@ -0,0 +1,361 @@
+// Package publisher implements
+// github.com/the-anna-project/instrumentor.Publisher and provides
+// instrumentation primitives to emit application metrics.
+package publisher
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/the-anna-project/instrumentor/internal/metric"
+	"github.com/the-anna-project/instrumentor/spec"
+)
+
+// ServiceConfig represents the configuration used to create a new StatsD
+// publisher service.
+type ServiceConfig struct {
+	// Settings.
+
+	// Address represents the UDP address of the StatsD agent metrics are sent
+	// to.
+	Address string
+	// ConstLabels represents labels having the same value for all metrics
+	// created by the service, e.g. the name of the service or its version.
+	ConstLabels map[string]string
+	// FlushInterval represents the interval in which buffered metrics are sent
+	// to the StatsD agent.
+	FlushInterval time.Duration
+	// MaxPacketSize represents the maximum number of bytes sent within a single
+	// UDP packet.
+	MaxPacketSize int
+	// PanicMode describes how panics of wrapped actions are handled. It is one
+	// of spec.PanicModeNone, spec.PanicModeRecover or spec.PanicModeRepanic.
+	PanicMode string
+	Prefixes  []string
+	// SanitizeKeys causes NewKey to replace all characters not being allowed in
+	// metric names by underscores, e.g. in case keys are built from dynamic
+	// input. Metric names and label names are validated either way. Invalid
+	// ones cause errors asserted by IsInvalidName.
+	SanitizeKeys bool
+}
+
+// DefaultServiceConfig provides a default configuration to create a new StatsD
+// publisher service by best effort.
+func DefaultServiceConfig() ServiceConfig {
+	clientConfig := DefaultClientConfig()
+
+	return ServiceConfig{
+		// Settings.
+		Address:       clientConfig.Address,
+		ConstLabels:   map[string]string{},
+		FlushInterval: time.Second,
+		MaxPacketSize: clientConfig.MaxPacketSize,
+		PanicMode:     spec.PanicModeNone,
+		Prefixes:      []string{},
+		SanitizeKeys:  false,
+	}
+}
+
+// NewService creates a new StatsD publisher service.
+func NewService(config ServiceConfig) (*Service, error) {
+	// Settings.
+	if config.ConstLabels == nil {
+		return nil, maskAnyf(invalidConfigError, "const labels must not be empty")
+	}
+	err := metric.ValidateConstLabels(config.ConstLabels)
+	if err != nil {
+		return nil, maskAny(err)
+	}
+	if config.FlushInterval <= 0 {
+		return nil, maskAnyf(invalidConfigError, "flush interval must be greater than 0")
+	}
+	if config.PanicMode != spec.PanicModeNone && config.PanicMode != spec.PanicModeRecover && config.PanicMode != spec.PanicModeRepanic {
+		return nil, maskAnyf(invalidConfigError, "panic mode must be one of: %s, %s, %s", spec.PanicModeNone, spec.PanicModeRecover, spec.PanicModeRepanic)
+	}
+	if config.Prefixes == nil {
+		return nil, maskAnyf(invalidConfigError, "prefixes must not be empty")
+	}
+
+	constLabels := map[string]string{}
+	for n, v := range config.ConstLabels {
+		constLabels[n] = v
+	}
+
+	var newClient *Client
+	{
+		clientConfig := DefaultClientConfig()
+		clientConfig.Address = config.Address
+		clientConfig.ConstLabels = constLabels
+		clientConfig.MaxPacketSize = config.MaxPacketSize
+		newClient, err = NewClient(clientConfig)
+		if err != nil {
+			return nil, maskAny(err)
+		}
+	}
+
+	newService := &Service{
+		// Internals.
+		client:       newClient,
+		closer:       make(chan struct{}, 1),
+		counters:     map[string]*Counter{},
+		bootOnce:     sync.Once{},
+		definitions:  map[string]metric.Definition{},
+		gauges:       map[string]*Gauge{},
+		histograms:   map[string]*Histogram{},
+		mutex:        sync.Mutex{},
+		shutdownOnce: sync.Once{},
+		summaries:    map[string]*Summary{},
+
+		// Settings.
+		constLabels:   constLabels,
+		flushInterval: config.FlushInterval,
+		panicMode:     config.PanicMode,
+		prefixes:      config.Prefixes,
+		sanitizeKeys:  config.SanitizeKeys,
+	}
+
+	return newService, nil
+}
+
+type Service struct {
+	// Internals.
+	client       *Client
+	closer       chan struct{}
+	counters     map[string]*Counter
+	bootOnce     sync.Once
+	definitions  map[string]metric.Definition
+	gauges       map[string]*Gauge
+	histograms   map[string]*Histogram
+	mutex        sync.Mutex
+	shutdownOnce sync.Once
+	summaries    map[string]*Summary
+
+	// Settings.
+
+	// constLabels represents labels having the same value for all metrics
+	// created by the service. The client adds them as DogStatsD tags to every
+	// line it sends.
+	constLabels map[string]string
+	// flushInterval represents the interval in which buffered metrics are sent
+	// to the StatsD agent.
+	flushInterval time.Duration
+	// panicMode describes how panics of wrapped actions are handled.
+	panicMode string
+	// prefixes represents the Instrumentor's ordered prefixes.
+	prefixes []string
+	// sanitizeKeys describes whether NewKey sanitizes the keys it returns.
+	sanitizeKeys bool
+}
+
+func (s *Service) Boot() {
+	s.bootOnce.Do(func() {
+		go func() {
+			ticker := time.NewTicker(s.flushInterval)
+			defer ticker.Stop()
+
+			for {
+				select {
+				case <-s.closer:
+					return
+				case <-ticker.C:
+					// There is no way to report errors of the background flush. Lines
+					// failing to be sent are dropped, which is the nature of StatsD
+					// anyway.
+					s.client.Flush()
+				}
+			}
+		}()
+	})
+}
+
+func (s *Service) Counter(config spec.CounterConfig) (spec.Counter, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	newDefinition := metric.Definition{
+		Help:   config.Help(),
+		Kind:   metric.KindCounter,
+		Labels: config.Labels(),
+		Name:   config.Name(),
+	}
+	d, ok := s.definitions[config.Name()]
+	err := d.Check(newDefinition)
+	if err != nil {
+		return nil, maskAny(err)
+	}
+	if ok {
+		return s.counters[config.Name()], nil
+	}
+
+	newCounter, err := newCounterWithConstLabels(config, s.constLabels)
+	if err != nil {
+		return nil, maskAny(err)
+	}
+
+	newCounter.Client = s.client
+	s.definitions[config.Name()] = newDefinition
+	s.counters[config.Name()] = newCounter
+
+	return newCounter, nil
+}
+
+func (s *Service) CounterConfig() spec.CounterConfig {
+	return DefaultCounterConfig()
+}
+
+func (s *Service) Gauge(config spec.GaugeConfig) (spec.Gauge, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	newDefinition := metric.Definition{
+		Help:   config.Help(),
+		Kind:   metric.KindGauge,
+		Labels: config.Labels(),
+		Name:   config.Name(),
+	}
+	d, ok := s.definitions[config.Name()]
+	err := d.Check(newDefinition)
+	if err != nil {
+		return nil, maskAny(err)
+	}
+	if ok {
+		return s.gauges[config.Name()], nil
+	}
+
+	newGauge, err := newGaugeWithConstLabels(config, s.constLabels)
+	if err != nil {
+		return nil, maskAny(err)
+	}
+
+	newGauge.Client = s.client
+	s.definitions[config.Name()] = newDefinition
+	s.gauges[config.Name()] = newGauge
+
+	return newGauge, nil
+}
+
+func (s *Service) GaugeConfig() spec.GaugeConfig {
+	return DefaultGaugeConfig()
+}
+
+func (s *Service) Histogram(config spec.HistogramConfig) (spec.Histogram, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	// The name of the histogram has its unit appended, so that histograms are
+	// looked up by the name they are actually reported with.
+	name := metric.UnitName(config.Name(), config.Unit())
+
+	newDefinition := metric.Definition{
+		Buckets:               config.Buckets(),
+		Help:                  config.Help(),
+		Kind:                  metric.KindHistogram,
+		Labels:                config.Labels(),
+		Name:                  name,
+		NativeBucketFactor:    config.NativeBucketFactor(),
+		NativeMaxBucketNumber: config.NativeMaxBucketNumber(),
+		NativeZeroThreshold:   config.NativeZeroThreshold(),
+	}
+	d, ok := s.definitions[name]
+	err := d.Check(newDefinition)
+	if err != nil {
+		return nil, maskAny(err)
+	}
+	if ok {
+		return s.histograms[name], nil
+	}
+
+	newHistogram, err := newHistogramWithConstLabels(config, s.constLabels)
+	if err != nil {
+		return nil, maskAny(err)
+	}
+
+	newHistogram.Client = s.client
+	s.definitions[name] = newDefinition
+	s.histograms[name] = newHistogram
+
+	return newHistogram, nil
+}
+
+func (s *Service) HistogramConfig() spec.HistogramConfig {
+	return DefaultHistogramConfig()
+}
+
+func (s *Service) HTTPEndpoint() string {
+	return ""
+}
+
+func (s *Service) HTTPHandler() http.Handler {
+	return nil
+}
+
+func (s *Service) Prefixes() []string {
+	return s.prefixes
+}
+
+func (s *Service) NewKey(str ...string) string {
+	key := strings.Join(append(append([]string{}, s.prefixes...), str...), "_")
+	if s.sanitizeKeys {
+		key = metric.SanitizeName(key)
+	}
+
+	return key
+}
+
+func (s *Service) Shutdown() {
+	s.shutdownOnce.Do(func() {
+		close(s.closer)
+		// Metrics buffered since the last flush are sent before the connection is
+		// closed.
+		s.client.Close()
+	})
+}
+
+func (s *Service) Summary(config spec.SummaryConfig) (spec.Summary, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	newDefinition := metric.Definition{
+		AgeBuckets: config.AgeBuckets(),
+		Help:       config.Help(),
+		Kind:       metric.KindSummary,
+		Labels:     config.Labels(),
+		MaxAge:     config.MaxAge(),
+		Name:       config.Name(),
+		Objectives: config.Objectives(),
+	}
+	d, ok := s.definitions[config.Name()]
+	err := d.Check(newDefinition)
+	if err != nil {
+		return nil, maskAny(err)
+	}
+	if ok {
+		return s.summaries[config.Name()], nil
+	}
+
+	newSummary, err := newSummaryWithConstLabels(config, s.constLabels)
+	if err != nil {
+		return nil, maskAny(err)
+	}
+
+	newSummary.Client = s.client
+	s.definitions[config.Name()] = newDefinition
+	s.summaries[config.Name()] = newSummary
+
+	return newSummary, nil
+}
+
+func (s *Service) SummaryConfig() spec.SummaryConfig {
+	return DefaultSummaryConfig()
+}
+
+func (s *Service) WrapContextFunc(key string, labels map[string]string, action func(ctx context.Context) error) func(ctx context.Context) error {
+	return metric.WrapContextFunc(s, s.panicMode, key, labels, action)
+}
+
+func (s *Service) WrapFunc(key string, action func() error) func() error {
+	return metric.WrapFunc(s, s.panicMode, key, action)
+}
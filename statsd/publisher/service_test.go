@@ -0,0 +1,267 @@
+package publisher
+
+import (
+	"fmt"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/the-anna-project/instrumentor/spec"
+)
+
+// newTestService creates a StatsD publisher service sending to a UDP listener
+// on a random local port. The returned function shuts the service down and
+// returns all lines the listener received.
+func newTestService(t *testing.T) (*Service, func() []string) {
+	l, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	config := DefaultServiceConfig()
+	config.Address = l.LocalAddr().String()
+	config.Prefixes = []string{"app"}
+	s, err := NewService(config)
+	if err != nil {
+		l.Close()
+		t.Fatal(err)
+	}
+
+	received := func() []string {
+		defer l.Close()
+
+		// Shutdown sends all buffered lines before the connection is closed.
+		s.Shutdown()
+
+		var lines []string
+		b := make([]byte, 2048)
+		for {
+			l.SetReadDeadline(time.Now().Add(100 * time.Millisecond))
+			n, _, err := l.ReadFrom(b)
+			if err != nil {
+				return lines
+			}
+			lines = append(lines, strings.Split(string(b[:n]), "\n")...)
+		}
+	}
+
+	return s, received
+}
+
+func TestService_Counter(t *testing.T) {
+	s, received := newTestService(t)
+
+	config := s.CounterConfig()
+	config.SetHelp("Number of requests.")
+	config.SetLabels([]string{"method"})
+	config.SetName(s.NewKey("requests", "total"))
+	c, err := s.Counter(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = c.IncrementWithLabels(2, "GET")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = c.IncrementWithLabels(-1, "GET")
+	if !IsInvalidConfig(err) {
+		t.Fatalf("expected invalid config error for negative delta, got %v", err)
+	}
+
+	lines := received()
+	want := []string{"app_requests_total:2|c|#method:GET"}
+	if strings.Join(lines, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("expected lines %q, got %q", want, lines)
+	}
+}
+
+func TestService_Gauge(t *testing.T) {
+	s, received := newTestService(t)
+
+	config := s.GaugeConfig()
+	config.SetHelp("Number of workers.")
+	config.SetName(s.NewKey("workers"))
+	g, err := s.Gauge(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = g.Set(-3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = g.Increment(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Negative values cannot be set directly, since StatsD would interpret them
+	// as a decrement. The gauge is therefore reset to 0 first.
+	lines := received()
+	want := []string{"app_workers:0|g", "app_workers:-3|g", "app_workers:+1|g"}
+	if strings.Join(lines, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("expected lines %q, got %q", want, lines)
+	}
+}
+
+func TestService_Histogram(t *testing.T) {
+	testCases := []struct {
+		Name   string
+		Unit   string
+		Sample float64
+		Want   string
+	}{
+		{
+			Name:   "size",
+			Unit:   "",
+			Sample: 0.25,
+			Want:   "app_size:0.25|h",
+		},
+		{
+			Name:   "duration",
+			Unit:   spec.UnitMilliseconds,
+			Sample: 250,
+			Want:   "app_duration_milliseconds:250|ms",
+		},
+		{
+			Name:   "duration",
+			Unit:   spec.UnitSeconds,
+			Sample: 0.25,
+			Want:   "app_duration_seconds:0.25|h",
+		},
+	}
+
+	// Only milliseconds are sent as timings. Samples in seconds are sent as
+	// histograms, so their values match the unit suffix of their names.
+	for _, tc := range testCases {
+		s, received := newTestService(t)
+
+		config := s.HistogramConfig()
+		config.SetHelp("Observed samples.")
+		config.SetName(s.NewKey(tc.Name))
+		config.SetUnit(tc.Unit)
+		h, err := s.Histogram(config)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		err = h.Observe(tc.Sample)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		lines := received()
+		if len(lines) != 1 || lines[0] != tc.Want {
+			t.Fatalf("unit %q: expected line %q, got %q", tc.Unit, tc.Want, lines)
+		}
+	}
+}
+
+func TestService_Histogram_Timer(t *testing.T) {
+	s, received := newTestService(t)
+
+	config := s.HistogramConfig()
+	config.SetHelp("Duration of requests.")
+	config.SetName(s.NewKey("duration"))
+	config.SetUnit(spec.UnitSeconds)
+	h, err := s.Histogram(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	timer := h.StartTimer()
+	time.Sleep(10 * time.Millisecond)
+	timer.Stop()
+
+	// The timer observes the duration in seconds, which is sent as it is.
+	lines := received()
+	if len(lines) != 1 {
+		t.Fatalf("expected 1 line, got %q", lines)
+	}
+	var v float64
+	_, err = fmt.Sscanf(lines[0], "app_duration_seconds:%g|h", &v)
+	if err != nil {
+		t.Fatalf("expected histogram line in seconds, got %q", lines[0])
+	}
+	if v < 0.01 || v > 1 {
+		t.Fatalf("expected duration of at least 10ms in seconds, got %v", v)
+	}
+}
+
+func TestService_ConstLabels(t *testing.T) {
+	config := DefaultServiceConfig()
+	config.ConstLabels = map[string]string{"service": "api"}
+	config.Prefixes = []string{"app"}
+	s, err := NewService(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Shutdown()
+
+	// Labels of metrics must not be named like constant labels, since the
+	// resulting lines would have duplicate DogStatsD tags.
+	testCases := []struct {
+		Name   string
+		Create func(labels []string) error
+	}{
+		{
+			Name: "counter",
+			Create: func(labels []string) error {
+				config := s.CounterConfig()
+				config.SetHelp("Number of requests.")
+				config.SetLabels(labels)
+				config.SetName(s.NewKey("requests", "total"))
+				_, err := s.Counter(config)
+				return err
+			},
+		},
+		{
+			Name: "gauge",
+			Create: func(labels []string) error {
+				config := s.GaugeConfig()
+				config.SetHelp("Number of connections.")
+				config.SetLabels(labels)
+				config.SetName(s.NewKey("connections"))
+				_, err := s.Gauge(config)
+				return err
+			},
+		},
+		{
+			Name: "histogram",
+			Create: func(labels []string) error {
+				config := s.HistogramConfig()
+				config.SetBuckets([]float64{1, 2})
+				config.SetHelp("Duration of requests.")
+				config.SetLabels(labels)
+				config.SetName(s.NewKey("duration"))
+				config.SetUnit(spec.UnitSeconds)
+				_, err := s.Histogram(config)
+				return err
+			},
+		},
+		{
+			Name: "summary",
+			Create: func(labels []string) error {
+				config := s.SummaryConfig()
+				config.SetHelp("Size of responses.")
+				config.SetLabels(labels)
+				config.SetName(s.NewKey("size"))
+				_, err := s.Summary(config)
+				return err
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		err := tc.Create([]string{"method", "service"})
+		if !IsInvalidName(err) {
+			t.Fatalf("%s: expected invalid name error, got %v", tc.Name, err)
+		}
+		err = tc.Create([]string{"method"})
+		if err != nil {
+			t.Fatalf("%s: expected no error, got %v", tc.Name, err)
+		}
+	}
+}